@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -68,13 +71,82 @@ func main() {
 	txRepo := repositories.NewTransactionRepository(db)
 	riskScoreRepo := repositories.NewRiskScoreRepository(db)
 	auditRepo := repositories.NewAuditRepository(db)
+	ruleRepo := repositories.NewRuleRepository(db)
+	reasonCodeRepo := repositories.NewReasonCodeRepository(db)
+	decisionPolicyRepo := repositories.NewDecisionPolicyRepository(db)
+	geoLocationRepo := repositories.NewGeoLocationRepository(db)
+	deviceRepo := repositories.NewDeviceRepository(db)
+	merchantProfileRepo := repositories.NewMerchantProfileRepository(db)
+	counterpartyRepo := repositories.NewCounterpartyRepository(db)
+	peerGroupRepo := repositories.NewPeerGroupRepository(db)
+
+	// Load rules from the database and keep them fresh
+	rulesCtx, stopRuleReload := context.WithCancel(context.Background())
+	defer stopRuleReload()
+
+	ruleEngine := scoring.NewRuleEngine(cfg.Rules.ReloadPeriod)
+	if err := ruleEngine.LoadRulesFromDB(rulesCtx, ruleRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load rules from database, using built-in defaults")
+	}
+	ruleEngine.StartReloader(rulesCtx, ruleRepo)
+
+	reasonCodes := scoring.NewReasonCodeCatalog(cfg.ReasonCodes.Limit, cfg.ReasonCodes.Language, cfg.Rules.ReloadPeriod)
+	if err := reasonCodes.Load(rulesCtx, reasonCodeRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load reason codes from database, using built-in defaults")
+	}
+	reasonCodes.StartReloader(rulesCtx, reasonCodeRepo)
+
+	policyEngine := scoring.NewPolicyEngine(cfg.Rules.ReloadPeriod)
+	if err := policyEngine.LoadFromDB(rulesCtx, decisionPolicyRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load decision policy from database, using built-in default")
+	}
+	policyEngine.StartReloader(rulesCtx, decisionPolicyRepo)
+
+	gazetteer := scoring.NewGazetteer(cfg.Rules.ReloadPeriod)
+	if err := gazetteer.Load(rulesCtx, geoLocationRepo); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load geo locations from database")
+	}
+	gazetteer.StartReloader(rulesCtx, geoLocationRepo)
+
+	peerGroups := scoring.NewPeerGroups(cfg.Rules.ReloadPeriod)
+	if err := peerGroups.Load(rulesCtx, peerGroupRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load peer group baselines from database, peer features disabled")
+	}
+	peerGroups.StartReloader(rulesCtx, peerGroupRepo)
+	peerGroups.StartRebuildJob(rulesCtx, peerGroupRepo, cfg.PeerGroups.RebuildPeriod, cfg.PeerGroups.Window, cfg.PeerGroups.MinTransactions)
 
 	// Initialize services
 	jwtManager := auth.NewJWTManager(cfg.JWT.Secret, cfg.JWT.Expiration)
 	authService := services.NewAuthService(userRepo, jwtManager)
-	ingestionService := ingestion.NewIngestionService(txRepo, accountRepo, auditRepo, streamClient, cacheClient)
-	scoringEngine := scoring.NewScoringEngine(txRepo, accountRepo, riskScoreRepo, cacheClient)
+	ingestionService := ingestion.NewIngestionService(txRepo, accountRepo, auditRepo, deviceRepo, streamClient, cacheClient)
+	scoringEngine := scoring.NewScoringEngine(txRepo, accountRepo, riskScoreRepo, cacheClient, ruleEngine)
+	scoringEngine.SetReasonCodeCatalog(reasonCodes)
+	scoringEngine.SetPolicyEngine(policyEngine)
+	scoringEngine.SetGazetteer(gazetteer)
+	scoringEngine.SetDeviceRepository(deviceRepo)
+	scoringEngine.SetCounterpartyRepository(counterpartyRepo)
+	scoringEngine.SetMerchantReputation(scoring.NewMerchantReputation(merchantProfileRepo))
+	scoringEngine.SetPeerGroups(peerGroups)
+	scoringEngine.SetProbeThresholds(scoring.ProbeThresholds{
+		MaxAmount:   cfg.Probe.MaxAmount,
+		LargeAmount: cfg.Probe.LargeAmount,
+		Window:      cfg.Probe.Window,
+		MinProbes:   cfg.Probe.MinProbes,
+	})
+	if cfg.FeatureStore.Enabled {
+		featureStore := scoring.NewFeatureStore(cacheClient, txRepo)
+		scoringEngine.SetFeatureStore(featureStore)
+		ingestionService.SetFeatureStore(featureStore)
+	}
+	authorizationService := ingestion.NewAuthorizationService(ingestionService, scoringEngine, riskScoreRepo, cfg.Authorize.LatencyBudget)
 	analyticsService := analytics.NewAnalyticsService(txRepo, riskScoreRepo, accountRepo, db, cacheClient)
+	ruleService := services.NewRuleService(ruleRepo, auditRepo, ruleEngine)
+	ruleService.StartExpiryJob(rulesCtx, cfg.Rules.ExpiryCheckPeriod)
+	reasonCodeService := services.NewReasonCodeService(reasonCodeRepo, riskScoreRepo, txRepo, auditRepo, reasonCodes)
+	decisionPolicyService := services.NewDecisionPolicyService(decisionPolicyRepo, auditRepo, policyEngine)
+	locationRiskService := services.NewLocationRiskService(geoLocationRepo, auditRepo, gazetteer)
+	decisionService := services.NewDecisionService(txRepo, riskScoreRepo, merchantProfileRepo, auditRepo)
+	merchantService := services.NewMerchantService(merchantProfileRepo, txRepo, riskScoreRepo, auditRepo)
 
 	// Setup Gin router
 	if cfg.Server.Environment == "production" {
@@ -92,7 +164,7 @@ func main() {
 	router.Use(rateLimitMiddleware(rateLimiter))
 
 	// Setup routes
-	setupRoutes(router, jwtManager, authService, ingestionService, scoringEngine, analyticsService, streamClient, db, txRepo)
+	setupRoutes(router, jwtManager, authService, ingestionService, cfg.Ingestion.MaxWait, authorizationService, scoringEngine, analyticsService, ruleService, reasonCodeService, decisionPolicyService, locationRiskService, decisionService, merchantService, streamClient, db, txRepo)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -144,8 +216,16 @@ func setupRoutes(
 	jwtManager *auth.JWTManager,
 	authService *services.AuthService,
 	ingestionService *ingestion.IngestionService,
+	ingestMaxWait time.Duration,
+	authorizationService *ingestion.AuthorizationService,
 	scoringEngine *scoring.ScoringEngine,
 	analyticsService *analytics.AnalyticsService,
+	ruleService *services.RuleService,
+	reasonCodeService *services.ReasonCodeService,
+	decisionPolicyService *services.DecisionPolicyService,
+	locationRiskService *services.LocationRiskService,
+	decisionService *services.DecisionService,
+	merchantService *services.MerchantService,
 	streamClient *queue.RedisStreamClient,
 	db *repositories.Database,
 	txRepo *repositories.TransactionRepository,
@@ -176,12 +256,22 @@ func setupRoutes(
 	// Transaction routes
 	txRoutes := protected.Group("/transactions")
 	{
-		txRoutes.POST("", ingestTransactionHandler(ingestionService))
+		txRoutes.POST("", ingestTransactionHandler(ingestionService, ingestMaxWait))
 		txRoutes.POST("/batch", ingestBatchHandler(ingestionService))
+		txRoutes.POST("/authorize", authorizeTransactionHandler(authorizationService))
 		txRoutes.GET("/recent", getRecentTransactionsHandler(txRepo))
 		txRoutes.GET("/:id", getTransactionHandler(ingestionService))
 		txRoutes.GET("/account/:account_id", getAccountTransactionsHandler(ingestionService))
 		txRoutes.GET("/flagged", getFlaggedTransactionsHandler(analyticsService))
+
+		// Decision lifecycle: step-up results from callers, reviews by analysts
+		txRoutes.GET("/:id/decision", getTransactionDecisionHandler(decisionService))
+		txRoutes.POST("/:id/step-up", completeStepUpHandler(decisionService))
+		txRoutes.POST("/:id/review", auth.RoleMiddleware("admin", "analyst"), reviewTransactionHandler(decisionService))
+		txRoutes.POST("/:id/escalate", auth.RoleMiddleware("admin", "analyst"), escalateTransactionHandler(decisionService))
+
+		// Chargeback labels feed merchant reputation
+		txRoutes.POST("/:id/chargeback", auth.RoleMiddleware("admin", "analyst"), recordChargebackHandler(merchantService))
 	}
 
 	// Risk routes
@@ -191,6 +281,10 @@ func setupRoutes(
 		riskRoutes.GET("/account/:account_id", getAccountRiskHandler(analyticsService))
 		riskRoutes.GET("/distribution", getRiskDistributionHandler(analyticsService))
 		riskRoutes.GET("/rules/top", getTopRulesHandler(analyticsService))
+
+		explanationService := scoring.NewExplanationService(scoringEngine, ruleService)
+		riskRoutes.GET("/transactions/:id/explanation", getExplanationHandler(explanationService))
+		riskRoutes.GET("/transactions/:id/reasons", getDecisionReasonsHandler(reasonCodeService))
 	}
 
 	// Backtest routes (admin only)
@@ -217,6 +311,69 @@ func setupRoutes(
 		abTestRoutes.DELETE("/:id", deleteExperimentHandler(abManager))
 	}
 
+	// Rule management routes (admin only)
+	ruleRoutes := protected.Group("/rules")
+	ruleRoutes.Use(auth.RoleMiddleware("admin"))
+	{
+		ruleRoutes.GET("", listRulesHandler(ruleService))
+		ruleRoutes.POST("", createRuleHandler(ruleService))
+		ruleRoutes.GET("/:id", getRuleHandler(ruleService))
+		ruleRoutes.PUT("/:id", updateRuleHandler(ruleService))
+		ruleRoutes.POST("/:id/enable", setRuleEnabledHandler(ruleService, true))
+		ruleRoutes.POST("/:id/disable", setRuleEnabledHandler(ruleService, false))
+		ruleRoutes.POST("/test", testRulesHandler(ruleService))
+	}
+
+	// Rule-set version routes (admin only)
+	ruleSetRoutes := protected.Group("/rule-sets")
+	ruleSetRoutes.Use(auth.RoleMiddleware("admin"))
+	{
+		ruleSetRoutes.GET("", listRuleSetVersionsHandler(ruleService))
+		ruleSetRoutes.GET("/:version", getRuleSetVersionHandler(ruleService))
+		ruleSetRoutes.GET("/:version/diff/:other", diffRuleSetVersionsHandler(ruleService))
+		ruleSetRoutes.POST("/:version/rollback", rollbackRuleSetHandler(ruleService))
+		ruleSetRoutes.PUT("/aggregation", setRuleSetAggregationHandler(ruleService))
+	}
+
+	// Reason-code catalog routes (admin only)
+	reasonCodeRoutes := protected.Group("/reason-codes")
+	reasonCodeRoutes.Use(auth.RoleMiddleware("admin"))
+	{
+		reasonCodeRoutes.GET("", listReasonCodesHandler(reasonCodeService))
+		reasonCodeRoutes.GET("/:code", getReasonCodeHandler(reasonCodeService))
+		reasonCodeRoutes.PUT("/:code", saveReasonCodeHandler(reasonCodeService))
+		reasonCodeRoutes.DELETE("/:code", deleteReasonCodeHandler(reasonCodeService))
+	}
+
+	// Decision policy administration (admin only)
+	policyRoutes := protected.Group("/decision-policies")
+	policyRoutes.Use(auth.RoleMiddleware("admin"))
+	{
+		policyRoutes.GET("", listDecisionPoliciesHandler(decisionPolicyService))
+		policyRoutes.PUT("", publishDecisionPolicyHandler(decisionPolicyService))
+		policyRoutes.GET("/active", getActiveDecisionPolicyHandler(decisionPolicyService))
+		policyRoutes.GET("/:version", getDecisionPolicyHandler(decisionPolicyService))
+		policyRoutes.POST("/:version/rollback", rollbackDecisionPolicyHandler(decisionPolicyService))
+	}
+
+	// Location risk administration (admin only)
+	geoRoutes := protected.Group("/geo/countries")
+	geoRoutes.Use(auth.RoleMiddleware("admin"))
+	{
+		geoRoutes.GET("", listCountryRiskHandler(locationRiskService))
+		geoRoutes.PUT("/:code/risk", updateCountryRiskHandler(locationRiskService))
+	}
+
+	// Merchant reputation administration (admin only)
+	merchantRoutes := protected.Group("/merchants")
+	merchantRoutes.Use(auth.RoleMiddleware("admin"))
+	{
+		merchantRoutes.GET("", listMerchantProfilesHandler(merchantService))
+		merchantRoutes.GET("/:id", getMerchantProfileHandler(merchantService))
+		merchantRoutes.PUT("/:id/override", setMerchantOverrideHandler(merchantService))
+		merchantRoutes.DELETE("/:id/override", clearMerchantOverrideHandler(merchantService))
+	}
+
 	// Analytics routes
 	analyticsRoutes := protected.Group("/analytics")
 	{
@@ -236,6 +393,10 @@ func setupRoutes(
 		accountRoutes.GET("", listAccountsHandler(db))
 		accountRoutes.POST("", createAccountHandler(db))
 		accountRoutes.GET("/:id", getAccountHandler(db))
+		accountRoutes.GET("/:id/features", auth.RoleMiddleware("admin", "analyst"), getAccountFeaturesHandler(scoringEngine))
+		accountRoutes.POST("/:id/features/rebuild", auth.RoleMiddleware("admin"), rebuildAccountFeaturesHandler(scoringEngine))
+		accountRoutes.GET("/:id/devices", auth.RoleMiddleware("admin", "analyst"), getAccountDevicesHandler(repositories.NewDeviceRepository(db)))
+		accountRoutes.GET("/:id/network", auth.RoleMiddleware("admin", "analyst"), getAccountNetworkHandler(repositories.NewCounterpartyRepository(db)))
 	}
 }
 
@@ -435,8 +596,20 @@ func refreshTokenHandler(authService *services.AuthService) gin.HandlerFunc {
 	}
 }
 
-func ingestTransactionHandler(ingestionService *ingestion.IngestionService) gin.HandlerFunc {
+// ingestTransactionHandler ingests a transaction. With ?wait=<duration> (e.g. 800ms,
+// capped at maxWait) it blocks until the transaction is scored or the wait passes.
+func ingestTransactionHandler(ingestionService *ingestion.IngestionService, maxWait time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var wait time.Duration
+		if raw := c.Query("wait"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid wait duration"})
+				return
+			}
+			wait = min(parsed, maxWait)
+		}
+
 		var req ingestion.TransactionRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -444,7 +617,7 @@ func ingestTransactionHandler(ingestionService *ingestion.IngestionService) gin.
 		}
 
 		requestID := c.GetString("request_id")
-		resp, err := ingestionService.IngestTransaction(c.Request.Context(), &req, requestID)
+		resp, err := ingestionService.IngestTransactionAndWait(c.Request.Context(), &req, requestID, wait)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -454,6 +627,27 @@ func ingestTransactionHandler(ingestionService *ingestion.IngestionService) gin.
 	}
 }
 
+// authorizeTransactionHandler persists and scores a transaction inline, returning the
+// decision within the configured latency budget
+func authorizeTransactionHandler(authorizationService *ingestion.AuthorizationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ingestion.TransactionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		requestID := c.GetString("request_id")
+		resp, err := authorizationService.Authorize(c.Request.Context(), &req, requestID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 func ingestBatchHandler(ingestionService *ingestion.IngestionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req ingestion.BatchTransactionRequest
@@ -487,6 +681,111 @@ func getTransactionHandler(ingestionService *ingestion.IngestionService) gin.Han
 	}
 }
 
+func getTransactionDecisionHandler(decisionService *services.DecisionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		decision, err := decisionService.GetDecision(c.Request.Context(), txID)
+		if err != nil {
+			c.JSON(decisionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, decision)
+	}
+}
+
+func completeStepUpHandler(decisionService *services.DecisionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		var req struct {
+			Passed *bool `json:"passed" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		decision, err := decisionService.CompleteStepUp(c.Request.Context(), txID, *req.Passed, requestActor(c))
+		if err != nil {
+			c.JSON(decisionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, decision)
+	}
+}
+
+func reviewTransactionHandler(decisionService *services.DecisionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		var req services.ReviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		decision, err := decisionService.Review(c.Request.Context(), txID, &req, requestActor(c))
+		if err != nil {
+			c.JSON(decisionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, decision)
+	}
+}
+
+func escalateTransactionHandler(decisionService *services.DecisionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		var req struct {
+			Note string `json:"note"`
+		}
+		_ = c.ShouldBindJSON(&req) // the note is optional
+
+		decision, err := decisionService.Escalate(c.Request.Context(), txID, req.Note, requestActor(c))
+		if err != nil {
+			c.JSON(decisionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, decision)
+	}
+}
+
+// decisionErrorStatus maps decision service errors to HTTP status codes
+func decisionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidReviewDecision):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidStatusTransition), errors.Is(err, repositories.ErrTransactionStatusChanged):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func getAccountTransactionsHandler(ingestionService *ingestion.IngestionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		accountID := c.Param("account_id")
@@ -565,6 +864,58 @@ func getAccountRiskHandler(analyticsService *analytics.AnalyticsService) gin.Han
 	}
 }
 
+func getExplanationHandler(explanationService *scoring.ExplanationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		explanation, err := explanationService.ExplainTransaction(c.Request.Context(), txID)
+		if err != nil {
+			switch {
+			case errors.Is(err, repositories.ErrRiskScoreNotFound), errors.Is(err, repositories.ErrTransactionNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, explanation)
+	}
+}
+
+// getDecisionReasonsHandler returns a decision's reason codes in the language from
+// ?lang= or, failing that, the Accept-Language header
+func getDecisionReasonsHandler(reasonCodeService *services.ReasonCodeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		languages := scoring.ParseLanguages(c.Query("lang"))
+		if len(languages) == 0 {
+			languages = scoring.ParseLanguages(c.GetHeader("Accept-Language"))
+		}
+
+		reasons, err := reasonCodeService.GetDecisionReasons(c.Request.Context(), txID, languages)
+		if err != nil {
+			if errors.Is(err, repositories.ErrRiskScoreNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, reasons)
+	}
+}
+
 func getRecentTransactionsHandler(txRepo *repositories.TransactionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		page := getIntParam(c, "page", 1)
@@ -739,6 +1090,103 @@ func getAccountHandler(db *repositories.Database) gin.HandlerFunc {
 	}
 }
 
+// getAccountFeaturesHandler returns an account's current rolling aggregates from the online feature store
+func getAccountFeaturesHandler(scoringEngine *scoring.ScoringEngine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseUUID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+
+		featureStore := scoringEngine.GetFeatureStore()
+		if featureStore == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "online feature store is disabled"})
+			return
+		}
+
+		aggregates, err := featureStore.Aggregates(c.Request.Context(), id, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, aggregates)
+	}
+}
+
+// getAccountDevicesHandler returns the devices an account has transacted from
+func getAccountDevicesHandler(deviceRepo *repositories.DeviceRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseUUID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+
+		devices, err := deviceRepo.GetByAccountID(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"account_id": id, "devices": devices})
+	}
+}
+
+// getAccountNetworkHandler returns an account's ego-network in the counterparty graph:
+// the counterparties it has paid and the other accounts that paid them
+func getAccountNetworkHandler(counterpartyRepo *repositories.CounterpartyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseUUID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+
+		limit := min(getIntParam(c, "limit", 50), 200)
+		senderLimit := min(getIntParam(c, "sender_limit", 20), 100)
+
+		network, err := counterpartyRepo.GetEgoNetwork(c.Request.Context(), id, limit, senderLimit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, network)
+	}
+}
+
+// rebuildAccountFeaturesHandler rebuilds an account's history in the online feature store from Postgres
+func rebuildAccountFeaturesHandler(scoringEngine *scoring.ScoringEngine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := parseUUID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+
+		featureStore := scoringEngine.GetFeatureStore()
+		if featureStore == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "online feature store is disabled"})
+			return
+		}
+
+		if err := featureStore.Rebuild(c.Request.Context(), id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		aggregates, err := featureStore.Aggregates(c.Request.Context(), id, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, aggregates)
+	}
+}
+
 // Helper functions
 
 func getIntParam(c *gin.Context, key string, defaultValue int) int {
@@ -789,11 +1237,13 @@ func runBacktestHandler(backtestService *scoring.BacktestService) gin.HandlerFun
 func createExperimentHandler(abManager *scoring.ABTestManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			Name         string   `json:"name" binding:"required"`
-			Description  string   `json:"description"`
-			ControlRules []string `json:"control_rules"`
-			TestRules    []string `json:"test_rules"`
-			TrafficSplit float64  `json:"traffic_split" binding:"required,min=0,max=1"`
+			Name               string                   `json:"name" binding:"required"`
+			Description        string                   `json:"description"`
+			ControlRules       []string                 `json:"control_rules"`
+			TestRules          []string                 `json:"test_rules"`
+			ControlAggregation *models.ScoreAggregation `json:"control_aggregation"`
+			TestAggregation    *models.ScoreAggregation `json:"test_aggregation"`
+			TrafficSplit       float64                  `json:"traffic_split" binding:"required,min=0,max=1"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -802,11 +1252,13 @@ func createExperimentHandler(abManager *scoring.ABTestManager) gin.HandlerFunc {
 		}
 
 		exp := &scoring.Experiment{
-			Name:         req.Name,
-			Description:  req.Description,
-			ControlRules: req.ControlRules,
-			TestRules:    req.TestRules,
-			TrafficSplit: req.TrafficSplit,
+			Name:               req.Name,
+			Description:        req.Description,
+			ControlRules:       req.ControlRules,
+			TestRules:          req.TestRules,
+			ControlAggregation: req.ControlAggregation,
+			TestAggregation:    req.TestAggregation,
+			TrafficSplit:       req.TrafficSplit,
 		}
 
 		if err := abManager.CreateExperiment(exp); err != nil {
@@ -930,3 +1382,547 @@ func deleteExperimentHandler(abManager *scoring.ABTestManager) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Experiment deleted"})
 	}
 }
+
+// Rule Handlers
+
+func listRulesHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := ruleService.ListRules(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"rules": rules})
+	}
+}
+
+func getRuleHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, err := ruleService.GetRule(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+func createRuleHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.RuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule, err := ruleService.CreateRule(c.Request.Context(), &req, requestActor(c))
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), ruleErrorBody(err))
+			return
+		}
+
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+func updateRuleHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.RuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule, err := ruleService.UpdateRule(c.Request.Context(), c.Param("id"), &req, requestActor(c))
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), ruleErrorBody(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+func testRulesHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := ruleService.TestRules(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func setRuleEnabledHandler(ruleService *services.RuleService, enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, err := ruleService.SetRuleEnabled(c.Request.Context(), c.Param("id"), enabled, requestActor(c))
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+func listRuleSetVersionsHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page := getIntParam(c, "page", 1)
+		pageSize := getIntParam(c, "page_size", 20)
+
+		versions, total, err := ruleService.ListRuleSetVersions(c.Request.Context(), page, pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"versions":       versions,
+			"active_version": ruleService.ActiveRuleSetVersion(),
+			"pagination": gin.H{
+				"page":      page,
+				"page_size": pageSize,
+				"total":     total,
+			},
+		})
+	}
+}
+
+func getRuleSetVersionHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return
+		}
+
+		ruleSet, err := ruleService.GetRuleSetVersion(c.Request.Context(), version)
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, ruleSet)
+	}
+}
+
+func diffRuleSetVersionsHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fromVersion, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return
+		}
+		toVersion, err := strconv.Atoi(c.Param("other"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return
+		}
+
+		diff, err := ruleService.DiffRuleSetVersions(c.Request.Context(), fromVersion, toVersion)
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, diff)
+	}
+}
+
+func rollbackRuleSetHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return
+		}
+
+		ruleSet, err := ruleService.RollbackRuleSet(c.Request.Context(), version, requestActor(c))
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, ruleSet)
+	}
+}
+
+func setRuleSetAggregationHandler(ruleService *services.RuleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.ScoreAggregation
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ruleSet, err := ruleService.SetAggregation(c.Request.Context(), req, requestActor(c))
+		if err != nil {
+			c.JSON(ruleErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, ruleSet)
+	}
+}
+
+func listReasonCodesHandler(reasonCodeService *services.ReasonCodeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		codes, err := reasonCodeService.ListReasonCodes(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"reason_codes": codes})
+	}
+}
+
+func getReasonCodeHandler(reasonCodeService *services.ReasonCodeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rc, err := reasonCodeService.GetReasonCode(c.Request.Context(), strings.ToUpper(c.Param("code")))
+		if err != nil {
+			c.JSON(reasonCodeErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, rc)
+	}
+}
+
+func saveReasonCodeHandler(reasonCodeService *services.ReasonCodeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.ReasonCode
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Code = c.Param("code")
+
+		rc, err := reasonCodeService.SaveReasonCode(c.Request.Context(), req, requestActor(c))
+		if err != nil {
+			c.JSON(reasonCodeErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, rc)
+	}
+}
+
+func deleteReasonCodeHandler(reasonCodeService *services.ReasonCodeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := strings.ToUpper(c.Param("code"))
+		if err := reasonCodeService.DeleteReasonCode(c.Request.Context(), code, requestActor(c)); err != nil {
+			c.JSON(reasonCodeErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "reason code deleted"})
+	}
+}
+
+// reasonCodeErrorStatus maps reason code service errors to HTTP status codes
+func reasonCodeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, scoring.ErrInvalidReasonCode):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrReasonCodeNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func listCountryRiskHandler(locationRiskService *services.LocationRiskService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		countries, err := locationRiskService.ListCountries(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"countries": countries})
+	}
+}
+
+func updateCountryRiskHandler(locationRiskService *services.LocationRiskService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.CountryRiskRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		country, err := locationRiskService.UpdateCountryRisk(c.Request.Context(), c.Param("code"), &req, requestActor(c))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, scoring.ErrInvalidLocationRisk) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, country)
+	}
+}
+
+func listMerchantProfilesHandler(merchantService *services.MerchantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page := getIntParam(c, "page", 1)
+		pageSize := getIntParam(c, "page_size", 20)
+
+		profiles, total, err := merchantService.ListProfiles(c.Request.Context(), page, pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"merchants": profiles,
+			"pagination": gin.H{
+				"page":      page,
+				"page_size": pageSize,
+				"total":     total,
+			},
+		})
+	}
+}
+
+func getMerchantProfileHandler(merchantService *services.MerchantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant ID"})
+			return
+		}
+
+		profile, err := merchantService.GetProfile(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(merchantErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+func setMerchantOverrideHandler(merchantService *services.MerchantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant ID"})
+			return
+		}
+
+		var req services.MerchantOverrideRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		profile, err := merchantService.SetOverride(c.Request.Context(), id, &req, requestActor(c))
+		if err != nil {
+			c.JSON(merchantErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+func clearMerchantOverrideHandler(merchantService *services.MerchantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid merchant ID"})
+			return
+		}
+
+		profile, err := merchantService.ClearOverride(c.Request.Context(), id, requestActor(c))
+		if err != nil {
+			c.JSON(merchantErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+func recordChargebackHandler(merchantService *services.MerchantService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction ID"})
+			return
+		}
+
+		var req services.ChargebackRequest
+		_ = c.ShouldBindJSON(&req) // the chargeback time and reason are optional
+
+		profile, err := merchantService.RecordChargeback(c.Request.Context(), txID, &req, requestActor(c))
+		if err != nil {
+			c.JSON(merchantErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"transaction_id": txID,
+			"merchant":       profile,
+		})
+	}
+}
+
+// merchantErrorStatus maps merchant profile and chargeback errors to HTTP statuses
+func merchantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidMerchantOverride), errors.Is(err, services.ErrTransactionWithoutMerchant):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrMerchantProfileNotFound), errors.Is(err, repositories.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrChargebackRecorded), errors.Is(err, services.ErrTransactionNotScored):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func listDecisionPoliciesHandler(decisionPolicyService *services.DecisionPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page := getIntParam(c, "page", 1)
+		pageSize := getIntParam(c, "page_size", 20)
+
+		policies, total, err := decisionPolicyService.ListPolicyVersions(c.Request.Context(), page, pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"versions":       policies,
+			"active_version": decisionPolicyService.ActivePolicyVersion(),
+			"pagination": gin.H{
+				"page":      page,
+				"page_size": pageSize,
+				"total":     total,
+			},
+		})
+	}
+}
+
+func getActiveDecisionPolicyHandler(decisionPolicyService *services.DecisionPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy, err := decisionPolicyService.GetActivePolicy(c.Request.Context())
+		if err != nil {
+			c.JSON(decisionPolicyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	}
+}
+
+func getDecisionPolicyHandler(decisionPolicyService *services.DecisionPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return
+		}
+
+		policy, err := decisionPolicyService.GetPolicyVersion(c.Request.Context(), version)
+		if err != nil {
+			c.JSON(decisionPolicyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	}
+}
+
+func publishDecisionPolicyHandler(decisionPolicyService *services.DecisionPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.DecisionPolicyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		policy, err := decisionPolicyService.PublishPolicy(c.Request.Context(), &req, requestActor(c))
+		if err != nil {
+			c.JSON(decisionPolicyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	}
+}
+
+func rollbackDecisionPolicyHandler(decisionPolicyService *services.DecisionPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return
+		}
+
+		policy, err := decisionPolicyService.RollbackPolicy(c.Request.Context(), version, requestActor(c))
+		if err != nil {
+			c.JSON(decisionPolicyErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, policy)
+	}
+}
+
+// decisionPolicyErrorStatus maps decision policy service errors to HTTP status codes
+func decisionPolicyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, scoring.ErrInvalidDecisionPolicy):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrDecisionPolicyNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestActor collects the audit details of the user making a change
+func requestActor(c *gin.Context) services.Actor {
+	userID, _ := auth.GetUserIDFromContext(c)
+	return services.Actor{
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+	}
+}
+
+// ruleErrorBody includes the test report when a change is rejected by failing rule test cases
+func ruleErrorBody(err error) gin.H {
+	var testErr *scoring.RuleTestError
+	if errors.As(err, &testErr) {
+		return gin.H{"error": err.Error(), "report": testErr.Report}
+	}
+	return gin.H{"error": err.Error()}
+}
+
+// ruleErrorStatus maps rule service errors to HTTP status codes
+func ruleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, scoring.ErrInvalidRule), errors.Is(err, scoring.ErrInvalidAggregation):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrRuleNotFound), errors.Is(err, repositories.ErrRuleSetVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrRuleAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, scoring.ErrRuleTestsFailed):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
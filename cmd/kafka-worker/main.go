@@ -142,11 +142,11 @@ func (m *RealTimeMetrics) RecordEvent(event *AnalyticsEvent) {
 		m.StatusTransitions[transition]++
 
 		switch event.Status {
-		case "processed":
+		case "approved", "monitored":
 			m.TransactionsProcessed++
-		case "flagged":
+		case "step_up_pending", "review_pending":
 			m.TransactionsFlagged++
-		case "blocked":
+		case "declined":
 			m.TransactionsBlocked++
 		}
 	}
@@ -412,11 +412,12 @@ func (h *AnalyticsPipelineHandler) logEvent(event *AnalyticsEvent, msg *Debezium
 
 	case "transaction_updated":
 		icon := "📝"
-		if event.Status == "flagged" {
+		switch event.Status {
+		case "step_up_pending", "review_pending":
 			icon = "🚨"
-		} else if event.Status == "blocked" {
+		case "declined":
 			icon = "🛑"
-		} else if event.Status == "processed" {
+		case "approved", "monitored":
 			icon = "✅"
 		}
 
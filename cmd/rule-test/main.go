@@ -0,0 +1,88 @@
+// Command rule-test runs every rule's regression test cases, either against the
+// rules stored in the database or against a JSON file of rules (e.g. the output of
+// GET /api/v1/rules with local edits). It exits non-zero when any case fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/enterprise/risk-engine/configs"
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+func main() {
+	file := flag.String("file", "", "JSON array of rules to test instead of the database rules")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	// Load .env file if exists
+	_ = godotenv.Load()
+
+	rules, err := loadRules(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rule-test: %v\n", err)
+		os.Exit(2)
+	}
+
+	report := scoring.RunRuleTests(rules)
+
+	if *jsonOutput {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printReport(report)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func loadRules(file string) ([]models.Rule, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var rules []models.Rule
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		return rules, nil
+	}
+
+	cfg := configs.Load()
+	db, err := repositories.NewDatabase(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return repositories.NewRuleRepository(db).GetAll(ctx)
+}
+
+func printReport(report *scoring.RuleTestReport) {
+	for _, f := range report.Failures {
+		switch {
+		case f.Error != "":
+			fmt.Printf("FAIL %s: %s\n", f.RuleID, f.Error)
+		case f.ExpectFire:
+			fmt.Printf("FAIL %s / %s: expected to fire, did not\n", f.RuleID, f.Case)
+		default:
+			fmt.Printf("FAIL %s / %s: expected not to fire, fired\n", f.RuleID, f.Case)
+		}
+	}
+	fmt.Printf("%d rules, %d cases passed, %d failed\n", report.Rules, report.Passed, report.Failed)
+}
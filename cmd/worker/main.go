@@ -56,9 +56,72 @@ func main() {
 	txRepo := repositories.NewTransactionRepository(db)
 	accountRepo := repositories.NewAccountRepository(db)
 	riskScoreRepo := repositories.NewRiskScoreRepository(db)
+	ruleRepo := repositories.NewRuleRepository(db)
+	reasonCodeRepo := repositories.NewReasonCodeRepository(db)
+	decisionPolicyRepo := repositories.NewDecisionPolicyRepository(db)
+	geoLocationRepo := repositories.NewGeoLocationRepository(db)
+	deviceRepo := repositories.NewDeviceRepository(db)
+	merchantProfileRepo := repositories.NewMerchantProfileRepository(db)
+	counterpartyRepo := repositories.NewCounterpartyRepository(db)
+	peerGroupRepo := repositories.NewPeerGroupRepository(db)
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Load rules from the database and keep them fresh
+	ruleEngine := scoring.NewRuleEngine(cfg.Rules.ReloadPeriod)
+	if err := ruleEngine.LoadRulesFromDB(ctx, ruleRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load rules from database, using built-in defaults")
+	}
+	ruleEngine.StartReloader(ctx, ruleRepo)
+
+	// Load the reason-code catalog alongside the rules
+	reasonCodes := scoring.NewReasonCodeCatalog(cfg.ReasonCodes.Limit, cfg.ReasonCodes.Language, cfg.Rules.ReloadPeriod)
+	if err := reasonCodes.Load(ctx, reasonCodeRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load reason codes from database, using built-in defaults")
+	}
+	reasonCodes.StartReloader(ctx, reasonCodeRepo)
+
+	// Load the decision policy that maps scores to risk levels and actions
+	policyEngine := scoring.NewPolicyEngine(cfg.Rules.ReloadPeriod)
+	if err := policyEngine.LoadFromDB(ctx, decisionPolicyRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load decision policy from database, using built-in default")
+	}
+	policyEngine.StartReloader(ctx, decisionPolicyRepo)
+
+	// Load the gazetteer that resolves transaction locations for travel features
+	gazetteer := scoring.NewGazetteer(cfg.Rules.ReloadPeriod)
+	if err := gazetteer.Load(ctx, geoLocationRepo); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load geo locations from database")
+	}
+	gazetteer.StartReloader(ctx, geoLocationRepo)
+
+	// Load the peer-group baselines rebuilt by the API server
+	peerGroups := scoring.NewPeerGroups(cfg.Rules.ReloadPeriod)
+	if err := peerGroups.Load(ctx, peerGroupRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to load peer group baselines from database, peer features disabled")
+	}
+	peerGroups.StartReloader(ctx, peerGroupRepo)
 
 	// Initialize scoring engine
-	scoringEngine := scoring.NewScoringEngine(txRepo, accountRepo, riskScoreRepo, cacheClient)
+	scoringEngine := scoring.NewScoringEngine(txRepo, accountRepo, riskScoreRepo, cacheClient, ruleEngine)
+	scoringEngine.SetReasonCodeCatalog(reasonCodes)
+	scoringEngine.SetPolicyEngine(policyEngine)
+	scoringEngine.SetGazetteer(gazetteer)
+	scoringEngine.SetDeviceRepository(deviceRepo)
+	scoringEngine.SetCounterpartyRepository(counterpartyRepo)
+	scoringEngine.SetMerchantReputation(scoring.NewMerchantReputation(merchantProfileRepo))
+	scoringEngine.SetPeerGroups(peerGroups)
+	scoringEngine.SetProbeThresholds(scoring.ProbeThresholds{
+		MaxAmount:   cfg.Probe.MaxAmount,
+		LargeAmount: cfg.Probe.LargeAmount,
+		Window:      cfg.Probe.Window,
+		MinProbes:   cfg.Probe.MinProbes,
+	})
+	if cfg.FeatureStore.Enabled {
+		scoringEngine.SetFeatureStore(scoring.NewFeatureStore(cacheClient, txRepo))
+	}
 
 	// Create worker pool
 	workerPool := scoring.NewWorkerPool(
@@ -68,10 +131,6 @@ func main() {
 		cfg.Worker,
 	)
 
-	// Setup context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Handle shutdown signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
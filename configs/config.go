@@ -7,11 +7,18 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Worker   WorkerConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	JWT          JWTConfig
+	Worker       WorkerConfig
+	Rules        RulesConfig
+	ReasonCodes  ReasonCodesConfig
+	Authorize    AuthorizeConfig
+	Ingestion    IngestionConfig
+	FeatureStore FeatureStoreConfig
+	PeerGroups   PeerGroupsConfig
+	Probe        ProbeConfig
 }
 
 type ServerConfig struct {
@@ -48,6 +55,41 @@ type WorkerConfig struct {
 	DeadLetterStream string
 }
 
+type RulesConfig struct {
+	ReloadPeriod      time.Duration
+	ExpiryCheckPeriod time.Duration
+}
+
+type ReasonCodesConfig struct {
+	Limit    int    // reason codes returned with each decision
+	Language string // language of the messages stored with each decision
+}
+
+type AuthorizeConfig struct {
+	LatencyBudget time.Duration // time allowed for inline scoring before the fallback decision
+}
+
+type IngestionConfig struct {
+	MaxWait time.Duration // longest ?wait= a client may block for a decision
+}
+
+type FeatureStoreConfig struct {
+	Enabled bool // compute rolling account features from Redis instead of Postgres
+}
+
+type PeerGroupsConfig struct {
+	RebuildPeriod   time.Duration // how often baselines are rebuilt; 0 disables rebuilding
+	Window          time.Duration // trailing window of transactions the baselines cover
+	MinTransactions int           // smallest peer group kept
+}
+
+type ProbeConfig struct {
+	MaxAmount   float64       // largest amount counted as a card-testing probe
+	LargeAmount float64       // smallest amount a probe pattern leads up to
+	Window      time.Duration // how far back probes are counted
+	MinProbes   int           // probes needed for a probe pattern
+}
+
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
@@ -79,6 +121,34 @@ func Load() *Config {
 			RetryAttempts:    getIntEnv("WORKER_RETRY_ATTEMPTS", 3),
 			DeadLetterStream: getEnv("DEAD_LETTER_STREAM", "transactions-dlq"),
 		},
+		Rules: RulesConfig{
+			ReloadPeriod:      getDurationEnv("RULE_RELOAD_PERIOD", 30*time.Second),
+			ExpiryCheckPeriod: getDurationEnv("RULE_EXPIRY_CHECK_PERIOD", time.Minute),
+		},
+		ReasonCodes: ReasonCodesConfig{
+			Limit:    getIntEnv("REASON_CODE_LIMIT", 4),
+			Language: getEnv("REASON_CODE_LANGUAGE", "en"),
+		},
+		Authorize: AuthorizeConfig{
+			LatencyBudget: getDurationEnv("AUTHORIZE_LATENCY_BUDGET", 200*time.Millisecond),
+		},
+		Ingestion: IngestionConfig{
+			MaxWait: getDurationEnv("INGEST_MAX_WAIT", 5*time.Second),
+		},
+		FeatureStore: FeatureStoreConfig{
+			Enabled: getBoolEnv("FEATURE_STORE_ENABLED", true),
+		},
+		PeerGroups: PeerGroupsConfig{
+			RebuildPeriod:   getDurationEnv("PEER_GROUP_REBUILD_PERIOD", time.Hour),
+			Window:          getDurationEnv("PEER_GROUP_WINDOW", 90*24*time.Hour),
+			MinTransactions: getIntEnv("PEER_GROUP_MIN_TRANSACTIONS", 50),
+		},
+		Probe: ProbeConfig{
+			MaxAmount:   getFloatEnv("PROBE_MAX_AMOUNT", 5),
+			LargeAmount: getFloatEnv("PROBE_LARGE_AMOUNT", 1000),
+			Window:      getDurationEnv("PROBE_WINDOW", 10*time.Minute),
+			MinProbes:   getIntEnv("PROBE_MIN_COUNT", 1),
+		},
 	}
 }
 
@@ -98,6 +168,24 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -490,16 +490,20 @@ async function loadTopRules() {
         const safeDenom = flaggedTotal > 0 ? flaggedTotal : 1;
         
         tbody.innerHTML = rules.map(rule => {
-            // Percentage of flagged/blocked transactions that had this rule
-            const rawPercent = (rule.count / safeDenom) * 100;
+            // Live rules: percentage of flagged/blocked transactions that had this rule.
+            // Shadow rules: hit rate over all scored transactions.
+            const rawPercent = rule.shadow
+                ? (rule.hit_rate || 0) * 100
+                : (rule.count / safeDenom) * 100;
             const intensity = Math.max(0, Math.min(100, Math.round(rawPercent)));
+            const scope = rule.shadow ? 'scored transactions hit this shadow rule' : 'flagged/blocked transactions had this rule';
 
             return `
             <tr>
-                <td><code class="mono">${rule.rule_id}</code></td>
+                <td><code class="mono">${rule.rule_id}</code>${rule.shadow ? ' <span class="rule-tag">shadow</span>' : ''}</td>
                 <td class="mono">${formatNumber(rule.count)}</td>
                 <td>
-                    <div class="trend-row" title="${intensity}% of flagged/blocked transactions had this rule in last 7 days">
+                    <div class="trend-row" title="${intensity}% of ${scope} in last 7 days">
                         <div class="trend-bar">
                             <div class="trend-fill" style="width: ${intensity}%;"></div>
                         </div>
@@ -624,7 +628,7 @@ async function loadRecentTransactions() {
                     </td>
                     <td class="mono">${formatCurrency(tx.amount || 0, tx.currency || 'USD')}</td>
                     <td>${tx.merchant || '--'}</td>
-                    <td><span class="status-badge ${tx.status || 'pending'}">${tx.status || 'pending'}</span></td>
+                    <td><span class="status-badge ${tx.status || 'pending'}">${(tx.status || 'pending').replace(/_/g, ' ')}</span></td>
                     <td class="text-muted text-sm">${formatTime(tx.created_at)}</td>
                 </tr>
             `).join('');
@@ -725,7 +729,7 @@ async function searchTransactions() {
                 </td>
                 <td class="mono">${formatCurrency(tx.amount || 0, tx.currency || 'USD')}</td>
                 <td>${tx.merchant || '--'}</td>
-                <td><span class="status-badge ${tx.status || 'pending'}">${tx.status || 'pending'}</span></td>
+                <td><span class="status-badge ${tx.status || 'pending'}">${(tx.status || 'pending').replace(/_/g, ' ')}</span></td>
                 <td class="text-muted text-sm">${formatTime(tx.created_at)}</td>
             </tr>
         `).join('');
@@ -0,0 +1,47 @@
+-- Migration: 005_seed_pattern_rules
+-- Description: Move the remaining hard-coded pattern rules into the rules table
+-- Created: 2026-10-16
+
+BEGIN;
+
+INSERT INTO rules (id, name, description, condition, score_impact, risk_level, priority, enabled) VALUES
+('RULE_GEO_IMPOSSIBLE_TRAVEL', 'Impossible Travel', 'Location unreachable since the previous transaction (faster than 900 km/h)',
+ '{"type": "threshold", "field": "implied_speed_kmh", "operator": ">", "value": 900}',
+ 40.0, 'critical', 3, true),
+
+('RULE_SEQUENCE_EXFIL_PATTERN', 'Sequence Exfiltration Pattern', 'Small probe transactions followed by a large transaction',
+ '{"type": "compound", "operator": "AND", "conditions": [{"type": "threshold", "field": "follows_probe_pattern", "operator": "=", "value": true}, {"type": "threshold", "field": "amount", "operator": ">", "value": 1000}]}',
+ 35.0, 'high', 8, true),
+
+('RULE_SHARED_BENEFICIARY_NETWORK', 'Shared Beneficiary Network', 'Multiple accounts sending to the same beneficiary (mule account pattern)',
+ '{"type": "threshold", "field": "shared_beneficiary_count", "operator": ">", "value": 3}',
+ 30.0, 'high', 12, true),
+
+('RULE_RAPID_DEVICE_SWITCH', 'Rapid Device Switch', 'High-value transaction from a new device',
+ '{"type": "compound", "operator": "AND", "conditions": [{"type": "threshold", "field": "is_new_device", "operator": "=", "value": true}, {"type": "threshold", "field": "amount", "operator": ">", "value": 500}]}',
+ 25.0, 'medium', 18, true),
+
+('RULE_PEER_GROUP_ANOMALY', 'Peer Group Anomaly', 'Spend deviates more than 3 standard deviations from similar accounts',
+ '{"type": "threshold", "field": "peer_group_deviation", "operator": ">", "value": 3.0}',
+ 25.0, 'medium', 22, true),
+
+('RULE_BEHAVIORAL_ANOMALY', 'Behavioral Pattern Anomaly', 'Composite behavioral anomaly score exceeds threshold',
+ '{"type": "threshold", "field": "behavioral_anomaly_score", "operator": ">", "value": 50}',
+ 20.0, 'medium', 35, true),
+
+('RULE_RAPID_CHANNEL_SWITCH', 'Rapid Channel Switching', 'Switching between online, POS and ATM rapidly',
+ '{"type": "threshold", "field": "channel_switch_count", "operator": ">", "value": 3}',
+ 15.0, 'medium', 45, true)
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    condition = EXCLUDED.condition,
+    score_impact = EXCLUDED.score_impact,
+    risk_level = EXCLUDED.risk_level,
+    priority = EXCLUDED.priority,
+    updated_at = NOW();
+
+-- There is no is_cross_border feature; the rule could never fire and is rejected on load
+UPDATE rules SET enabled = false, updated_at = NOW() WHERE id = 'RULE_CROSS_BORDER';
+
+COMMIT;
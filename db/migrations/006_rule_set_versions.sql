@@ -0,0 +1,57 @@
+-- Migration: 006_rule_set_versions
+-- Description: Immutable, numbered rule-set versions and the version used for each risk score
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Every change to the rules table publishes a full snapshot of all rules as a new version.
+-- Versions are append-only; rollback publishes a copy of an earlier version.
+CREATE TABLE IF NOT EXISTS rule_set_versions (
+    version INTEGER PRIMARY KEY,
+    rules JSONB NOT NULL,
+    description TEXT,
+    rolled_back_from INTEGER REFERENCES rule_set_versions(version),
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_set_versions_created_at ON rule_set_versions(created_at DESC);
+
+CREATE OR REPLACE FUNCTION prevent_rule_set_version_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'rule_set_versions is append-only (version %)', OLD.version;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS rule_set_versions_immutable ON rule_set_versions;
+CREATE TRIGGER rule_set_versions_immutable
+    BEFORE UPDATE OR DELETE ON rule_set_versions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_rule_set_version_change();
+
+-- Publish the current rules as version 1
+INSERT INTO rule_set_versions (version, rules, description)
+SELECT 1,
+       COALESCE(jsonb_agg(jsonb_build_object(
+           'id', id,
+           'name', name,
+           'description', COALESCE(description, ''),
+           'condition', condition,
+           'score_impact', score_impact,
+           'risk_level', risk_level,
+           'priority', priority,
+           'enabled', enabled,
+           'created_at', created_at,
+           'updated_at', updated_at
+       ) ORDER BY priority, id), '[]'::jsonb),
+       'Initial rule set'
+FROM rules
+ON CONFLICT (version) DO NOTHING;
+
+-- Record the rule-set version that produced each score (NULL for built-in fallback rules)
+ALTER TABLE risk_scores ADD COLUMN IF NOT EXISTS rule_set_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_risk_scores_rule_set_version ON risk_scores(rule_set_version);
+
+COMMIT;
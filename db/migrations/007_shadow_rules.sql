@@ -0,0 +1,15 @@
+-- Migration: 007_shadow_rules
+-- Description: Shadow-mode rules that are evaluated and recorded without affecting the score
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- live rules contribute to the score; shadow rules are only recorded
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS mode VARCHAR(20) NOT NULL DEFAULT 'live'
+    CHECK (mode IN ('live', 'shadow'));
+
+ALTER TABLE risk_scores ADD COLUMN IF NOT EXISTS shadow_rules_triggered TEXT[] DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_risk_scores_shadow_rules_triggered ON risk_scores USING GIN(shadow_rules_triggered);
+
+COMMIT;
@@ -0,0 +1,23 @@
+-- Migration: 008_score_aggregation
+-- Description: Rule groups and a per-rule-set score aggregation strategy
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Triggered rules sharing a group contribute at most once (the largest impact)
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS rule_group VARCHAR(100);
+
+-- Rule-set settings that are published with every version (single row)
+CREATE TABLE IF NOT EXISTS rule_set_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    aggregation JSONB NOT NULL DEFAULT '{"strategy": "sum_capped"}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO rule_set_settings (id) VALUES (TRUE) ON CONFLICT (id) DO NOTHING;
+
+-- Existing versions were scored with the capped sum
+ALTER TABLE rule_set_versions
+    ADD COLUMN IF NOT EXISTS aggregation JSONB NOT NULL DEFAULT '{"strategy": "sum_capped"}';
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Migration: 009_rule_schedules
+-- Description: Rule effective windows and recurring schedules
+-- Created: 2026-10-16
+
+BEGIN;
+
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS effective_from TIMESTAMPTZ;
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS effective_until TIMESTAMPTZ;
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS schedule JSONB;
+
+ALTER TABLE rules DROP CONSTRAINT IF EXISTS rules_effective_window_check;
+ALTER TABLE rules ADD CONSTRAINT rules_effective_window_check
+    CHECK (effective_from IS NULL OR effective_until IS NULL OR effective_until > effective_from);
+
+-- The expiry job looks for enabled rules past their effective_until
+CREATE INDEX IF NOT EXISTS idx_rules_effective_until ON rules(effective_until)
+    WHERE enabled AND effective_until IS NOT NULL;
+
+COMMIT;
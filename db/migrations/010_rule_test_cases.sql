@@ -0,0 +1,10 @@
+-- Migration: 010_rule_test_cases
+-- Description: Per-rule regression test cases
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Array of {name, transaction, features, expect_fire}; run before every rule change is accepted
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS test_cases JSONB;
+
+COMMIT;
@@ -0,0 +1,86 @@
+-- Migration: 011_reason_codes
+-- Description: Customer-facing reason-code catalog and the reason codes of each risk score
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Catalog mapping rules and behavioral anomaly types to customer-facing codes.
+-- messages holds one template per language, e.g. {"en": "...", "es": "..."};
+-- templates may use {amount}, {currency}, {merchant}, {country} and {channel}.
+CREATE TABLE IF NOT EXISTS reason_codes (
+    code VARCHAR(20) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    rules TEXT[] NOT NULL DEFAULT '{}',
+    anomalies TEXT[] NOT NULL DEFAULT '{}',
+    messages JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Top reason codes of each decision, highest contribution first
+ALTER TABLE risk_scores ADD COLUMN IF NOT EXISTS reason_codes JSONB;
+
+INSERT INTO reason_codes (code, description, rules, anomalies, messages) VALUES
+('RC01', 'Amount unusually high for the account',
+ '{RULE_SPIKE_ANOMALY,RULE_CRITICAL_AMOUNT}', '{SPENDING_SPIKE}',
+ '{"en": "The amount of {amount} {currency} is unusually high for this account.",
+   "es": "El importe de {amount} {currency} es inusualmente alto para esta cuenta.",
+   "fr": "Le montant de {amount} {currency} est inhabituellement élevé pour ce compte."}'),
+('RC02', 'Unusual number of transactions in a short period',
+ '{RULE_VELOCITY_BURST,RULE_RAPID_SMALL_TRANSACTIONS}', '{VELOCITY_BURST}',
+ '{"en": "An unusual number of transactions was made in a short period.",
+   "es": "Se realizó un número inusual de transacciones en poco tiempo.",
+   "fr": "Un nombre inhabituel de transactions a été effectué en peu de temps."}'),
+('RC03', 'Location inconsistent with account history',
+ '{RULE_NEW_LOCATION_HIGH_AMOUNT,RULE_LOCATION_HOPPING,RULE_GEO_IMPOSSIBLE_TRAVEL,RULE_CROSS_BORDER}', '{GEO_IMPOSSIBLE_TRAVEL}',
+ '{"en": "The transaction location is inconsistent with recent account activity.",
+   "es": "La ubicación de la transacción no coincide con la actividad reciente de la cuenta.",
+   "fr": "Le lieu de la transaction ne correspond pas à l''activité récente du compte."}'),
+('RC04', 'Transaction from a restricted country',
+ '{RULE_HIGH_RISK_COUNTRY}', '{}',
+ '{"en": "Transactions from {country} are subject to additional restrictions.",
+   "es": "Las transacciones desde {country} están sujetas a restricciones adicionales.",
+   "fr": "Les transactions depuis {country} sont soumises à des restrictions supplémentaires."}'),
+('RC05', 'High amount at an unfamiliar merchant',
+ '{RULE_NEW_MERCHANT_HIGH_AMOUNT}', '{}',
+ '{"en": "This is an unusually large payment to a merchant not used before.",
+   "es": "Es un pago inusualmente alto a un comercio no utilizado anteriormente.",
+   "fr": "Il s''agit d''un paiement inhabituellement élevé à un commerçant jamais utilisé."}'),
+('RC06', 'Activity at an unusual time',
+ '{RULE_NIGHT_TRANSACTION}', '{UNUSUAL_TIME_PATTERN}',
+ '{"en": "The transaction was made at an unusual time for this account.",
+   "es": "La transacción se realizó a una hora inusual para esta cuenta.",
+   "fr": "La transaction a été effectuée à une heure inhabituelle pour ce compte."}'),
+('RC07', 'Unrecognized device',
+ '{RULE_RAPID_DEVICE_SWITCH}', '{NEW_DEVICE_HIGH_VALUE}',
+ '{"en": "The transaction was made from a device not previously used with this account.",
+   "es": "La transacción se realizó desde un dispositivo no utilizado antes con esta cuenta.",
+   "fr": "La transaction a été effectuée depuis un appareil jamais utilisé avec ce compte."}'),
+('RC08', 'Spending pattern differs from account or peer history',
+ '{RULE_PEER_GROUP_ANOMALY,RULE_BEHAVIORAL_ANOMALY}', '{PEER_GROUP_DEVIATION}',
+ '{"en": "The spending pattern differs from the usual activity of this account.",
+   "es": "El patrón de gasto difiere de la actividad habitual de esta cuenta.",
+   "fr": "Les habitudes de dépense diffèrent de l''activité habituelle de ce compte."}'),
+('RC09', 'Small test payments followed by a large payment',
+ '{RULE_SEQUENCE_EXFIL_PATTERN}', '{SEQUENCE_EXFIL_PATTERN}',
+ '{"en": "Small test payments were followed by a large payment.",
+   "es": "Se realizaron pequeños pagos de prueba seguidos de un pago grande.",
+   "fr": "De petits paiements de test ont été suivis d''un paiement important."}'),
+('RC10', 'Payee linked to suspicious activity',
+ '{RULE_SHARED_BENEFICIARY_NETWORK}', '{}',
+ '{"en": "The payee is associated with suspicious activity.",
+   "es": "El beneficiario está asociado a actividad sospechosa.",
+   "fr": "Le bénéficiaire est associé à une activité suspecte."}'),
+('RC11', 'Rapid switching between payment channels',
+ '{RULE_RAPID_CHANNEL_SWITCH}', '{RAPID_CHANNEL_SWITCH}',
+ '{"en": "Payment channels were switched rapidly before this {channel} transaction.",
+   "es": "Se cambió rápidamente de canal de pago antes de esta transacción {channel}.",
+   "fr": "Les canaux de paiement ont changé rapidement avant cette transaction {channel}."}'),
+('RC99', 'Fallback for rules and anomalies without a code',
+ '{}', '{}',
+ '{"en": "The transaction did not pass our risk checks.",
+   "es": "La transacción no superó nuestros controles de riesgo.",
+   "fr": "La transaction n''a pas passé nos contrôles de risque."}')
+ON CONFLICT (code) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,44 @@
+-- Migration: 012_decision_policies
+-- Description: Versioned decision policy (risk-level thresholds and actions per segment)
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Every change publishes a full policy as a new version; the latest version is active.
+-- Versions are append-only; rollback publishes a copy of an earlier version.
+CREATE TABLE IF NOT EXISTS decision_policy_versions (
+    version INTEGER PRIMARY KEY,
+    policy JSONB NOT NULL, -- {thresholds, actions, segments}
+    description TEXT,
+    rolled_back_from INTEGER REFERENCES decision_policy_versions(version),
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION prevent_decision_policy_version_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'decision_policy_versions is append-only (version %)', OLD.version;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS decision_policy_versions_immutable ON decision_policy_versions;
+CREATE TRIGGER decision_policy_versions_immutable
+    BEFORE UPDATE OR DELETE ON decision_policy_versions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_decision_policy_version_change();
+
+-- Publish today's hard-coded policy as version 1
+INSERT INTO decision_policy_versions (version, policy, description) VALUES
+(1, '{
+    "thresholds": {"medium": 25, "high": 50, "critical": 70},
+    "actions": {"low": "processed", "medium": "processed", "high": "flagged", "critical": "blocked"},
+    "segments": []
+}', 'Initial decision policy')
+ON CONFLICT (version) DO NOTHING;
+
+-- Record the policy version and segment that decided each score (NULL for the built-in default)
+ALTER TABLE risk_scores ADD COLUMN IF NOT EXISTS decision_policy_version INTEGER;
+ALTER TABLE risk_scores ADD COLUMN IF NOT EXISTS policy_segment VARCHAR(100);
+
+COMMIT;
@@ -0,0 +1,41 @@
+-- Migration: 013_decision_outcomes
+-- Description: Decision outcomes (approve, approve with monitoring, step-up, review, decline) and their transaction statuses
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Replace processed/flagged/blocked with the status each decision starts in.
+-- step_up_pending and review_pending are resolved to approved or declined;
+-- monitored approvals may be escalated to review_pending.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+
+UPDATE transactions SET status = CASE status
+    WHEN 'processed' THEN 'approved'
+    WHEN 'flagged' THEN 'review_pending'
+    WHEN 'blocked' THEN 'declined'
+END
+WHERE status IN ('processed', 'flagged', 'blocked');
+
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check CHECK (status IN (
+    'pending', 'approved', 'monitored', 'step_up_pending', 'review_pending', 'declined'
+));
+
+-- Record the decision on each score (NULL only for scores predating decisions that
+-- cannot be matched to a transaction)
+ALTER TABLE risk_scores ADD COLUMN IF NOT EXISTS decision VARCHAR(30)
+    CHECK (decision IN ('approve', 'approve_with_monitoring', 'step_up', 'review', 'decline'));
+
+UPDATE risk_scores rs SET decision = CASE t.status
+    WHEN 'approved' THEN 'approve'
+    WHEN 'review_pending' THEN 'review'
+    WHEN 'declined' THEN 'decline'
+END
+FROM transactions t
+WHERE rs.transaction_id = t.id
+  AND rs.transaction_created_at = t.created_at
+  AND rs.decision IS NULL
+  AND t.status IN ('approved', 'review_pending', 'declined');
+
+CREATE INDEX IF NOT EXISTS idx_risk_scores_decision ON risk_scores(decision);
+
+COMMIT;
@@ -0,0 +1,34 @@
+-- Migration: 014_location_risk
+-- Description: Country-level location risk in geo_locations replaces the hard-coded high-risk country list
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- One country-level row (city_name NULL) per country, so its risk can be upserted.
+-- NULL city names never conflict on UNIQUE(country_code, city_name); drop any duplicates first.
+DELETE FROM geo_locations g
+USING geo_locations d
+WHERE g.city_name IS NULL
+  AND d.city_name IS NULL
+  AND g.country_code = d.country_code
+  AND g.id > d.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_geo_country_level
+    ON geo_locations(country_code) WHERE city_name IS NULL;
+
+-- Countries of the former built-in high-risk list without a country-level row
+INSERT INTO geo_locations (country_code, country_name, city_name, region, latitude, longitude, timezone, risk_level, is_sanctioned, population) VALUES
+('VE', 'Venezuela', NULL, NULL, 6.4238, -66.5897, 'America/Caracas', 'high', false, 28435940),
+('MM', 'Myanmar', NULL, NULL, 21.9162, 95.9560, 'Asia/Yangon', 'high', false, 54409800),
+('BY', 'Belarus', NULL, NULL, 53.7098, 27.9534, 'Europe/Minsk', 'high', false, 9449323),
+('ZW', 'Zimbabwe', NULL, NULL, -19.0154, 29.1549, 'Africa/Harare', 'high', false, 14862924)
+ON CONFLICT (country_code) WHERE city_name IS NULL DO NOTHING;
+
+-- A sanctioned location is always flagged as such
+UPDATE geo_locations SET is_sanctioned = true, updated_at = NOW()
+WHERE risk_level = 'sanctioned' AND NOT is_sanctioned;
+
+ALTER TABLE geo_locations ADD CONSTRAINT geo_locations_risk_level_check
+    CHECK (risk_level IN ('low', 'medium', 'high', 'sanctioned'));
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- Migration: 015_account_devices
+-- Description: Device attributes on transactions and each account's history of known devices
+-- Created: 2026-10-16
+
+BEGIN;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS device_id VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS device_fingerprint VARCHAR(128);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS user_agent TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ip_address INET;
+
+-- Devices are keyed by device ID, or by fingerprint hash when the client sends no ID.
+-- first_seen_at and last_seen_at are transaction times, so out-of-order ingestion
+-- records the same history.
+CREATE TABLE IF NOT EXISTS account_devices (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    device_key VARCHAR(255) NOT NULL,
+    device_id VARCHAR(255),
+    device_fingerprint VARCHAR(128),
+    last_user_agent TEXT,
+    last_ip_address INET,
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (account_id, device_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_devices_first_seen ON account_devices(account_id, first_seen_at);
+
+COMMIT;
@@ -0,0 +1,42 @@
+-- Migration: 016_merchant_profiles
+-- Description: Merchant reputation profiles built from scored and charged-back transactions
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- One profile per normalized merchant name and category
+CREATE TABLE IF NOT EXISTS merchant_profiles (
+    id BIGSERIAL PRIMARY KEY,
+    merchant_key VARCHAR(400) NOT NULL UNIQUE,
+    merchant_name VARCHAR(255) NOT NULL,
+    merchant_category VARCHAR(100) NOT NULL DEFAULT '',
+    transaction_count BIGINT NOT NULL DEFAULT 0,
+    total_amount DECIMAL(18, 2) NOT NULL DEFAULT 0,
+    flagged_count BIGINT NOT NULL DEFAULT 0,    -- decided step-up or review
+    blocked_count BIGINT NOT NULL DEFAULT 0,    -- declined
+    chargeback_count BIGINT NOT NULL DEFAULT 0,
+    account_count BIGINT NOT NULL DEFAULT 0,    -- distinct accounts
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    risk_override DECIMAL(5, 2) CHECK (risk_override BETWEEN 0 AND 100),
+    override_reason TEXT,
+    overridden_by UUID REFERENCES users(id),
+    overridden_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Each scored transaction counts once towards its merchant's profile, however often it
+-- is rescored; chargebacks are labeled here
+CREATE TABLE IF NOT EXISTS merchant_transactions (
+    transaction_id UUID PRIMARY KEY,
+    merchant_key VARCHAR(400) NOT NULL,
+    account_id UUID NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    decision VARCHAR(30) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    charged_back_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_transactions_account ON merchant_transactions(merchant_key, account_id);
+
+COMMIT;
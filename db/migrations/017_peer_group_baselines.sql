@@ -0,0 +1,30 @@
+-- Migration: 017_peer_group_baselines
+-- Description: Spend distributions of peer groups of accounts, rebuilt periodically
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- A peer group is accounts of the same type and risk profile, in the same account-age
+-- bucket at transaction time, spending in the same merchant category. merchant_category
+-- '*' is the group across all categories, used when a category has too few transactions.
+CREATE TABLE IF NOT EXISTS peer_group_baselines (
+    account_type VARCHAR(50) NOT NULL,
+    risk_profile VARCHAR(20) NOT NULL,
+    age_bucket VARCHAR(20) NOT NULL,           -- 0-30d, 30-90d, 90-365d, 365d+
+    merchant_category VARCHAR(100) NOT NULL,
+    transaction_count BIGINT NOT NULL,
+    account_count BIGINT NOT NULL,
+    mean_amount DECIMAL(15, 2) NOT NULL,
+    stddev_amount DECIMAL(15, 2) NOT NULL,
+    mean_log_amount DOUBLE PRECISION NOT NULL, -- spend is skewed; deviations use ln(amount)
+    stddev_log_amount DOUBLE PRECISION NOT NULL,
+    p50_amount DECIMAL(15, 2) NOT NULL,
+    p90_amount DECIMAL(15, 2) NOT NULL,
+    p99_amount DECIMAL(15, 2) NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_type, risk_profile, age_bucket, merchant_category)
+);
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Migration: 018_counterparty_graph
+-- Description: Counterparty identifiers on transfers and the account-counterparty graph
+-- Created: 2026-10-16
+
+BEGIN;
+
+-- Beneficiary of a transfer, e.g. a hashed IBAN or wallet address
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_counterparty ON transactions(counterparty_id, created_at)
+    WHERE counterparty_id IS NOT NULL;
+
+-- One edge per account and counterparty it has paid. first_seen_at and last_seen_at are
+-- transaction times, so out-of-order scoring records the same graph.
+CREATE TABLE IF NOT EXISTS account_counterparties (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    counterparty_id VARCHAR(255) NOT NULL,
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (account_id, counterparty_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_counterparties_counterparty ON account_counterparties(counterparty_id, last_seen_at);
+
+COMMIT;
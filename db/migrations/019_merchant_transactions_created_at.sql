@@ -0,0 +1,9 @@
+-- Migration: 019_merchant_transactions_created_at
+-- Description: Index merchant transactions by time for merchant profiles as of a transaction
+-- Created: 2026-10-16
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_merchant_transactions_created ON merchant_transactions(merchant_key, created_at);
+
+COMMIT;
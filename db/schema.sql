@@ -51,7 +51,9 @@ CREATE TABLE IF NOT EXISTS transactions (
     location VARCHAR(255),
     country VARCHAR(3),
     channel VARCHAR(20) NOT NULL DEFAULT 'online' CHECK (channel IN ('online', 'pos', 'atm')),
-    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'flagged', 'blocked')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN (
+        'pending', 'approved', 'monitored', 'step_up_pending', 'review_pending', 'declined'
+    )),
     idempotency_key VARCHAR(255) NOT NULL,
     metadata JSONB DEFAULT '{}',
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
@@ -287,7 +289,7 @@ ON CONFLICT (id) DO NOTHING;
 -- VIEWS FOR ANALYTICS
 -- ============================================
 
--- View for recent flagged transactions
+-- View for recent flagged transactions (awaiting step-up or review, or declined)
 CREATE OR REPLACE VIEW v_recent_flagged_transactions AS
 SELECT 
     t.id,
@@ -305,7 +307,7 @@ SELECT
     rs.rules_triggered
 FROM transactions t
 JOIN risk_scores rs ON t.id = rs.transaction_id AND t.created_at = rs.transaction_created_at
-WHERE t.status IN ('flagged', 'blocked')
+WHERE t.status IN ('step_up_pending', 'review_pending', 'declined')
 ORDER BY t.created_at DESC;
 
 -- View for account risk summary
@@ -316,8 +318,8 @@ SELECT
     a.status,
     COUNT(t.id) AS total_transactions_30d,
     COALESCE(AVG(t.amount), 0) AS avg_transaction_amount,
-    COUNT(CASE WHEN t.status = 'flagged' THEN 1 END) AS flagged_count_30d,
-    COUNT(CASE WHEN t.status = 'blocked' THEN 1 END) AS blocked_count_30d,
+    COUNT(CASE WHEN t.status IN ('step_up_pending', 'review_pending') THEN 1 END) AS flagged_count_30d,
+    COUNT(CASE WHEN t.status = 'declined' THEN 1 END) AS blocked_count_30d,
     COALESCE(AVG(rs.score), 0) AS avg_risk_score,
     MAX(t.created_at) AS last_transaction_at
 FROM accounts a
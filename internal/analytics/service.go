@@ -136,6 +136,7 @@ func (s *AnalyticsService) GetFlaggedTransactions(ctx context.Context, page, pag
 			ft.RiskScore = score.Score
 			ft.RiskLevel = score.RiskLevel
 			ft.RulesTriggered = score.RulesTriggered
+			ft.ReasonCodes = score.ReasonCodes
 		}
 		enriched = append(enriched, ft)
 	}
@@ -228,7 +229,7 @@ func (s *AnalyticsService) calculateAvgProcessingTime(ctx context.Context) (floa
 func (s *AnalyticsService) calculateErrorRate(ctx context.Context) (float64, error) {
 	query := `
 		SELECT 
-			COUNT(CASE WHEN status IN ('flagged', 'blocked') THEN 1 END)::float / 
+			COUNT(CASE WHEN status IN ('step_up_pending', 'review_pending', 'declined') THEN 1 END)::float / 
 			NULLIF(COUNT(*), 0)
 		FROM transactions
 		WHERE created_at >= NOW() - INTERVAL '1 hour'
@@ -297,23 +298,44 @@ func (s *AnalyticsService) GetRiskDistribution(ctx context.Context, days int) (*
 // The count is the number of DISTINCT flagged/blocked transactions
 // where this rule was present, so it can be safely compared against
 // the total flagged/blocked transaction count.
+// Shadow rules are reported alongside (up to limit of each kind); since
+// they never affect the decision they are counted over all scored
+// transactions. HitRate is count / all scored transactions in the window.
 func (s *AnalyticsService) GetTopTriggeredRules(ctx context.Context, days, limit int) ([]models.RuleCount, error) {
 	query := `
-		SELECT 
-			rule_id,
-			COUNT(DISTINCT transaction_id) AS count
-		FROM (
-			SELECT 
-				transaction_id,
-				unnest(rules_triggered) AS rule_id
+		WITH scored AS (
+			SELECT transaction_id, risk_level, rules_triggered, shadow_rules_triggered
 			FROM risk_scores
-			WHERE 
-				created_at >= NOW() - ($1::text || ' days')::interval
-				AND risk_level IN ('high', 'critical')
-		) t
-		GROUP BY rule_id
-		ORDER BY count DESC
-		LIMIT $2
+			WHERE created_at >= NOW() - ($1::text || ' days')::interval
+		),
+		hits AS (
+			SELECT transaction_id, unnest(rules_triggered) AS rule_id, false AS shadow
+			FROM scored
+			WHERE risk_level IN ('high', 'critical')
+			UNION ALL
+			SELECT transaction_id, unnest(shadow_rules_triggered) AS rule_id, true AS shadow
+			FROM scored
+		),
+		counts AS (
+			SELECT
+				rule_id,
+				shadow,
+				COUNT(DISTINCT transaction_id) AS count
+			FROM hits
+			GROUP BY rule_id, shadow
+		),
+		ranked AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY shadow ORDER BY count DESC, rule_id) AS rank
+			FROM counts
+		)
+		SELECT
+			rule_id,
+			count,
+			shadow,
+			COALESCE(count::float8 / NULLIF((SELECT COUNT(DISTINCT transaction_id) FROM scored), 0), 0) AS hit_rate
+		FROM ranked
+		WHERE rank <= $2
+		ORDER BY shadow, count DESC, rule_id
 	`
 
 	rows, err := s.db.Pool.Query(ctx, query, fmt.Sprintf("%d", days), limit)
@@ -325,7 +347,7 @@ func (s *AnalyticsService) GetTopTriggeredRules(ctx context.Context, days, limit
 	var rules []models.RuleCount
 	for rows.Next() {
 		var rc models.RuleCount
-		if err := rows.Scan(&rc.RuleID, &rc.Count); err != nil {
+		if err := rows.Scan(&rc.RuleID, &rc.Count, &rc.Shadow, &rc.HitRate); err != nil {
 			return nil, err
 		}
 		rules = append(rules, rc)
@@ -372,10 +394,11 @@ func (s *AnalyticsService) GetHourlyTransactionVolume(ctx context.Context, date
 
 // FlaggedTransaction includes transaction with risk details
 type FlaggedTransaction struct {
-	Transaction    *models.Transaction `json:"transaction"`
-	RiskScore      float64             `json:"risk_score"`
-	RiskLevel      string              `json:"risk_level"`
-	RulesTriggered []string            `json:"rules_triggered"`
+	Transaction    *models.Transaction     `json:"transaction"`
+	RiskScore      float64                 `json:"risk_score"`
+	RiskLevel      string                  `json:"risk_level"`
+	RulesTriggered []string                `json:"rules_triggered"`
+	ReasonCodes    []models.DecisionReason `json:"reason_codes"`
 }
 
 // FlaggedTransactionsResponse is the response for flagged transactions
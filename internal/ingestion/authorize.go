@@ -0,0 +1,195 @@
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+// AuthorizationResponse is the inline decision for a transaction
+type AuthorizationResponse struct {
+	TransactionID         string                  `json:"transaction_id"`
+	IdempotencyKey        string                  `json:"idempotency_key"`
+	Decision              string                  `json:"decision"`
+	Status                string                  `json:"status"` // pending while a fallback decision awaits full scoring
+	RiskLevel             string                  `json:"risk_level,omitempty"`
+	Score                 *float64                `json:"score,omitempty"`
+	ReasonCodes           []models.DecisionReason `json:"reason_codes,omitempty"`
+	DecisionPolicyVersion int                     `json:"decision_policy_version"`
+	Fallback              bool                    `json:"fallback"` // budget exceeded; full scoring continues asynchronously
+	LatencyMs             int64                   `json:"latency_ms"`
+	CreatedAt             time.Time               `json:"created_at"`
+	Message               string                  `json:"message,omitempty"`
+}
+
+// AuthorizationService scores transactions inline for authorization flows. The
+// transaction is persisted and scored in-process; when scoring does not finish within
+// the latency budget the policy's fallback decision is returned while scoring carries
+// on, and the transaction is handed to the async workers only if scoring fails.
+type AuthorizationService struct {
+	ingestion     *IngestionService
+	engine        *scoring.ScoringEngine
+	riskScoreRepo *repositories.RiskScoreRepository
+	budget        time.Duration
+}
+
+// NewAuthorizationService creates a new authorization service
+func NewAuthorizationService(
+	ingestion *IngestionService,
+	engine *scoring.ScoringEngine,
+	riskScoreRepo *repositories.RiskScoreRepository,
+	budget time.Duration,
+) *AuthorizationService {
+	return &AuthorizationService{
+		ingestion:     ingestion,
+		engine:        engine,
+		riskScoreRepo: riskScoreRepo,
+		budget:        budget,
+	}
+}
+
+// scoreResult is the outcome of an inline scoring attempt
+type scoreResult struct {
+	score *models.RiskScore
+	err   error
+}
+
+// Authorize persists a transaction and returns its decision within the latency budget.
+// The budget runs from the start of the request, so persisting counts against it.
+func (s *AuthorizationService) Authorize(ctx context.Context, req *TransactionRequest, requestID string) (*AuthorizationResponse, error) {
+	startTime := time.Now()
+
+	tx, account, duplicate, err := s.ingestion.persistTransaction(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if duplicate {
+		return s.existingDecision(ctx, tx, startTime)
+	}
+
+	// Only the response races the budget: scoring is not cancelled, so its writes are
+	// never cut off partway through
+	scoreCtx := context.WithoutCancel(ctx)
+	budget := time.NewTimer(time.Until(startTime.Add(s.budget)))
+	defer budget.Stop()
+
+	done := make(chan scoreResult, 1)
+	go func() {
+		score, err := s.engine.ScoreTransaction(scoreCtx, transactionEvent(tx))
+		done <- scoreResult{score: score, err: err}
+	}()
+
+	var resp *AuthorizationResponse
+	select {
+	case res := <-done:
+		if res.err == nil {
+			resp = scoredResponse(tx, res.score, models.DecisionStatuses[res.score.Decision])
+			break
+		}
+		log.Warn().Err(res.err).
+			Str("transaction_id", tx.ID.String()).
+			Msg("Inline scoring failed, returning fallback decision")
+		s.ingestion.publishTransaction(scoreCtx, tx)
+	case <-budget.C:
+		log.Warn().
+			Str("transaction_id", tx.ID.String()).
+			Dur("budget", s.budget).
+			Msg("Authorization latency budget exceeded, returning fallback decision")
+		go s.handOff(scoreCtx, tx, done)
+	}
+
+	if resp == nil {
+		resp = s.fallbackResponse(tx, account.AccountType)
+	}
+	resp.LatencyMs = time.Since(startTime).Milliseconds()
+
+	s.ingestion.createAuditLog(ctx, tx, requestID, "authorize", models.JSONB{
+		"decision":   resp.Decision,
+		"fallback":   resp.Fallback,
+		"latency_ms": resp.LatencyMs,
+	})
+
+	log.Info().
+		Str("transaction_id", tx.ID.String()).
+		Str("decision", resp.Decision).
+		Bool("fallback", resp.Fallback).
+		Int64("latency_ms", resp.LatencyMs).
+		Msg("Transaction authorized")
+
+	return resp, nil
+}
+
+// handOff waits for inline scoring that overran the budget and publishes the
+// transaction for async scoring only if it failed
+func (s *AuthorizationService) handOff(ctx context.Context, tx *models.Transaction, done <-chan scoreResult) {
+	res := <-done
+	if res.err == nil {
+		return
+	}
+	log.Warn().Err(res.err).
+		Str("transaction_id", tx.ID.String()).
+		Msg("Inline scoring failed after the latency budget, handing off to async scoring")
+	s.ingestion.publishTransaction(ctx, tx)
+}
+
+// existingDecision answers a retried request: the stored score when there is one,
+// otherwise the fallback decision while the first attempt is still being scored
+func (s *AuthorizationService) existingDecision(ctx context.Context, tx *models.Transaction, startTime time.Time) (*AuthorizationResponse, error) {
+	var resp *AuthorizationResponse
+
+	score, err := s.riskScoreRepo.GetByTransactionID(ctx, tx.ID)
+	switch {
+	case err == nil:
+		resp = scoredResponse(tx, score, tx.Status)
+	case errors.Is(err, repositories.ErrRiskScoreNotFound):
+		accountType := ""
+		if account, err := s.ingestion.accountRepo.GetByID(ctx, tx.AccountID); err == nil {
+			accountType = account.AccountType
+		}
+		resp = s.fallbackResponse(tx, accountType)
+	default:
+		return nil, err
+	}
+
+	resp.Message = "Transaction already exists (idempotent)"
+	resp.LatencyMs = time.Since(startTime).Milliseconds()
+	return resp, nil
+}
+
+// fallbackResponse returns the policy's fallback decision; the transaction stays
+// pending until the async workers score it
+func (s *AuthorizationService) fallbackResponse(tx *models.Transaction, accountType string) *AuthorizationResponse {
+	decision := s.engine.GetPolicyEngine().Fallback(accountType, tx)
+
+	return &AuthorizationResponse{
+		TransactionID:         tx.ID.String(),
+		IdempotencyKey:        tx.IdempotencyKey,
+		Decision:              decision.Decision,
+		Status:                tx.Status,
+		DecisionPolicyVersion: decision.PolicyVersion,
+		Fallback:              true,
+		CreatedAt:             tx.CreatedAt,
+	}
+}
+
+// scoredResponse returns the decision of a completed risk score with the transaction's status
+func scoredResponse(tx *models.Transaction, score *models.RiskScore, status string) *AuthorizationResponse {
+	return &AuthorizationResponse{
+		TransactionID:         tx.ID.String(),
+		IdempotencyKey:        tx.IdempotencyKey,
+		Decision:              score.Decision,
+		Status:                status,
+		RiskLevel:             score.RiskLevel,
+		Score:                 &score.Score,
+		ReasonCodes:           score.ReasonCodes,
+		DecisionPolicyVersion: score.DecisionPolicyVersion,
+		CreatedAt:             tx.CreatedAt,
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/enterprise/risk-engine/internal/models"
 	"github.com/enterprise/risk-engine/internal/queue"
 	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
 )
 
 // TransactionRequest represents an incoming transaction request
@@ -25,6 +26,36 @@ type TransactionRequest struct {
 	Channel          string                 `json:"channel" binding:"required,oneof=online pos atm"`
 	IdempotencyKey   string                 `json:"idempotency_key" binding:"required"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+
+	// Device the transaction was made from (all optional)
+	DeviceID          string `json:"device_id" binding:"omitempty,max=255"`
+	DeviceFingerprint string `json:"device_fingerprint" binding:"omitempty,max=128"` // client fingerprint hash
+	UserAgent         string `json:"user_agent" binding:"omitempty,max=1024"`
+	IPAddress         string `json:"ip_address" binding:"omitempty,ip"`
+
+	// Beneficiary of a transfer (optional), e.g. a hashed IBAN or wallet address
+	CounterpartyID string `json:"counterparty_id" binding:"omitempty,max=255"`
+}
+
+// transaction builds the transaction to store for a request
+func (req *TransactionRequest) transaction(accountID uuid.UUID) *models.Transaction {
+	return &models.Transaction{
+		AccountID:         accountID,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		Merchant:          req.Merchant,
+		MerchantCategory:  req.MerchantCategory,
+		Location:          req.Location,
+		Country:           req.Country,
+		Channel:           req.Channel,
+		IdempotencyKey:    req.IdempotencyKey,
+		Metadata:          models.JSONB(req.Metadata),
+		DeviceID:          req.DeviceID,
+		DeviceFingerprint: req.DeviceFingerprint,
+		UserAgent:         req.UserAgent,
+		IPAddress:         req.IPAddress,
+		CounterpartyID:    req.CounterpartyID,
+	}
 }
 
 // BatchTransactionRequest represents a batch of transactions
@@ -32,13 +63,18 @@ type BatchTransactionRequest struct {
 	Transactions []TransactionRequest `json:"transactions" binding:"required,min=1,max=1000"`
 }
 
-// TransactionResponse represents the response after ingesting a transaction
+// TransactionResponse represents the response after ingesting a transaction. The
+// decision fields are set when the request waited for scoring and it finished in time.
 type TransactionResponse struct {
-	TransactionID  string    `json:"transaction_id"`
-	Status         string    `json:"status"`
-	IdempotencyKey string    `json:"idempotency_key"`
-	CreatedAt      time.Time `json:"created_at"`
-	Message        string    `json:"message,omitempty"`
+	TransactionID  string                  `json:"transaction_id"`
+	Status         string                  `json:"status"`
+	IdempotencyKey string                  `json:"idempotency_key"`
+	CreatedAt      time.Time               `json:"created_at"`
+	Decision       string                  `json:"decision,omitempty"`
+	RiskLevel      string                  `json:"risk_level,omitempty"`
+	Score          *float64                `json:"score,omitempty"`
+	ReasonCodes    []models.DecisionReason `json:"reason_codes,omitempty"`
+	Message        string                  `json:"message,omitempty"`
 }
 
 // BatchTransactionResponse represents the response for batch ingestion
@@ -53,8 +89,10 @@ type IngestionService struct {
 	txRepo      *repositories.TransactionRepository
 	accountRepo *repositories.AccountRepository
 	auditRepo   *repositories.AuditRepository
+	deviceRepo  *repositories.DeviceRepository
 	streamClient *queue.RedisStreamClient
 	cacheClient  *queue.CacheClient
+	featureStore *scoring.FeatureStore
 }
 
 // NewIngestionService creates a new ingestion service
@@ -62,6 +100,7 @@ func NewIngestionService(
 	txRepo *repositories.TransactionRepository,
 	accountRepo *repositories.AccountRepository,
 	auditRepo *repositories.AuditRepository,
+	deviceRepo *repositories.DeviceRepository,
 	streamClient *queue.RedisStreamClient,
 	cacheClient *queue.CacheClient,
 ) *IngestionService {
@@ -69,19 +108,120 @@ func NewIngestionService(
 		txRepo:       txRepo,
 		accountRepo:  accountRepo,
 		auditRepo:    auditRepo,
+		deviceRepo:   deviceRepo,
 		streamClient: streamClient,
 		cacheClient:  cacheClient,
 	}
 }
 
+// SetFeatureStore adds each ingested transaction to its account's history in the online
+// feature store, so transactions still being scored count in later ones' features
+func (s *IngestionService) SetFeatureStore(featureStore *scoring.FeatureStore) {
+	s.featureStore = featureStore
+}
+
 // IngestTransaction ingests a single transaction
 func (s *IngestionService) IngestTransaction(ctx context.Context, req *TransactionRequest, requestID string) (*TransactionResponse, error) {
+	return s.IngestTransactionAndWait(ctx, req, requestID, 0)
+}
+
+// IngestTransactionAndWait ingests a single transaction and, when wait is positive,
+// blocks until a worker publishes its risk score or wait passes. On timeout the
+// transaction is returned pending, as without waiting.
+func (s *IngestionService) IngestTransactionAndWait(ctx context.Context, req *TransactionRequest, requestID string, wait time.Duration) (*TransactionResponse, error) {
 	startTime := time.Now()
 
+	tx, _, duplicate, err := s.persistTransaction(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TransactionResponse{
+		TransactionID:  tx.ID.String(),
+		Status:         tx.Status,
+		IdempotencyKey: tx.IdempotencyKey,
+		CreatedAt:      tx.CreatedAt,
+	}
+	if duplicate {
+		resp.Message = "Transaction already exists (idempotent)"
+	}
+
+	// Subscribe before publishing so the score cannot be missed
+	var sub *queue.Subscription
+	if wait > 0 {
+		sub, err = s.cacheClient.Subscribe(ctx, queue.RiskScoreChannel(tx.ID.String()))
+		if err != nil {
+			log.Warn().Err(err).
+				Str("transaction_id", tx.ID.String()).
+				Msg("Failed to subscribe to risk score, not waiting")
+		} else {
+			defer sub.Close()
+		}
+	}
+
+	if !duplicate {
+		// Publish event to Redis Stream for async processing
+		s.publishTransaction(ctx, tx)
+
+		// Create audit log
+		s.createAuditLog(ctx, tx, requestID, "create", nil)
+
+		processingTime := time.Since(startTime)
+		log.Info().
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Float64("amount", tx.Amount).
+			Dur("processing_time", processingTime).
+			Msg("Transaction ingested")
+	}
+
+	if sub != nil {
+		if score := s.waitForRiskScore(ctx, sub, tx.ID.String(), startTime.Add(wait)); score != nil {
+			if status, ok := models.DecisionStatuses[score.Decision]; ok && resp.Status == models.TransactionStatusPending {
+				resp.Status = status
+			}
+			resp.Decision = score.Decision
+			resp.RiskLevel = score.RiskLevel
+			resp.Score = &score.Score
+			resp.ReasonCodes = score.ReasonCodes
+		} else if !duplicate {
+			resp.Message = "Decision not ready within the wait; poll GET /api/v1/transactions/" + resp.TransactionID
+		}
+	}
+
+	return resp, nil
+}
+
+// waitForRiskScore returns the transaction's risk score from the cache or, failing
+// that, the first one published before the deadline. It returns nil on timeout.
+func (s *IngestionService) waitForRiskScore(ctx context.Context, sub *queue.Subscription, txID string, deadline time.Time) *models.RiskScore {
+	var score models.RiskScore
+
+	// Already scored (retried request, or scored before the subscription)
+	if err := s.cacheClient.Get(ctx, "risk_score:"+txID, &score); err == nil {
+		return &score
+	}
+
+	waitCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	if err := sub.Receive(waitCtx, &score); err != nil {
+		if waitCtx.Err() == nil {
+			log.Warn().Err(err).Str("transaction_id", txID).Msg("Failed to receive risk score")
+		}
+		return nil
+	}
+	return &score
+}
+
+// persistTransaction verifies the account and stores the transaction. When the
+// idempotency key already exists it returns the stored transaction, a nil account
+// and duplicate set.
+func (s *IngestionService) persistTransaction(ctx context.Context, req *TransactionRequest) (*models.Transaction, *models.Account, bool, error) {
 	// Parse account ID
 	accountID, err := uuid.Parse(req.AccountID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid account_id format: %w", err)
+		return nil, nil, false, fmt.Errorf("invalid account_id format: %w", err)
 	}
 
 	// Check for duplicate (idempotency)
@@ -91,46 +231,75 @@ func (s *IngestionService) IngestTransaction(ctx context.Context, req *Transacti
 			Str("idempotency_key", req.IdempotencyKey).
 			Str("transaction_id", existing.ID.String()).
 			Msg("Duplicate transaction detected")
-		
-		return &TransactionResponse{
-			TransactionID:  existing.ID.String(),
-			Status:         existing.Status,
-			IdempotencyKey: existing.IdempotencyKey,
-			CreatedAt:      existing.CreatedAt,
-			Message:        "Transaction already exists (idempotent)",
-		}, nil
+
+		return existing, nil, true, nil
 	}
 
 	// Verify account exists and is active
 	account, err := s.accountRepo.GetByID(ctx, accountID)
 	if err != nil {
-		return nil, fmt.Errorf("account not found: %w", err)
+		return nil, nil, false, fmt.Errorf("account not found: %w", err)
 	}
 
 	if account.Status != models.AccountStatusActive {
-		return nil, fmt.Errorf("account is not active: %s", account.Status)
+		return nil, nil, false, fmt.Errorf("account is not active: %s", account.Status)
 	}
 
 	// Create transaction
-	tx := &models.Transaction{
-		AccountID:        accountID,
-		Amount:           req.Amount,
-		Currency:         req.Currency,
-		Merchant:         req.Merchant,
-		MerchantCategory: req.MerchantCategory,
-		Location:         req.Location,
-		Country:          req.Country,
-		Channel:          req.Channel,
-		IdempotencyKey:   req.IdempotencyKey,
-		Metadata:         models.JSONB(req.Metadata),
-	}
+	tx := req.transaction(accountID)
 
 	if err := s.txRepo.Create(ctx, tx); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	// Publish event to Redis Stream for async processing
-	event := &models.TransactionEvent{
+	s.recordDevice(ctx, tx)
+	s.recordFeatures(ctx, tx)
+
+	return tx, account, false, nil
+}
+
+// recordDevice adds the transaction's device, if it has one, to its account's device history
+func (s *IngestionService) recordDevice(ctx context.Context, tx *models.Transaction) {
+	if tx.DeviceKey() == "" {
+		return
+	}
+
+	if err := s.deviceRepo.Record(ctx, tx); err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Msg("Failed to record transaction device")
+	}
+}
+
+// recordFeatures adds the transaction, not yet scored, to its account's history in the
+// online feature store
+func (s *IngestionService) recordFeatures(ctx context.Context, tx *models.Transaction) {
+	if s.featureStore == nil {
+		return
+	}
+
+	if err := s.featureStore.Record(ctx, tx, nil); err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Msg("Failed to update online feature store")
+	}
+}
+
+// publishTransaction hands a stored transaction to the async scoring workers
+func (s *IngestionService) publishTransaction(ctx context.Context, tx *models.Transaction) {
+	if _, err := s.streamClient.Publish(ctx, transactionEvent(tx)); err != nil {
+		log.Error().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Msg("Failed to publish event to stream")
+		// Don't fail the request - transaction is saved, will be processed later
+	}
+}
+
+// transactionEvent builds the stream event for a stored transaction
+func transactionEvent(tx *models.Transaction) *models.TransactionEvent {
+	return &models.TransactionEvent{
 		TransactionID: tx.ID.String(),
 		AccountID:     tx.AccountID.String(),
 		Amount:        tx.Amount,
@@ -142,31 +311,6 @@ func (s *IngestionService) IngestTransaction(ctx context.Context, req *Transacti
 		Timestamp:     tx.CreatedAt,
 		RetryCount:    0,
 	}
-
-	if _, err := s.streamClient.Publish(ctx, event); err != nil {
-		log.Error().Err(err).
-			Str("transaction_id", tx.ID.String()).
-			Msg("Failed to publish event to stream")
-		// Don't fail the request - transaction is saved, will be processed later
-	}
-
-	// Create audit log
-	s.createAuditLog(ctx, tx, requestID, "create")
-
-	processingTime := time.Since(startTime)
-	log.Info().
-		Str("transaction_id", tx.ID.String()).
-		Str("account_id", tx.AccountID.String()).
-		Float64("amount", tx.Amount).
-		Dur("processing_time", processingTime).
-		Msg("Transaction ingested")
-
-	return &TransactionResponse{
-		TransactionID:  tx.ID.String(),
-		Status:         tx.Status,
-		IdempotencyKey: tx.IdempotencyKey,
-		CreatedAt:      tx.CreatedAt,
-	}, nil
 }
 
 // IngestBatch ingests multiple transactions
@@ -193,25 +337,13 @@ func (s *IngestionService) IngestBatch(ctx context.Context, req *BatchTransactio
 			continue
 		}
 
-		tx := &models.Transaction{
-			AccountID:        accountID,
-			Amount:           txReq.Amount,
-			Currency:         txReq.Currency,
-			Merchant:         txReq.Merchant,
-			MerchantCategory: txReq.MerchantCategory,
-			Location:         txReq.Location,
-			Country:          txReq.Country,
-			Channel:          txReq.Channel,
-			IdempotencyKey:   txReq.IdempotencyKey,
-			Metadata:         models.JSONB(txReq.Metadata),
-		}
-
-		transactions = append(transactions, tx)
+		transactions = append(transactions, txReq.transaction(accountID))
 	}
 
 	// Batch insert transactions
 	if len(transactions) > 0 {
-		if err := s.txRepo.CreateBatch(ctx, transactions); err != nil {
+		inserted, err := s.txRepo.CreateBatch(ctx, transactions)
+		if err != nil {
 			log.Error().Err(err).Msg("Failed to batch insert transactions")
 			// Mark all as failed
 			for _, tx := range transactions {
@@ -223,20 +355,14 @@ func (s *IngestionService) IngestBatch(ctx context.Context, req *BatchTransactio
 				})
 			}
 		} else {
+			for _, tx := range inserted {
+				s.recordDevice(ctx, tx)
+				s.recordFeatures(ctx, tx)
+			}
+
 			// Create events for successful transactions
 			for _, tx := range transactions {
-				events = append(events, &models.TransactionEvent{
-					TransactionID: tx.ID.String(),
-					AccountID:     tx.AccountID.String(),
-					Amount:        tx.Amount,
-					Currency:      tx.Currency,
-					Merchant:      tx.Merchant,
-					Location:      tx.Location,
-					Country:       tx.Country,
-					Channel:       tx.Channel,
-					Timestamp:     tx.CreatedAt,
-					RetryCount:    0,
-				})
+				events = append(events, transactionEvent(tx))
 
 				response.Successful++
 				response.Results = append(response.Results, TransactionResponse{
@@ -265,8 +391,8 @@ func (s *IngestionService) IngestBatch(ctx context.Context, req *BatchTransactio
 	return response, nil
 }
 
-// createAuditLog creates an audit log entry for a transaction
-func (s *IngestionService) createAuditLog(ctx context.Context, tx *models.Transaction, requestID, action string) {
+// createAuditLog creates an audit log entry for a transaction; extra is added to the payload
+func (s *IngestionService) createAuditLog(ctx context.Context, tx *models.Transaction, requestID, action string, extra models.JSONB) {
 	auditLog := &models.AuditLog{
 		EventType:  models.AuditEventTransaction,
 		EntityID:   tx.ID,
@@ -281,6 +407,9 @@ func (s *IngestionService) createAuditLog(ctx context.Context, tx *models.Transa
 			"account_id": tx.AccountID.String(),
 		},
 	}
+	for key, value := range extra {
+		auditLog.Payload[key] = value
+	}
 
 	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
 		log.Error().Err(err).
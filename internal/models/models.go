@@ -54,21 +54,170 @@ type Transaction struct {
 	Location        string     `json:"location"`
 	Country         string     `json:"country"`
 	Channel         string     `json:"channel"` // online, pos, atm
-	Status          string     `json:"status"`  // pending, processed, flagged, blocked
+	Status          string     `json:"status"`  // pending, approved, monitored, step_up_pending, review_pending, declined
 	IdempotencyKey  string     `json:"idempotency_key"`
 	Metadata        JSONB      `json:"metadata,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	ProcessedAt     *time.Time `json:"processed_at,omitempty"`
+
+	// Device the transaction was made from
+	DeviceID          string `json:"device_id,omitempty"`
+	DeviceFingerprint string `json:"device_fingerprint,omitempty"` // client fingerprint hash
+	UserAgent         string `json:"user_agent,omitempty"`
+	IPAddress         string `json:"ip_address,omitempty"`
+
+	// Beneficiary of a transfer
+	CounterpartyID string `json:"counterparty_id,omitempty"`
+}
+
+// DeviceKey identifies the transaction's device within its account: the device ID, or
+// the fingerprint hash when the client sent no ID. It is empty without either.
+func (t *Transaction) DeviceKey() string {
+	if t.DeviceID != "" {
+		return t.DeviceID
+	}
+	if t.DeviceFingerprint != "" {
+		return "fp:" + t.DeviceFingerprint
+	}
+	return ""
+}
+
+// AccountDevice is a device an account has transacted from
+type AccountDevice struct {
+	AccountID         uuid.UUID `json:"account_id"`
+	DeviceKey         string    `json:"device_key"`
+	DeviceID          string    `json:"device_id,omitempty"`
+	DeviceFingerprint string    `json:"device_fingerprint,omitempty"`
+	LastUserAgent     string    `json:"last_user_agent,omitempty"`
+	LastIPAddress     string    `json:"last_ip_address,omitempty"`
+	FirstSeenAt       time.Time `json:"first_seen_at"`
+	LastSeenAt        time.Time `json:"last_seen_at"`
+}
+
+// CounterpartySender is an account in the counterparty graph that has paid a counterparty
+type CounterpartySender struct {
+	AccountID   uuid.UUID `json:"account_id"`
+	RiskProfile string    `json:"risk_profile"`
+	Status      string    `json:"status"`
+	Flagged     bool      `json:"flagged"` // high risk profile or suspended
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// EgoCounterparty is a counterparty an account has paid, with the other accounts that
+// have paid it
+type EgoCounterparty struct {
+	CounterpartyID string               `json:"counterparty_id"`
+	FirstSeenAt    time.Time            `json:"first_seen_at"`
+	LastSeenAt     time.Time            `json:"last_seen_at"`
+	SenderCount    int                  `json:"sender_count"` // other accounts, flagged_sender_count of them flagged
+	FlaggedSenders int                  `json:"flagged_sender_count"`
+	Senders        []CounterpartySender `json:"senders"` // most recent first, capped
+}
+
+// EgoNetwork is an account's neighborhood in the counterparty graph: its counterparties
+// and the other accounts sharing them
+type EgoNetwork struct {
+	AccountID      uuid.UUID         `json:"account_id"`
+	Counterparties []EgoCounterparty `json:"counterparties"`
+}
+
+// MerchantProfile is the reputation of a merchant, keyed by its normalized name and
+// category, built from the transactions scored at it and their chargebacks
+type MerchantProfile struct {
+	ID               int64      `json:"id"`
+	MerchantKey      string     `json:"merchant_key"`
+	MerchantName     string     `json:"merchant_name"`
+	MerchantCategory string     `json:"merchant_category"`
+	TransactionCount int64      `json:"transaction_count"`
+	TotalAmount      float64    `json:"total_amount"`
+	FlaggedCount     int64      `json:"flagged_count"` // decided step-up or review
+	BlockedCount     int64      `json:"blocked_count"` // declined
+	ChargebackCount  int64      `json:"chargeback_count"`
+	AccountCount     int64      `json:"account_count"` // distinct accounts
+	FirstSeenAt      time.Time  `json:"first_seen_at"`
+	LastSeenAt       time.Time  `json:"last_seen_at"`
+	RiskOverride     *float64   `json:"risk_override,omitempty"` // replaces the computed risk score
+	OverrideReason   string     `json:"override_reason,omitempty"`
+	OverriddenBy     *uuid.UUID `json:"overridden_by,omitempty"`
+	OverriddenAt     *time.Time `json:"overridden_at,omitempty"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	RiskScore        float64    `json:"risk_score"` // computed at read time, override included
 }
 
-// TransactionStatus enum values
+// PeerGroupBaseline is the spend distribution of a peer group: accounts of the same type
+// and risk profile, in the same account-age bucket, at the same merchant category ("*"
+// for all categories)
+type PeerGroupBaseline struct {
+	AccountType      string    `json:"account_type"`
+	RiskProfile      string    `json:"risk_profile"`
+	AgeBucket        string    `json:"age_bucket"`
+	MerchantCategory string    `json:"merchant_category"`
+	TransactionCount int64     `json:"transaction_count"`
+	AccountCount     int64     `json:"account_count"`
+	MeanAmount       float64   `json:"mean_amount"`
+	StdDevAmount     float64   `json:"stddev_amount"`
+	MeanLogAmount    float64   `json:"mean_log_amount"`
+	StdDevLogAmount  float64   `json:"stddev_log_amount"`
+	P50Amount        float64   `json:"p50_amount"`
+	P90Amount        float64   `json:"p90_amount"`
+	P99Amount        float64   `json:"p99_amount"`
+	WindowStart      time.Time `json:"window_start"`
+	WindowEnd        time.Time `json:"window_end"`
+	ComputedAt       time.Time `json:"computed_at"`
+}
+
+// TransactionHistoryEntry is an earlier transaction of an account as seen by feature
+// computation, with the decision it was first scored with (empty while unscored)
+type TransactionHistoryEntry struct {
+	ID        uuid.UUID  `json:"id"`
+	Amount    float64    `json:"amount"`
+	Merchant  string     `json:"merchant,omitempty"`
+	Location  string     `json:"location,omitempty"`
+	Country   string     `json:"country,omitempty"`
+	Channel   string     `json:"channel,omitempty"`
+	Decision  string     `json:"decision,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"` // when the first risk score was created
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TransactionStatus enum values. A scored transaction starts in the status of its
+// decision; step-up and review holds are resolved to approved or declined.
+const (
+	TransactionStatusPending       = "pending"
+	TransactionStatusApproved      = "approved"
+	TransactionStatusMonitored     = "monitored"       // approved, kept under monitoring; may be escalated to review
+	TransactionStatusStepUpPending = "step_up_pending" // awaiting step-up authentication
+	TransactionStatusReviewPending = "review_pending"  // held for manual review
+	TransactionStatusDeclined      = "declined"
+)
+
+// Decision enum values: the actions a decision policy assigns to a risk level
 const (
-	TransactionStatusPending   = "pending"
-	TransactionStatusProcessed = "processed"
-	TransactionStatusFlagged   = "flagged"
-	TransactionStatusBlocked   = "blocked"
+	DecisionApprove               = "approve"
+	DecisionApproveWithMonitoring = "approve_with_monitoring"
+	DecisionStepUp                = "step_up"
+	DecisionReview                = "review"
+	DecisionDecline               = "decline"
 )
 
+// DecisionStatuses maps each decision to the transaction status it starts in
+var DecisionStatuses = map[string]string{
+	DecisionApprove:               TransactionStatusApproved,
+	DecisionApproveWithMonitoring: TransactionStatusMonitored,
+	DecisionStepUp:                TransactionStatusStepUpPending,
+	DecisionReview:                TransactionStatusReviewPending,
+	DecisionDecline:               TransactionStatusDeclined,
+}
+
+// ResolvedDecisions maps the statuses a step-up or review hold resolves to, and the
+// escalation of a monitored approval, to the decision the transaction then counts as
+var ResolvedDecisions = map[string]string{
+	TransactionStatusApproved:      DecisionApprove,
+	TransactionStatusReviewPending: DecisionReview,
+	TransactionStatusDeclined:      DecisionDecline,
+}
+
 // TransactionChannel enum values
 const (
 	ChannelOnline = "online"
@@ -85,15 +234,58 @@ type RiskScore struct {
 	MLScore          *float64  `json:"ml_score"`          // Score from ML model (nullable)
 	BehavioralScore  *float64  `json:"behavioral_score"`  // Score from behavioral analysis
 	RiskLevel        string    `json:"risk_level"`        // low, medium, high, critical
+	Decision         string    `json:"decision"`          // approve, approve_with_monitoring, step_up, review, decline
 	RulesTriggered   []string  `json:"rules_triggered"`   // list of rule IDs
+	ShadowRulesTriggered []string `json:"shadow_rules_triggered"` // shadow rules that fired (no score impact)
 	AnomaliesDetected []string `json:"anomalies_detected"` // list of anomaly types
+	ReasonCodes      []DecisionReason `json:"reason_codes"`   // customer-facing reasons, highest contribution first
 	Features         JSONB     `json:"features"`          // computed features
 	ModelVersion     string    `json:"model_version"`
+	RuleSetVersion   int       `json:"rule_set_version"`  // rule-set version used (0 = built-in defaults)
+	DecisionPolicyVersion int  `json:"decision_policy_version"` // decision policy used (0 = built-in default)
+	PolicySegment    string    `json:"policy_segment,omitempty"` // policy segment that matched, if any
 	ScoringPath      string    `json:"scoring_path"`      // "fast" or "full"
 	ProcessingTimeMs int64     `json:"processing_time_ms"`
 	CreatedAt        time.Time `json:"created_at"`
 }
 
+// DecisionReason is a customer-facing reason code returned with a scoring decision.
+// It never carries internal rule IDs.
+type DecisionReason struct {
+	Code         string  `json:"code"`
+	Message      string  `json:"message"`
+	Language     string  `json:"language"`
+	Contribution float64 `json:"contribution"` // points of the final score attributed to this code
+}
+
+// GeoLocation is a gazetteer entry: a city, or a whole country when CityName is empty
+type GeoLocation struct {
+	ID           int       `json:"id"`
+	CountryCode  string    `json:"country_code"`
+	CountryName  string    `json:"country_name"`
+	CityName     string    `json:"city_name,omitempty"`
+	Region       string    `json:"region,omitempty"`
+	Latitude     *float64  `json:"latitude"`
+	Longitude    *float64  `json:"longitude"`
+	Timezone     string    `json:"timezone,omitempty"`
+	RiskLevel    string    `json:"risk_level"` // low, medium, high, sanctioned
+	IsSanctioned bool      `json:"is_sanctioned"`
+	Population   int64     `json:"population,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ReasonCode is a reason-code catalog entry. Rules and anomaly types map to at most
+// one code; messages are templates keyed by language (e.g. "en", "es").
+type ReasonCode struct {
+	Code        string            `json:"code"`
+	Description string            `json:"description"` // internal description, not shown to customers
+	Rules       []string          `json:"rules"`
+	Anomalies   []string          `json:"anomalies"`
+	Messages    map[string]string `json:"messages"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
 // RiskLevel enum values
 const (
 	RiskLevelLow      = "low"
@@ -119,12 +311,17 @@ type AuditLog struct {
 
 // AuditEventType enum values
 const (
-	AuditEventTransaction     = "transaction"
-	AuditEventRiskScore       = "risk_score"
-	AuditEventAccountUpdate   = "account_update"
-	AuditEventUserLogin       = "user_login"
-	AuditEventUserLogout      = "user_logout"
-	AuditEventRuleUpdate      = "rule_update"
+	AuditEventTransaction      = "transaction"
+	AuditEventRiskScore        = "risk_score"
+	AuditEventAccountUpdate    = "account_update"
+	AuditEventUserLogin        = "user_login"
+	AuditEventUserLogout       = "user_logout"
+	AuditEventRuleUpdate       = "rule_update"
+	AuditEventReasonCodeUpdate = "reason_code_update"
+	AuditEventPolicyUpdate     = "policy_update"
+	AuditEventLocationRisk     = "location_risk_update"
+	AuditEventMerchantUpdate   = "merchant_update"
+	AuditEventDecision         = "decision"
 )
 
 // TransactionEvent is the event published to Redis Streams
@@ -158,8 +355,12 @@ type RiskFeatures struct {
 	UniqueLocations7d      int     `json:"unique_locations_7d"`
 	LocationChangeCount    int     `json:"location_change_count"`
 	IsNewLocation          bool    `json:"is_new_location"`
-	IsHighRiskCountry      bool    `json:"is_high_risk_country"`
+	IsHighRiskCountry      bool    `json:"is_high_risk_country"`     // Country risk level high or sanctioned
+	LocationRiskLevel      string  `json:"location_risk_level"`      // low, medium, high, sanctioned; empty when unknown
+	LocationRiskRank       int     `json:"location_risk_rank"`       // 0 unknown, 1 low ... 4 sanctioned
+	IsSanctioned           bool    `json:"is_sanctioned"`            // City or country under sanctions
 	DistanceFromLastTx     float64 `json:"distance_from_last_tx_km"` // Geo distance
+	ImpliedSpeedKmh        float64 `json:"implied_speed_kmh"`        // Distance / hours since that transaction
 	
 	// Merchant patterns
 	IsNewMerchant          bool    `json:"is_new_merchant"`
@@ -176,29 +377,143 @@ type RiskFeatures struct {
 	BehavioralAnomalyScore float64 `json:"behavioral_anomaly_score"` // Composite behavioral score
 	
 	// Sequence patterns (for modern fraud detection)
-	RecentSmallTxCount     int     `json:"recent_small_tx_count"`    // Small txns in last 10 min
-	FollowsProbePattern    bool    `json:"follows_probe_pattern"`    // Small tx followed by large
-	SharedBeneficiaryCount int     `json:"shared_beneficiary_count"` // Accounts sharing same target
+	RecentSmallTxCount     int     `json:"recent_small_tx_count"`    // Probe-sized txns in the probe window
+	ProbeMerchantCount     int     `json:"probe_merchant_count"`     // Distinct merchants of those probes
+	ProbeSameMerchant      bool    `json:"probe_same_merchant"`      // A probe hit this transaction's merchant
+	MinutesSinceLastProbe  float64 `json:"minutes_since_last_probe"` // 0 without probes
+	FollowsProbePattern    bool    `json:"follows_probe_pattern"`    // Large tx following probes
+	SharedBeneficiaryCount int     `json:"shared_beneficiary_count"` // Accounts paying the same counterparty in 24h, this one included
+	CounterpartyCount24h   int     `json:"counterparty_count_24h"`   // Counterparties the account paid in 24h, this one included
+	FlaggedBeneficiaryCount int    `json:"flagged_beneficiary_count"` // Account's counterparties also paid by flagged accounts
 	
 	// Peer group comparison
 	PeerGroupAvgSpend      float64 `json:"peer_group_avg_spend"`     // Similar accounts' avg
 	PeerGroupDeviation     float64 `json:"peer_group_deviation"`     // Deviation from peer group
 	
 	// Device/channel patterns
-	IsNewDevice            bool    `json:"is_new_device"`
+	IsNewDevice            bool    `json:"is_new_device"`            // Device not used by the account before
+	DeviceAgeDays          float64 `json:"device_age_days"`          // Days since the device was first used
+	AccountDeviceCount     int     `json:"account_device_count"`     // Devices used by the account, this one included
 	ChannelSwitchCount     int     `json:"channel_switch_count"`     // online→pos→atm changes
+
+	// Windowed aggregates used by window_aggregate rule conditions, keyed by aggregate definition
+	WindowAggregates map[string]float64 `json:"window_aggregates,omitempty"`
 }
 
 // Rule represents a scoring rule
 type Rule struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Condition   string  `json:"condition"` // JSON condition expression
-	ScoreImpact float64 `json:"score_impact"`
-	RiskLevel   string  `json:"risk_level"`
-	Priority    int     `json:"priority"`
-	Enabled     bool    `json:"enabled"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Condition   json.RawMessage `json:"condition"` // JSON condition expression
+	ScoreImpact float64         `json:"score_impact"`
+	RiskLevel   string          `json:"risk_level"`
+	Priority    int             `json:"priority"`
+	Enabled     bool            `json:"enabled"`
+	Mode        string          `json:"mode"`            // live, shadow
+	Group       string          `json:"group,omitempty"` // triggered rules in a group contribute at most once
+
+	// Optional effective window and recurring schedule; outside them the rule is skipped
+	EffectiveFrom  *time.Time    `json:"effective_from,omitempty"`
+	EffectiveUntil *time.Time    `json:"effective_until,omitempty"` // expired rules are auto-disabled
+	Schedule       *RuleSchedule `json:"schedule,omitempty"`
+
+	// Regression cases run against the whole rule set before any rule change is accepted
+	TestCases []RuleTestCase `json:"test_cases,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RuleTestCase is a sample transaction and its features with the expected rule outcome.
+// Only the rule's condition is evaluated; effective windows and schedules are ignored.
+type RuleTestCase struct {
+	Name        string       `json:"name"`
+	Transaction Transaction  `json:"transaction"` // created_at sets the hour
+	Features    RiskFeatures `json:"features"`
+	ExpectFire  bool         `json:"expect_fire"`
+}
+
+// RuleSchedule restricts a rule to recurring hours on given days of the week
+type RuleSchedule struct {
+	Days      []string `json:"days,omitempty"`     // mon, tue, ... sun; empty = every day
+	StartHour int      `json:"start_hour"`         // 0-23, inclusive
+	EndHour   int      `json:"end_hour"`           // 0-24, exclusive; before start_hour wraps past midnight
+	Timezone  string   `json:"timezone,omitempty"` // IANA name, default UTC
+}
+
+// RuleMode enum values
+const (
+	RuleModeLive   = "live"
+	RuleModeShadow = "shadow"
+)
+
+// ScoreAggregation configures how the score impacts of triggered live rules are combined
+type ScoreAggregation struct {
+	Strategy string  `json:"strategy"`        // sum_capped, max, noisy_or, diminishing
+	Decay    float64 `json:"decay,omitempty"` // diminishing: weight multiplier for each further rule
+}
+
+// ScoreAggregation strategy values
+const (
+	AggregationSumCapped   = "sum_capped"
+	AggregationMax         = "max"
+	AggregationNoisyOr     = "noisy_or"
+	AggregationDiminishing = "diminishing"
+)
+
+// RuleSetVersion is an immutable, numbered snapshot of every rule
+type RuleSetVersion struct {
+	Version        int              `json:"version"`
+	Rules          []Rule           `json:"rules,omitempty"`
+	RuleCount      int              `json:"rule_count"`
+	Aggregation    ScoreAggregation `json:"aggregation"`
+	Description    string           `json:"description"`
+	RolledBackFrom *int             `json:"rolled_back_from,omitempty"` // version this one restores
+	CreatedBy      *uuid.UUID       `json:"created_by,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// DecisionPolicy is an immutable, numbered version of the decision policy: the score
+// thresholds of each risk level and the decision each risk level leads to, with
+// overrides for segments of accounts, channels, countries and amounts
+type DecisionPolicy struct {
+	Version        int               `json:"version"`
+	Thresholds     RiskThresholds    `json:"thresholds"`
+	Actions        map[string]string `json:"actions"`  // risk level -> decision
+	Fallback       string            `json:"fallback"` // decision when inline authorization runs out of time
+	Segments       []PolicySegment   `json:"segments"`
+	Description    string            `json:"description"`
+	RolledBackFrom *int              `json:"rolled_back_from,omitempty"` // version this one restores
+	CreatedBy      *uuid.UUID        `json:"created_by,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// RiskThresholds are the lowest scores of the medium, high and critical risk levels
+type RiskThresholds struct {
+	Medium   float64 `json:"medium"`
+	High     float64 `json:"high"`
+	Critical float64 `json:"critical"`
+}
+
+// PolicySegment overrides the policy's thresholds and actions for the transactions it
+// matches. Segments are tried in order and the first match applies.
+type PolicySegment struct {
+	Name       string            `json:"name"`
+	Match      SegmentMatch      `json:"match"`
+	Thresholds *RiskThresholds   `json:"thresholds,omitempty"` // nil = the policy's thresholds
+	Actions    map[string]string `json:"actions,omitempty"`    // merged over the policy's actions
+	Fallback   string            `json:"fallback,omitempty"`   // "" = the policy's fallback
+}
+
+// SegmentMatch selects transactions. Empty lists match everything; the amount
+// band includes min_amount and excludes max_amount.
+type SegmentMatch struct {
+	AccountTypes []string `json:"account_types,omitempty"`
+	Channels     []string `json:"channels,omitempty"`
+	Countries    []string `json:"countries,omitempty"`
+	MinAmount    *float64 `json:"min_amount,omitempty"`
+	MaxAmount    *float64 `json:"max_amount,omitempty"`
 }
 
 // JSONB is a helper type for PostgreSQL JSONB columns
@@ -238,18 +553,21 @@ type RiskSummary struct {
 	Date               string  `json:"date"`
 	TotalTransactions  int     `json:"total_transactions"`
 	TotalAmount        float64 `json:"total_amount"`
-	FlaggedCount       int     `json:"flagged_count"`
-	BlockedCount       int     `json:"blocked_count"`
+	FlaggedCount       int     `json:"flagged_count"` // held for step-up or review
+	BlockedCount       int     `json:"blocked_count"` // declined
 	AvgRiskScore       float64 `json:"avg_risk_score"`
 	HighRiskCount      int     `json:"high_risk_count"`
 	CriticalRiskCount  int     `json:"critical_risk_count"`
 	TopRulesTriggered  []RuleCount `json:"top_rules_triggered"`
+	DecisionCounts     map[string]int `json:"decision_counts"` // scoring decisions by action
 }
 
 // RuleCount represents a rule and its trigger count
 type RuleCount struct {
-	RuleID string `json:"rule_id"`
-	Count  int    `json:"count"`
+	RuleID  string  `json:"rule_id"`
+	Count   int     `json:"count"`
+	Shadow  bool    `json:"shadow"`             // counted from shadow_rules_triggered
+	HitRate float64 `json:"hit_rate,omitempty"` // share of scored transactions the rule fired on
 }
 
 // AccountRiskProfile represents an account's risk profile
@@ -482,6 +482,67 @@ func (c *CacheClient) HIncrBy(ctx context.Context, key, field string, incr int64
 	return c.client.HIncrBy(ctx, key, field, incr).Result()
 }
 
+// Pipelined sends the commands queued by fn in a single round trip
+func (c *CacheClient) Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.client.Pipelined(ctx, fn)
+}
+
+// TxPipelined sends the commands queued by fn in a single round trip and applies them
+// atomically (MULTI/EXEC)
+func (c *CacheClient) TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.client.TxPipelined(ctx, fn)
+}
+
+// Watch runs fn in an optimistic transaction over keys: the commands fn queues with
+// TxPipelined fail with redis.TxFailedErr if any of the keys changed since fn started
+func (c *CacheClient) Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	return c.client.Watch(ctx, fn, keys...)
+}
+
+// RiskScoreChannel is the pub/sub channel a transaction's risk score is published on
+func RiskScoreChannel(txID string) string {
+	return fmt.Sprintf("risk_score_ready:%s", txID)
+}
+
+// Publish publishes a JSON-encoded value on a pub/sub channel
+func (c *CacheClient) Publish(ctx context.Context, channel string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.client.Publish(ctx, channel, data).Err()
+}
+
+// Subscription receives JSON-encoded values published on a channel
+type Subscription struct {
+	pubsub *redis.PubSub
+}
+
+// Subscribe subscribes to a pub/sub channel. It returns once the subscription is
+// active, so anything published afterwards is received.
+func (c *CacheClient) Subscribe(ctx context.Context, channel string) (*Subscription, error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	return &Subscription{pubsub: pubsub}, nil
+}
+
+// Receive blocks until a message arrives or ctx is done and decodes it into dest
+func (s *Subscription) Receive(ctx context.Context, dest interface{}) error {
+	msg, err := s.pubsub.ReceiveMessage(ctx)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(msg.Payload), dest)
+}
+
+// Close unsubscribes and releases the subscription's connection
+func (s *Subscription) Close() error {
+	return s.pubsub.Close()
+}
+
 // Close closes the cache client
 func (c *CacheClient) Close() error {
 	return c.client.Close()
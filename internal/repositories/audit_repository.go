@@ -26,7 +26,7 @@ func (r *AuditRepository) Create(ctx context.Context, log *models.AuditLog) erro
 		INSERT INTO audit_logs (
 			id, event_type, entity_id, entity_type, user_id, action,
 			payload, ip_address, user_agent, request_id, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::inet, $9, $10, $11)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, $9, $10, $11)
 	`
 
 	log.ID = uuid.New()
@@ -62,7 +62,7 @@ func (r *AuditRepository) CreateBatch(ctx context.Context, logs []*models.AuditL
 		INSERT INTO audit_logs (
 			id, event_type, entity_id, entity_type, user_id, action,
 			payload, ip_address, user_agent, request_id, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8::inet, $9, $10, $11)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, $9, $10, $11)
 	`
 
 	for _, log := range logs {
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// flaggedAccountCondition selects flagged accounts, aliased a: escalated to a high risk
+// profile, or suspended
+const flaggedAccountCondition = `(a.risk_profile = 'high' OR a.status = 'suspended')`
+
+// CounterpartyRepository handles the account-counterparty graph: which accounts have
+// paid which counterparties, and when
+type CounterpartyRepository struct {
+	db *Database
+}
+
+// NewCounterpartyRepository creates a new counterparty repository
+func NewCounterpartyRepository(db *Database) *CounterpartyRepository {
+	return &CounterpartyRepository{db: db}
+}
+
+// CounterpartyStats are the fan-in and fan-out of a transfer, from transactions and
+// edges strictly before it
+type CounterpartyStats struct {
+	OtherSenders        int // other accounts that paid the counterparty in the window
+	OtherCounterparties int // other counterparties the account paid in the window
+	FlaggedShared       int // the account's counterparties, this one included, also paid by flagged accounts
+}
+
+// Record adds a transfer's edge to the graph. Recording the same transaction again
+// leaves the graph unchanged, in any order.
+func (r *CounterpartyRepository) Record(ctx context.Context, tx *models.Transaction) error {
+	query := `
+		INSERT INTO account_counterparties (account_id, counterparty_id, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (account_id, counterparty_id) DO UPDATE SET
+			first_seen_at = LEAST(account_counterparties.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at = GREATEST(account_counterparties.last_seen_at, EXCLUDED.last_seen_at)
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query, tx.AccountID, tx.CounterpartyID, tx.CreatedAt)
+	return err
+}
+
+// GetStats computes the fan-in and fan-out of a transfer over the window before it
+func (r *CounterpartyRepository) GetStats(ctx context.Context, tx *models.Transaction, window time.Duration) (*CounterpartyStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(DISTINCT account_id)
+			 FROM transactions
+			 WHERE counterparty_id = $2 AND account_id <> $1
+			   AND created_at >= $3 AND created_at < $4),
+			(SELECT COUNT(DISTINCT counterparty_id)
+			 FROM transactions
+			 WHERE account_id = $1 AND counterparty_id IS NOT NULL AND counterparty_id <> $2
+			   AND created_at >= $3 AND created_at < $4),
+			(SELECT COUNT(*)
+			 FROM (
+				 SELECT counterparty_id FROM account_counterparties
+				 WHERE account_id = $1 AND first_seen_at < $4
+				 UNION
+				 SELECT $2::varchar
+			 ) mine
+			 WHERE EXISTS (
+				 SELECT 1
+				 FROM account_counterparties other
+				 JOIN accounts a ON a.id = other.account_id
+				 WHERE other.counterparty_id = mine.counterparty_id
+				   AND other.account_id <> $1
+				   AND other.first_seen_at < $4
+				   AND ` + flaggedAccountCondition + `
+			 ))
+	`
+
+	stats := &CounterpartyStats{}
+	if err := r.db.Pool.QueryRow(ctx, query,
+		tx.AccountID,
+		tx.CounterpartyID,
+		tx.CreatedAt.Add(-window),
+		tx.CreatedAt,
+	).Scan(
+		&stats.OtherSenders,
+		&stats.OtherCounterparties,
+		&stats.FlaggedShared,
+	); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetEgoNetwork retrieves an account's most recently paid counterparties, up to limit,
+// each with the most recent of the other accounts that paid it, up to senderLimit
+func (r *CounterpartyRepository) GetEgoNetwork(ctx context.Context, accountID uuid.UUID, limit, senderLimit int) (*models.EgoNetwork, error) {
+	query := `
+		SELECT mine.counterparty_id, mine.first_seen_at, mine.last_seen_at,
+			   counts.senders, counts.flagged,
+			   other.account_id, other.risk_profile, other.status, other.flagged,
+			   other.first_seen_at, other.last_seen_at
+		FROM (
+			SELECT counterparty_id, first_seen_at, last_seen_at
+			FROM account_counterparties
+			WHERE account_id = $1
+			ORDER BY last_seen_at DESC
+			LIMIT $2
+		) mine
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS senders,
+				   COUNT(*) FILTER (WHERE ` + flaggedAccountCondition + `) AS flagged
+			FROM account_counterparties o
+			JOIN accounts a ON a.id = o.account_id
+			WHERE o.counterparty_id = mine.counterparty_id AND o.account_id <> $1
+		) counts
+		LEFT JOIN LATERAL (
+			SELECT o.account_id, a.risk_profile, a.status, ` + flaggedAccountCondition + ` AS flagged,
+				   o.first_seen_at, o.last_seen_at
+			FROM account_counterparties o
+			JOIN accounts a ON a.id = o.account_id
+			WHERE o.counterparty_id = mine.counterparty_id AND o.account_id <> $1
+			ORDER BY o.last_seen_at DESC
+			LIMIT $3
+		) other ON true
+		ORDER BY mine.last_seen_at DESC, mine.counterparty_id, other.last_seen_at DESC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, accountID, limit, senderLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	network := &models.EgoNetwork{
+		AccountID:      accountID,
+		Counterparties: []models.EgoCounterparty{},
+	}
+	for rows.Next() {
+		var counterparty models.EgoCounterparty
+		var senderID *uuid.UUID
+		var riskProfile, status *string
+		var flagged *bool
+		var senderFirstSeen, senderLastSeen *time.Time
+		if err := rows.Scan(
+			&counterparty.CounterpartyID,
+			&counterparty.FirstSeenAt,
+			&counterparty.LastSeenAt,
+			&counterparty.SenderCount,
+			&counterparty.FlaggedSenders,
+			&senderID,
+			&riskProfile,
+			&status,
+			&flagged,
+			&senderFirstSeen,
+			&senderLastSeen,
+		); err != nil {
+			return nil, err
+		}
+
+		n := len(network.Counterparties)
+		if n == 0 || network.Counterparties[n-1].CounterpartyID != counterparty.CounterpartyID {
+			counterparty.Senders = []models.CounterpartySender{}
+			network.Counterparties = append(network.Counterparties, counterparty)
+			n++
+		}
+		if senderID != nil {
+			current := &network.Counterparties[n-1]
+			current.Senders = append(current.Senders, models.CounterpartySender{
+				AccountID:   *senderID,
+				RiskProfile: *riskProfile,
+				Status:      *status,
+				Flagged:     *flagged,
+				FirstSeenAt: *senderFirstSeen,
+				LastSeenAt:  *senderLastSeen,
+			})
+		}
+	}
+
+	return network, rows.Err()
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+var (
+	ErrDecisionPolicyNotFound = errors.New("decision policy version not found")
+)
+
+// DecisionPolicyRepository handles decision policy version database operations
+type DecisionPolicyRepository struct {
+	db *Database
+}
+
+// NewDecisionPolicyRepository creates a new decision policy repository
+func NewDecisionPolicyRepository(db *Database) *DecisionPolicyRepository {
+	return &DecisionPolicyRepository{db: db}
+}
+
+// storedPolicy is the part of a decision policy kept in the policy column
+type storedPolicy struct {
+	Thresholds models.RiskThresholds  `json:"thresholds"`
+	Actions    map[string]string      `json:"actions"`
+	Fallback   string                 `json:"fallback,omitempty"`
+	Segments   []models.PolicySegment `json:"segments"`
+}
+
+// Publish stores a policy as the next version number and returns it
+func (r *DecisionPolicyRepository) Publish(ctx context.Context, policy *models.DecisionPolicy) (int, error) {
+	policyBytes, err := json.Marshal(storedPolicy{
+		Thresholds: policy.Thresholds,
+		Actions:    policy.Actions,
+		Fallback:   policy.Fallback,
+		Segments:   policy.Segments,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO decision_policy_versions (version, policy, description, rolled_back_from, created_by, created_at)
+		VALUES ((SELECT COALESCE(MAX(version), 0) + 1 FROM decision_policy_versions), $1, $2, $3, $4, $5)
+		RETURNING version
+	`
+
+	var version int
+	err = r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		// Serialize publishers so version numbers are gapless
+		if _, err := tx.Exec(ctx, `LOCK TABLE decision_policy_versions IN EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, query,
+			policyBytes,
+			policy.Description,
+			policy.RolledBackFrom,
+			policy.CreatedBy,
+			time.Now(),
+		).Scan(&version)
+	})
+
+	return version, err
+}
+
+// GetActiveDecisionPolicy retrieves the latest published decision policy
+func (r *DecisionPolicyRepository) GetActiveDecisionPolicy(ctx context.Context) (*models.DecisionPolicy, error) {
+	query := `
+		SELECT version, policy, COALESCE(description, ''), rolled_back_from, created_by, created_at
+		FROM decision_policy_versions
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	return scanDecisionPolicy(r.db.Pool.QueryRow(ctx, query))
+}
+
+// GetVersion retrieves a specific decision policy version
+func (r *DecisionPolicyRepository) GetVersion(ctx context.Context, version int) (*models.DecisionPolicy, error) {
+	query := `
+		SELECT version, policy, COALESCE(description, ''), rolled_back_from, created_by, created_at
+		FROM decision_policy_versions
+		WHERE version = $1
+	`
+
+	return scanDecisionPolicy(r.db.Pool.QueryRow(ctx, query, version))
+}
+
+// ListVersions retrieves decision policy versions, newest first
+func (r *DecisionPolicyRepository) ListVersions(ctx context.Context, page, pageSize int) ([]*models.DecisionPolicy, int, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM decision_policy_versions`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT version, policy, COALESCE(description, ''), rolled_back_from, created_by, created_at
+		FROM decision_policy_versions
+		ORDER BY version DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var policies []*models.DecisionPolicy
+	for rows.Next() {
+		policy, err := scanDecisionPolicy(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, total, rows.Err()
+}
+
+func scanDecisionPolicy(row pgx.Row) (*models.DecisionPolicy, error) {
+	policy := &models.DecisionPolicy{}
+	var policyBytes []byte
+
+	err := row.Scan(
+		&policy.Version,
+		&policyBytes,
+		&policy.Description,
+		&policy.RolledBackFrom,
+		&policy.CreatedBy,
+		&policy.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDecisionPolicyNotFound
+		}
+		return nil, err
+	}
+
+	var stored storedPolicy
+	if err := json.Unmarshal(policyBytes, &stored); err != nil {
+		return nil, err
+	}
+	policy.Thresholds = stored.Thresholds
+	policy.Actions = stored.Actions
+	policy.Fallback = stored.Fallback
+	policy.Segments = stored.Segments
+	if policy.Segments == nil {
+		policy.Segments = []models.PolicySegment{}
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// DeviceRepository handles each account's history of known devices
+type DeviceRepository struct {
+	db *Database
+}
+
+// NewDeviceRepository creates a new device repository
+func NewDeviceRepository(db *Database) *DeviceRepository {
+	return &DeviceRepository{db: db}
+}
+
+// DeviceHistory is an account's device history as of a point in time
+type DeviceHistory struct {
+	KnownDevices int        // devices first seen before that time
+	FirstSeenAt  *time.Time // when the device was first seen, if before that time
+}
+
+// Record adds a transaction's device to its account's history. Recording the same
+// transaction again leaves the history unchanged, in any order.
+func (r *DeviceRepository) Record(ctx context.Context, tx *models.Transaction) error {
+	query := `
+		INSERT INTO account_devices (
+			account_id, device_key, device_id, device_fingerprint,
+			last_user_agent, last_ip_address, first_seen_at, last_seen_at
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6::text, '')::inet, $7, $7)
+		ON CONFLICT (account_id, device_key) DO UPDATE SET
+			device_id = COALESCE(account_devices.device_id, EXCLUDED.device_id),
+			device_fingerprint = CASE WHEN EXCLUDED.last_seen_at >= account_devices.last_seen_at
+				THEN COALESCE(EXCLUDED.device_fingerprint, account_devices.device_fingerprint)
+				ELSE account_devices.device_fingerprint END,
+			last_user_agent = CASE WHEN EXCLUDED.last_seen_at >= account_devices.last_seen_at
+				THEN COALESCE(EXCLUDED.last_user_agent, account_devices.last_user_agent)
+				ELSE account_devices.last_user_agent END,
+			last_ip_address = CASE WHEN EXCLUDED.last_seen_at >= account_devices.last_seen_at
+				THEN COALESCE(EXCLUDED.last_ip_address, account_devices.last_ip_address)
+				ELSE account_devices.last_ip_address END,
+			first_seen_at = LEAST(account_devices.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at = GREATEST(account_devices.last_seen_at, EXCLUDED.last_seen_at)
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query,
+		tx.AccountID,
+		tx.DeviceKey(),
+		tx.DeviceID,
+		tx.DeviceFingerprint,
+		tx.UserAgent,
+		tx.IPAddress,
+		tx.CreatedAt,
+	)
+	return err
+}
+
+// GetHistory retrieves an account's device history strictly before the given time:
+// how many devices it had used, and when it first used the device with deviceKey
+func (r *DeviceRepository) GetHistory(ctx context.Context, accountID uuid.UUID, deviceKey string, before time.Time) (*DeviceHistory, error) {
+	query := `
+		SELECT COUNT(*),
+			   MIN(first_seen_at) FILTER (WHERE device_key = $2)
+		FROM account_devices
+		WHERE account_id = $1 AND first_seen_at < $3
+	`
+
+	history := &DeviceHistory{}
+	if err := r.db.Pool.QueryRow(ctx, query, accountID, deviceKey, before).Scan(
+		&history.KnownDevices,
+		&history.FirstSeenAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetByAccountID retrieves an account's devices, most recently seen first
+func (r *DeviceRepository) GetByAccountID(ctx context.Context, accountID uuid.UUID) ([]models.AccountDevice, error) {
+	query := `
+		SELECT account_id, device_key, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(last_user_agent, ''), COALESCE(host(last_ip_address), ''),
+			   first_seen_at, last_seen_at
+		FROM account_devices
+		WHERE account_id = $1
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []models.AccountDevice{}
+	for rows.Next() {
+		var device models.AccountDevice
+		if err := rows.Scan(
+			&device.AccountID,
+			&device.DeviceKey,
+			&device.DeviceID,
+			&device.DeviceFingerprint,
+			&device.LastUserAgent,
+			&device.LastIPAddress,
+			&device.FirstSeenAt,
+			&device.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
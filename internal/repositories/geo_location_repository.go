@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+var (
+	ErrGeoLocationNotFound = errors.New("geo location not found")
+)
+
+// GeoLocationRepository handles geo_locations reference data
+type GeoLocationRepository struct {
+	db *Database
+}
+
+// NewGeoLocationRepository creates a new geo location repository
+func NewGeoLocationRepository(db *Database) *GeoLocationRepository {
+	return &GeoLocationRepository{db: db}
+}
+
+const geoLocationColumns = `
+	id, country_code, country_name, COALESCE(city_name, ''), COALESCE(region, ''),
+	latitude, longitude, COALESCE(timezone, ''), COALESCE(risk_level, 'low'),
+	COALESCE(is_sanctioned, false), COALESCE(population, 0), updated_at
+`
+
+// GetAll retrieves every city and country
+func (r *GeoLocationRepository) GetAll(ctx context.Context) ([]models.GeoLocation, error) {
+	query := `SELECT ` + geoLocationColumns + `
+		FROM geo_locations
+		ORDER BY country_code, city_name NULLS FIRST
+	`
+
+	return r.query(ctx, query)
+}
+
+// GetCountries retrieves the country-level entries
+func (r *GeoLocationRepository) GetCountries(ctx context.Context) ([]models.GeoLocation, error) {
+	query := `SELECT ` + geoLocationColumns + `
+		FROM geo_locations
+		WHERE city_name IS NULL
+		ORDER BY country_code
+	`
+
+	return r.query(ctx, query)
+}
+
+// GetCountry retrieves a country's country-level entry
+func (r *GeoLocationRepository) GetCountry(ctx context.Context, countryCode string) (*models.GeoLocation, error) {
+	query := `SELECT ` + geoLocationColumns + `
+		FROM geo_locations
+		WHERE country_code = $1 AND city_name IS NULL
+	`
+
+	loc, err := scanGeoLocation(r.db.Pool.QueryRow(ctx, query, countryCode))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGeoLocationNotFound
+	}
+	return loc, err
+}
+
+// UpsertCountryRisk sets a country's risk level and sanctions flag, creating its
+// country-level entry (without coordinates) when it has none
+func (r *GeoLocationRepository) UpsertCountryRisk(ctx context.Context, loc *models.GeoLocation) (*models.GeoLocation, error) {
+	query := `
+		INSERT INTO geo_locations (country_code, country_name, risk_level, is_sanctioned)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (country_code) WHERE city_name IS NULL DO UPDATE SET
+			country_name = EXCLUDED.country_name,
+			risk_level = EXCLUDED.risk_level,
+			is_sanctioned = EXCLUDED.is_sanctioned,
+			updated_at = NOW()
+		RETURNING ` + geoLocationColumns
+
+	return scanGeoLocation(r.db.Pool.QueryRow(ctx, query,
+		loc.CountryCode,
+		loc.CountryName,
+		loc.RiskLevel,
+		loc.IsSanctioned,
+	))
+}
+
+func (r *GeoLocationRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.GeoLocation, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []models.GeoLocation{}
+	for rows.Next() {
+		loc, err := scanGeoLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, *loc)
+	}
+
+	return locations, rows.Err()
+}
+
+func scanGeoLocation(row pgx.Row) (*models.GeoLocation, error) {
+	loc := &models.GeoLocation{}
+	err := row.Scan(
+		&loc.ID,
+		&loc.CountryCode,
+		&loc.CountryName,
+		&loc.CityName,
+		&loc.Region,
+		&loc.Latitude,
+		&loc.Longitude,
+		&loc.Timezone,
+		&loc.RiskLevel,
+		&loc.IsSanctioned,
+		&loc.Population,
+		&loc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
@@ -0,0 +1,333 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+var (
+	ErrMerchantProfileNotFound     = errors.New("merchant profile not found")
+	ErrMerchantTransactionNotFound = errors.New("transaction not recorded in a merchant profile")
+	ErrChargebackRecorded          = errors.New("chargeback already recorded")
+)
+
+// MerchantProfileRepository handles merchant reputation profiles
+type MerchantProfileRepository struct {
+	db *Database
+}
+
+// NewMerchantProfileRepository creates a new merchant profile repository
+func NewMerchantProfileRepository(db *Database) *MerchantProfileRepository {
+	return &MerchantProfileRepository{db: db}
+}
+
+const merchantProfileColumns = `
+	id, merchant_key, merchant_name, merchant_category, transaction_count, total_amount,
+	flagged_count, blocked_count, chargeback_count, account_count, first_seen_at, last_seen_at,
+	risk_override, COALESCE(override_reason, ''), overridden_by, overridden_at, updated_at
+`
+
+// qualifiedMerchantProfileColumns are merchantProfileColumns for queries joining other tables
+const qualifiedMerchantProfileColumns = `
+	merchant_profiles.id, merchant_profiles.merchant_key, merchant_profiles.merchant_name,
+	merchant_profiles.merchant_category, merchant_profiles.transaction_count, merchant_profiles.total_amount,
+	merchant_profiles.flagged_count, merchant_profiles.blocked_count, merchant_profiles.chargeback_count,
+	merchant_profiles.account_count, merchant_profiles.first_seen_at, merchant_profiles.last_seen_at,
+	merchant_profiles.risk_override, COALESCE(merchant_profiles.override_reason, ''),
+	merchant_profiles.overridden_by, merchant_profiles.overridden_at, merchant_profiles.updated_at
+`
+
+// GetByKey retrieves a merchant's profile by its normalized key
+func (r *MerchantProfileRepository) GetByKey(ctx context.Context, merchantKey string) (*models.MerchantProfile, error) {
+	query := `SELECT ` + merchantProfileColumns + `
+		FROM merchant_profiles
+		WHERE merchant_key = $1
+	`
+
+	profile, err := scanMerchantProfile(r.db.Pool.QueryRow(ctx, query, merchantKey))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMerchantProfileNotFound
+	}
+	return profile, err
+}
+
+// GetAsOf retrieves a merchant's profile as it stood at asOf: counted from the
+// transactions recorded before then, other than excludeTxID, with chargebacks labeled
+// before then. Decisions are counted as currently resolved and the override is the
+// current one.
+func (r *MerchantProfileRepository) GetAsOf(ctx context.Context, merchantKey string, asOf time.Time, excludeTxID uuid.UUID) (*models.MerchantProfile, error) {
+	query := `
+		SELECT
+			p.id, p.merchant_key, p.merchant_name, p.merchant_category,
+			COUNT(mt.transaction_id),
+			COALESCE(SUM(mt.amount), 0),
+			COUNT(mt.transaction_id) FILTER (WHERE mt.decision IN ($4, $5)),
+			COUNT(mt.transaction_id) FILTER (WHERE mt.decision = $6),
+			COUNT(mt.transaction_id) FILTER (WHERE mt.charged_back_at < $2),
+			COUNT(DISTINCT mt.account_id),
+			COALESCE(MIN(mt.created_at), $2),
+			COALESCE(MAX(mt.created_at), $2),
+			p.risk_override, COALESCE(p.override_reason, ''), p.overridden_by, p.overridden_at, p.updated_at
+		FROM merchant_profiles p
+		LEFT JOIN merchant_transactions mt ON mt.merchant_key = p.merchant_key
+			AND mt.created_at < $2
+			AND mt.transaction_id <> $3
+		WHERE p.merchant_key = $1
+		GROUP BY p.id
+	`
+
+	profile, err := scanMerchantProfile(r.db.Pool.QueryRow(ctx, query,
+		merchantKey,
+		asOf,
+		excludeTxID,
+		models.DecisionStepUp,
+		models.DecisionReview,
+		models.DecisionDecline,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMerchantProfileNotFound
+	}
+	return profile, err
+}
+
+// GetByID retrieves a merchant profile by ID
+func (r *MerchantProfileRepository) GetByID(ctx context.Context, id int64) (*models.MerchantProfile, error) {
+	query := `SELECT ` + merchantProfileColumns + `
+		FROM merchant_profiles
+		WHERE id = $1
+	`
+
+	profile, err := scanMerchantProfile(r.db.Pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMerchantProfileNotFound
+	}
+	return profile, err
+}
+
+// List retrieves merchant profiles with pagination, busiest first
+func (r *MerchantProfileRepository) List(ctx context.Context, page, pageSize int) ([]*models.MerchantProfile, int, error) {
+	offset := (page - 1) * pageSize
+
+	countQuery := `SELECT COUNT(*) FROM merchant_profiles`
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, countQuery).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + merchantProfileColumns + `
+		FROM merchant_profiles
+		ORDER BY transaction_count DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	profiles := []*models.MerchantProfile{}
+	for rows.Next() {
+		profile, err := scanMerchantProfile(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, total, rows.Err()
+}
+
+// RecordTransaction adds a scored transaction to its merchant's profile, creating the
+// profile on the merchant's first transaction. A transaction counts once: recording it
+// again, e.g. when it is rescored, leaves the profile unchanged.
+func (r *MerchantProfileRepository) RecordTransaction(ctx context.Context, merchantKey string, tx *models.Transaction, decision string) error {
+	query := `
+		WITH event AS (
+			INSERT INTO merchant_transactions (transaction_id, merchant_key, account_id, amount, decision, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (transaction_id) DO NOTHING
+			RETURNING transaction_id
+		), new_account AS (
+			SELECT COUNT(*) AS n
+			FROM event
+			WHERE NOT EXISTS (
+				SELECT 1 FROM merchant_transactions WHERE merchant_key = $2 AND account_id = $3
+			)
+		)
+		INSERT INTO merchant_profiles (
+			merchant_key, merchant_name, merchant_category, transaction_count, total_amount,
+			flagged_count, blocked_count, account_count, first_seen_at, last_seen_at
+		)
+		SELECT $2, $7, $8, 1, $4::decimal, $9::bigint, $10::bigint, new_account.n, $6::timestamptz, $6::timestamptz
+		FROM event, new_account
+		ON CONFLICT (merchant_key) DO UPDATE SET
+			transaction_count = merchant_profiles.transaction_count + 1,
+			total_amount = merchant_profiles.total_amount + EXCLUDED.total_amount,
+			flagged_count = merchant_profiles.flagged_count + EXCLUDED.flagged_count,
+			blocked_count = merchant_profiles.blocked_count + EXCLUDED.blocked_count,
+			account_count = merchant_profiles.account_count + EXCLUDED.account_count,
+			first_seen_at = LEAST(merchant_profiles.first_seen_at, EXCLUDED.first_seen_at),
+			last_seen_at = GREATEST(merchant_profiles.last_seen_at, EXCLUDED.last_seen_at),
+			updated_at = NOW()
+	`
+
+	flagged, blocked := decisionCounts(decision)
+	_, err := r.db.Pool.Exec(ctx, query,
+		tx.ID,
+		merchantKey,
+		tx.AccountID,
+		tx.Amount,
+		decision,
+		tx.CreatedAt,
+		tx.Merchant,
+		tx.MerchantCategory,
+		flagged,
+		blocked,
+	)
+	return err
+}
+
+// ResolveDecision replaces the decision a recorded transaction counts as, when a hold on
+// it is resolved or a monitored approval escalated, moving it between its merchant's
+// flagged and blocked counts. A transaction missing from the profiles is left out.
+func (r *MerchantProfileRepository) ResolveDecision(ctx context.Context, txID uuid.UUID, decision string) error {
+	query := `
+		WITH previous AS (
+			SELECT merchant_key, decision FROM merchant_transactions
+			WHERE transaction_id = $1
+			FOR UPDATE
+		), resolved AS (
+			UPDATE merchant_transactions SET decision = $2
+			FROM previous
+			WHERE merchant_transactions.transaction_id = $1 AND previous.decision <> $2
+			RETURNING previous.merchant_key, previous.decision
+		)
+		UPDATE merchant_profiles SET
+			flagged_count = merchant_profiles.flagged_count + $3
+				- CASE WHEN resolved.decision IN ($5, $6) THEN 1 ELSE 0 END,
+			blocked_count = merchant_profiles.blocked_count + $4
+				- CASE WHEN resolved.decision = $7 THEN 1 ELSE 0 END,
+			updated_at = NOW()
+		FROM resolved
+		WHERE merchant_profiles.merchant_key = resolved.merchant_key
+	`
+
+	flagged, blocked := decisionCounts(decision)
+	_, err := r.db.Pool.Exec(ctx, query,
+		txID,
+		decision,
+		flagged,
+		blocked,
+		models.DecisionStepUp,
+		models.DecisionReview,
+		models.DecisionDecline,
+	)
+	return err
+}
+
+// decisionCounts is what a transaction with the decision adds to its merchant's flagged
+// and blocked counts
+func decisionCounts(decision string) (int, int) {
+	switch decision {
+	case models.DecisionStepUp, models.DecisionReview:
+		return 1, 0
+	case models.DecisionDecline:
+		return 0, 1
+	}
+	return 0, 0
+}
+
+// RecordChargeback labels a transaction as charged back and counts it in its merchant's
+// profile. It returns the updated profile.
+func (r *MerchantProfileRepository) RecordChargeback(ctx context.Context, txID uuid.UUID, chargedBackAt time.Time) (*models.MerchantProfile, error) {
+	var existing *time.Time
+	err := r.db.Pool.QueryRow(ctx,
+		`SELECT charged_back_at FROM merchant_transactions WHERE transaction_id = $1`, txID,
+	).Scan(&existing)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMerchantTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrChargebackRecorded
+	}
+
+	query := `
+		WITH labeled AS (
+			UPDATE merchant_transactions SET charged_back_at = $2
+			WHERE transaction_id = $1 AND charged_back_at IS NULL
+			RETURNING merchant_key
+		)
+		UPDATE merchant_profiles SET
+			chargeback_count = merchant_profiles.chargeback_count + 1,
+			updated_at = NOW()
+		FROM labeled
+		WHERE merchant_profiles.merchant_key = labeled.merchant_key
+		RETURNING ` + qualifiedMerchantProfileColumns
+
+	profile, err := scanMerchantProfile(r.db.Pool.QueryRow(ctx, query, txID, chargedBackAt))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Labeled concurrently
+		return nil, ErrChargebackRecorded
+	}
+	return profile, err
+}
+
+// SetOverride sets or, with a nil risk score, clears a merchant's risk score override
+func (r *MerchantProfileRepository) SetOverride(ctx context.Context, id int64, riskScore *float64, reason string, userID *uuid.UUID) (*models.MerchantProfile, error) {
+	query := `
+		UPDATE merchant_profiles SET
+			risk_override = $2,
+			override_reason = NULLIF($3, ''),
+			overridden_by = $4,
+			overridden_at = CASE WHEN $2::decimal IS NULL THEN NULL ELSE NOW() END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + merchantProfileColumns
+
+	if riskScore == nil {
+		reason, userID = "", nil
+	}
+
+	profile, err := scanMerchantProfile(r.db.Pool.QueryRow(ctx, query, id, riskScore, reason, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMerchantProfileNotFound
+	}
+	return profile, err
+}
+
+func scanMerchantProfile(row pgx.Row) (*models.MerchantProfile, error) {
+	profile := &models.MerchantProfile{}
+	err := row.Scan(
+		&profile.ID,
+		&profile.MerchantKey,
+		&profile.MerchantName,
+		&profile.MerchantCategory,
+		&profile.TransactionCount,
+		&profile.TotalAmount,
+		&profile.FlaggedCount,
+		&profile.BlockedCount,
+		&profile.ChargebackCount,
+		&profile.AccountCount,
+		&profile.FirstSeenAt,
+		&profile.LastSeenAt,
+		&profile.RiskOverride,
+		&profile.OverrideReason,
+		&profile.OverriddenBy,
+		&profile.OverriddenAt,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
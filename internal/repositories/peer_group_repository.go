@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+var (
+	ErrPeerGroupRebuildInProgress = errors.New("peer group rebuild already in progress")
+)
+
+// PeerGroupRepository handles peer-group spend baselines
+type PeerGroupRepository struct {
+	db *Database
+}
+
+// NewPeerGroupRepository creates a new peer group repository
+func NewPeerGroupRepository(db *Database) *PeerGroupRepository {
+	return &PeerGroupRepository{db: db}
+}
+
+// GetAll retrieves every peer-group baseline
+func (r *PeerGroupRepository) GetAll(ctx context.Context) ([]models.PeerGroupBaseline, error) {
+	query := `
+		SELECT account_type, risk_profile, age_bucket, merchant_category, transaction_count,
+			   account_count, mean_amount, stddev_amount, mean_log_amount, stddev_log_amount,
+			   p50_amount, p90_amount, p99_amount, window_start, window_end, computed_at
+		FROM peer_group_baselines
+		ORDER BY account_type, risk_profile, age_bucket, merchant_category
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baselines := []models.PeerGroupBaseline{}
+	for rows.Next() {
+		var b models.PeerGroupBaseline
+		if err := rows.Scan(
+			&b.AccountType,
+			&b.RiskProfile,
+			&b.AgeBucket,
+			&b.MerchantCategory,
+			&b.TransactionCount,
+			&b.AccountCount,
+			&b.MeanAmount,
+			&b.StdDevAmount,
+			&b.MeanLogAmount,
+			&b.StdDevLogAmount,
+			&b.P50Amount,
+			&b.P90Amount,
+			&b.P99Amount,
+			&b.WindowStart,
+			&b.WindowEnd,
+			&b.ComputedAt,
+		); err != nil {
+			return nil, err
+		}
+		baselines = append(baselines, b)
+	}
+
+	return baselines, rows.Err()
+}
+
+// Rebuild replaces the baselines with the spend distributions of the transactions in
+// [windowStart, windowEnd), keeping groups with at least minTransactions. Declined
+// transactions are left out of the baselines. Account-age buckets and category
+// normalization must match scoring.peerGroupAgeBucket and scoring.peerGroupCategory.
+// Only one rebuild runs at a time across instances; the others return
+// ErrPeerGroupRebuildInProgress.
+func (r *PeerGroupRepository) Rebuild(ctx context.Context, windowStart, windowEnd time.Time, minTransactions int) (int64, error) {
+	query := `
+		WITH peer_tx AS (
+			SELECT a.account_type,
+				   a.risk_profile,
+				   CASE
+					   WHEN t.created_at < a.created_at + INTERVAL '30 days' THEN '0-30d'
+					   WHEN t.created_at < a.created_at + INTERVAL '90 days' THEN '30-90d'
+					   WHEN t.created_at < a.created_at + INTERVAL '365 days' THEN '90-365d'
+					   ELSE '365d+'
+				   END AS age_bucket,
+				   LOWER(TRIM(COALESCE(t.merchant_category, ''))) AS merchant_category,
+				   t.account_id,
+				   t.amount
+			FROM transactions t
+			JOIN accounts a ON a.id = t.account_id
+			WHERE t.created_at >= $1 AND t.created_at < $2
+			  AND t.amount > 0
+			  AND t.status <> 'declined'
+		)
+		INSERT INTO peer_group_baselines (
+			account_type, risk_profile, age_bucket, merchant_category, transaction_count,
+			account_count, mean_amount, stddev_amount, mean_log_amount, stddev_log_amount,
+			p50_amount, p90_amount, p99_amount, window_start, window_end
+		)
+		SELECT account_type,
+			   risk_profile,
+			   age_bucket,
+			   CASE WHEN GROUPING(merchant_category) = 1 THEN '*' ELSE merchant_category END,
+			   COUNT(*),
+			   COUNT(DISTINCT account_id),
+			   AVG(amount),
+			   COALESCE(STDDEV_SAMP(amount), 0),
+			   AVG(LN(amount)),
+			   COALESCE(STDDEV_SAMP(LN(amount)), 0),
+			   PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY amount),
+			   PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY amount),
+			   PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY amount),
+			   $1, $2
+		FROM peer_tx
+		GROUP BY GROUPING SETS (
+			(account_type, risk_profile, age_bucket, merchant_category),
+			(account_type, risk_profile, age_bucket)
+		)
+		HAVING COUNT(*) >= $3
+	`
+
+	var inserted int64
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx,
+			`SELECT pg_try_advisory_xact_lock(hashtext('peer_group_baselines'))`,
+		).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return ErrPeerGroupRebuildInProgress
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM peer_group_baselines`); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, query, windowStart, windowEnd, minTransactions)
+		if err != nil {
+			return err
+		}
+		inserted = tag.RowsAffected()
+		return nil
+	})
+
+	return inserted, err
+}
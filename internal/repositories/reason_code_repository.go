@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+var (
+	ErrReasonCodeNotFound = errors.New("reason code not found")
+)
+
+// ReasonCodeRepository handles reason-code catalog database operations
+type ReasonCodeRepository struct {
+	db *Database
+}
+
+// NewReasonCodeRepository creates a new reason code repository
+func NewReasonCodeRepository(db *Database) *ReasonCodeRepository {
+	return &ReasonCodeRepository{db: db}
+}
+
+// GetAll retrieves the whole catalog ordered by code
+func (r *ReasonCodeRepository) GetAll(ctx context.Context) ([]models.ReasonCode, error) {
+	query := `
+		SELECT code, description, rules, anomalies, messages, created_at, updated_at
+		FROM reason_codes
+		ORDER BY code
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []models.ReasonCode{}
+	for rows.Next() {
+		rc, err := scanReasonCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, *rc)
+	}
+
+	return codes, rows.Err()
+}
+
+// GetByCode retrieves a catalog entry by code
+func (r *ReasonCodeRepository) GetByCode(ctx context.Context, code string) (*models.ReasonCode, error) {
+	query := `
+		SELECT code, description, rules, anomalies, messages, created_at, updated_at
+		FROM reason_codes
+		WHERE code = $1
+	`
+
+	rc, err := scanReasonCode(r.db.Pool.QueryRow(ctx, query, code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReasonCodeNotFound
+		}
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// Upsert creates a catalog entry or replaces the existing one with the same code
+func (r *ReasonCodeRepository) Upsert(ctx context.Context, rc *models.ReasonCode) error {
+	query := `
+		INSERT INTO reason_codes (code, description, rules, anomalies, messages, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (code) DO UPDATE SET
+			description = EXCLUDED.description,
+			rules = EXCLUDED.rules,
+			anomalies = EXCLUDED.anomalies,
+			messages = EXCLUDED.messages,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	messagesBytes, err := json.Marshal(rc.Messages)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Pool.QueryRow(ctx, query,
+		rc.Code,
+		rc.Description,
+		rc.Rules,
+		rc.Anomalies,
+		messagesBytes,
+		time.Now(),
+	).Scan(&rc.CreatedAt, &rc.UpdatedAt)
+}
+
+// Delete removes a catalog entry
+func (r *ReasonCodeRepository) Delete(ctx context.Context, code string) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM reason_codes WHERE code = $1`, code)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReasonCodeNotFound
+	}
+	return nil
+}
+
+func scanReasonCode(row pgx.Row) (*models.ReasonCode, error) {
+	rc := &models.ReasonCode{}
+	var messagesBytes []byte
+
+	if err := row.Scan(
+		&rc.Code,
+		&rc.Description,
+		&rc.Rules,
+		&rc.Anomalies,
+		&messagesBytes,
+		&rc.CreatedAt,
+		&rc.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(messagesBytes, &rc.Messages); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
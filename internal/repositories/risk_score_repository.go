@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -31,26 +32,39 @@ func (r *RiskScoreRepository) Create(ctx context.Context, score *models.RiskScor
 	query := `
 		INSERT INTO risk_scores (
 			id, transaction_id, transaction_created_at, score, risk_level,
-			rules_triggered, features, model_version, processing_time_ms, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			rules_triggered, shadow_rules_triggered, features, model_version, rule_set_version,
+			processing_time_ms, created_at, reason_codes, decision_policy_version, policy_segment,
+			decision
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0), $11, $12, $13, NULLIF($14, 0), NULLIF($15, ''),
+			NULLIF($16, ''))
 	`
 
 	score.ID = uuid.New()
-	score.CreatedAt = time.Now()
+	score.CreatedAt = time.Now().Truncate(time.Microsecond) // as Postgres stores it
 
 	featuresBytes, _ := score.Features.Value()
+	reasonCodesBytes, err := encodeReasonCodes(score.ReasonCodes)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err = r.db.Pool.Exec(ctx, query,
 		score.ID,
 		score.TransactionID,
 		score.CreatedAt, // transaction_created_at - using score.CreatedAt as placeholder
 		score.Score,
 		score.RiskLevel,
 		pq.Array(score.RulesTriggered),
+		pq.Array(score.ShadowRulesTriggered),
 		featuresBytes,
 		score.ModelVersion,
+		score.RuleSetVersion,
 		score.ProcessingTimeMs,
 		score.CreatedAt,
+		reasonCodesBytes,
+		score.DecisionPolicyVersion,
+		score.PolicySegment,
+		score.Decision,
 	)
 
 	return err
@@ -61,26 +75,39 @@ func (r *RiskScoreRepository) CreateWithTransactionTime(ctx context.Context, sco
 	query := `
 		INSERT INTO risk_scores (
 			id, transaction_id, transaction_created_at, score, risk_level,
-			rules_triggered, features, model_version, processing_time_ms, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			rules_triggered, shadow_rules_triggered, features, model_version, rule_set_version,
+			processing_time_ms, created_at, reason_codes, decision_policy_version, policy_segment,
+			decision
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0), $11, $12, $13, NULLIF($14, 0), NULLIF($15, ''),
+			NULLIF($16, ''))
 	`
 
 	score.ID = uuid.New()
-	score.CreatedAt = time.Now()
+	score.CreatedAt = time.Now().Truncate(time.Microsecond) // as Postgres stores it
 
 	featuresBytes, _ := score.Features.Value()
+	reasonCodesBytes, err := encodeReasonCodes(score.ReasonCodes)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err = r.db.Pool.Exec(ctx, query,
 		score.ID,
 		score.TransactionID,
 		transactionCreatedAt,
 		score.Score,
 		score.RiskLevel,
 		pq.Array(score.RulesTriggered),
+		pq.Array(score.ShadowRulesTriggered),
 		featuresBytes,
 		score.ModelVersion,
+		score.RuleSetVersion,
 		score.ProcessingTimeMs,
 		score.CreatedAt,
+		reasonCodesBytes,
+		score.DecisionPolicyVersion,
+		score.PolicySegment,
+		score.Decision,
 	)
 
 	return err
@@ -90,14 +117,16 @@ func (r *RiskScoreRepository) CreateWithTransactionTime(ctx context.Context, sco
 func (r *RiskScoreRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.RiskScore, error) {
 	query := `
 		SELECT id, transaction_id, score, risk_level, rules_triggered,
-			   features, model_version, processing_time_ms, created_at
+			   COALESCE(shadow_rules_triggered, '{}'), features, model_version, COALESCE(rule_set_version, 0), processing_time_ms, created_at,
+			   reason_codes, COALESCE(decision_policy_version, 0), COALESCE(policy_segment, ''),
+			   COALESCE(decision, '')
 		FROM risk_scores
 		WHERE transaction_id = $1
 	`
 
 	score := &models.RiskScore{}
 	var rulesTriggered []string
-	var featuresBytes []byte
+	var featuresBytes, reasonCodesBytes []byte
 
 	err := r.db.Pool.QueryRow(ctx, query, transactionID).Scan(
 		&score.ID,
@@ -105,10 +134,16 @@ func (r *RiskScoreRepository) GetByTransactionID(ctx context.Context, transactio
 		&score.Score,
 		&score.RiskLevel,
 		&rulesTriggered, // pgx can handle []string directly
+		&score.ShadowRulesTriggered,
 		&featuresBytes,
 		&score.ModelVersion,
+		&score.RuleSetVersion,
 		&score.ProcessingTimeMs,
 		&score.CreatedAt,
+		&reasonCodesBytes,
+		&score.DecisionPolicyVersion,
+		&score.PolicySegment,
+		&score.Decision,
 	)
 
 	if err != nil {
@@ -120,6 +155,9 @@ func (r *RiskScoreRepository) GetByTransactionID(ctx context.Context, transactio
 
 	score.RulesTriggered = rulesTriggered
 	score.Features.Scan(featuresBytes)
+	if err := decodeReasonCodes(reasonCodesBytes, &score.ReasonCodes); err != nil {
+		return nil, err
+	}
 	return score, nil
 }
 
@@ -135,7 +173,9 @@ func (r *RiskScoreRepository) GetByRiskLevel(ctx context.Context, riskLevel stri
 
 	query := `
 		SELECT id, transaction_id, score, risk_level, rules_triggered,
-			   features, model_version, processing_time_ms, created_at
+			   COALESCE(shadow_rules_triggered, '{}'), features, model_version, COALESCE(rule_set_version, 0), processing_time_ms, created_at,
+			   reason_codes, COALESCE(decision_policy_version, 0), COALESCE(policy_segment, ''),
+			   COALESCE(decision, '')
 		FROM risk_scores
 		WHERE risk_level = $1
 		ORDER BY created_at DESC
@@ -160,8 +200,8 @@ func (r *RiskScoreRepository) GetDailySummary(ctx context.Context, date time.Tim
 		SELECT 
 			COUNT(*) as total_transactions,
 			COALESCE(SUM(t.amount), 0) as total_amount,
-			COUNT(CASE WHEN t.status = 'flagged' THEN 1 END) as flagged_count,
-			COUNT(CASE WHEN t.status = 'blocked' THEN 1 END) as blocked_count,
+			COUNT(CASE WHEN t.status IN ('step_up_pending', 'review_pending') THEN 1 END) as flagged_count,
+			COUNT(CASE WHEN t.status = 'declined' THEN 1 END) as blocked_count,
 			COALESCE(AVG(rs.score), 0) as avg_risk_score,
 			COUNT(CASE WHEN rs.risk_level = 'high' THEN 1 END) as high_risk_count,
 			COUNT(CASE WHEN rs.risk_level = 'critical' THEN 1 END) as critical_risk_count
@@ -212,6 +252,30 @@ func (r *RiskScoreRepository) GetDailySummary(ctx context.Context, date time.Tim
 		summary.TopRulesTriggered = append(summary.TopRulesTriggered, ruleCount)
 	}
 
+	// Get decision counts
+	decisionsQuery := `
+		SELECT decision, COUNT(*) as count
+		FROM risk_scores
+		WHERE created_at >= $1 AND created_at < $2 AND decision IS NOT NULL
+		GROUP BY decision
+	`
+
+	decisionRows, err := r.db.Pool.Query(ctx, decisionsQuery, startOfDay, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+	defer decisionRows.Close()
+
+	summary.DecisionCounts = make(map[string]int)
+	for decisionRows.Next() {
+		var decision string
+		var count int
+		if err := decisionRows.Scan(&decision, &count); err != nil {
+			return nil, err
+		}
+		summary.DecisionCounts[decision] = count
+	}
+
 	return summary, nil
 }
 
@@ -223,7 +287,7 @@ func (r *RiskScoreRepository) GetAccountRiskProfile(ctx context.Context, account
 			a.risk_profile as current_risk_level,
 			COALESCE(AVG(t.amount), 0) as avg_transaction_amount,
 			COUNT(t.id) as transaction_count_30d,
-			COUNT(CASE WHEN t.status = 'flagged' THEN 1 END) as flagged_count_30d,
+			COUNT(CASE WHEN t.status IN ('step_up_pending', 'review_pending', 'declined') THEN 1 END) as flagged_count_30d,
 			MAX(t.created_at) as last_transaction_at
 		FROM accounts a
 		LEFT JOIN transactions t ON a.id = t.account_id AND t.created_at >= NOW() - INTERVAL '30 days'
@@ -265,7 +329,7 @@ func (r *RiskScoreRepository) scanRiskScores(rows pgx.Rows, total int) ([]*model
 	for rows.Next() {
 		score := &models.RiskScore{}
 		var rulesTriggered []string
-		var featuresBytes []byte
+		var featuresBytes, reasonCodesBytes []byte
 
 		if err := rows.Scan(
 			&score.ID,
@@ -273,18 +337,43 @@ func (r *RiskScoreRepository) scanRiskScores(rows pgx.Rows, total int) ([]*model
 			&score.Score,
 			&score.RiskLevel,
 			&rulesTriggered, // pgx handles []string directly
+			&score.ShadowRulesTriggered,
 			&featuresBytes,
 			&score.ModelVersion,
+			&score.RuleSetVersion,
 			&score.ProcessingTimeMs,
 			&score.CreatedAt,
+			&reasonCodesBytes,
+			&score.DecisionPolicyVersion,
+			&score.PolicySegment,
+			&score.Decision,
 		); err != nil {
 			return nil, 0, err
 		}
 
 		score.RulesTriggered = rulesTriggered
 		score.Features.Scan(featuresBytes)
+		if err := decodeReasonCodes(reasonCodesBytes, &score.ReasonCodes); err != nil {
+			return nil, 0, err
+		}
 		scores = append(scores, score)
 	}
 
 	return scores, total, nil
 }
+
+// encodeReasonCodes stores NULL when a decision has no reason codes
+func encodeReasonCodes(reasons []models.DecisionReason) ([]byte, error) {
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(reasons)
+}
+
+func decodeReasonCodes(data []byte, reasons *[]models.DecisionReason) error {
+	if len(data) == 0 {
+		*reasons = []models.DecisionReason{}
+		return nil
+	}
+	return json.Unmarshal(data, reasons)
+}
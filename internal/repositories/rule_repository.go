@@ -0,0 +1,594 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+var (
+	ErrRuleNotFound           = errors.New("rule not found")
+	ErrRuleAlreadyExists      = errors.New("rule already exists")
+	ErrRuleSetVersionNotFound = errors.New("rule set version not found")
+)
+
+// RuleSetChange describes who published a rule-set version and why
+type RuleSetChange struct {
+	CreatedBy   *uuid.UUID
+	Description string
+}
+
+// RuleRepository handles scoring rule database operations
+type RuleRepository struct {
+	db *Database
+}
+
+// NewRuleRepository creates a new rule repository
+func NewRuleRepository(db *Database) *RuleRepository {
+	return &RuleRepository{db: db}
+}
+
+// GetAll retrieves all rules, including disabled ones, ordered by priority
+func (r *RuleRepository) GetAll(ctx context.Context) ([]models.Rule, error) {
+	query := `
+		SELECT id, name, COALESCE(description, ''), condition, score_impact,
+			   risk_level, priority, enabled, mode, COALESCE(rule_group, ''),
+			   effective_from, effective_until, schedule, test_cases, created_at, updated_at
+		FROM rules
+		ORDER BY priority ASC, id ASC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRules(rows)
+}
+
+// GetByID retrieves a rule by ID
+func (r *RuleRepository) GetByID(ctx context.Context, id string) (*models.Rule, error) {
+	query := `
+		SELECT id, name, COALESCE(description, ''), condition, score_impact,
+			   risk_level, priority, enabled, mode, COALESCE(rule_group, ''),
+			   effective_from, effective_until, schedule, test_cases, created_at, updated_at
+		FROM rules
+		WHERE id = $1
+	`
+
+	rule := &models.Rule{}
+	var conditionBytes, scheduleBytes, testCasesBytes []byte
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Description,
+		&conditionBytes,
+		&rule.ScoreImpact,
+		&rule.RiskLevel,
+		&rule.Priority,
+		&rule.Enabled,
+		&rule.Mode,
+		&rule.Group,
+		&rule.EffectiveFrom,
+		&rule.EffectiveUntil,
+		&scheduleBytes,
+		&testCasesBytes,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, err
+	}
+
+	rule.Condition = conditionBytes
+	if rule.Schedule, err = decodeSchedule(scheduleBytes); err != nil {
+		return nil, err
+	}
+	if err := decodeTestCases(testCasesBytes, &rule.TestCases); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Create creates a new rule and publishes the resulting rule set as a new version
+func (r *RuleRepository) Create(ctx context.Context, rule *models.Rule, change RuleSetChange) (int, error) {
+	query := `
+		INSERT INTO rules (id, name, description, condition, score_impact, risk_level, priority, enabled, mode, rule_group,
+						   effective_from, effective_until, schedule, test_cases, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13, $14, $15, $16)
+	`
+
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+
+	scheduleBytes, err := encodeSchedule(rule.Schedule)
+	if err != nil {
+		return 0, err
+	}
+	testCasesBytes, err := encodeTestCases(rule.TestCases)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query,
+			rule.ID,
+			rule.Name,
+			rule.Description,
+			[]byte(rule.Condition),
+			rule.ScoreImpact,
+			rule.RiskLevel,
+			rule.Priority,
+			rule.Enabled,
+			rule.Mode,
+			rule.Group,
+			rule.EffectiveFrom,
+			rule.EffectiveUntil,
+			scheduleBytes,
+			testCasesBytes,
+			rule.CreatedAt,
+			rule.UpdatedAt,
+		); err != nil {
+			if isDuplicateKeyError(err) {
+				return ErrRuleAlreadyExists
+			}
+			return err
+		}
+
+		var err error
+		version, err = r.publishVersion(ctx, tx, change, nil)
+		return err
+	})
+
+	return version, err
+}
+
+// Update updates a rule's definition and publishes the resulting rule set as a new version
+func (r *RuleRepository) Update(ctx context.Context, rule *models.Rule, change RuleSetChange) (int, error) {
+	query := `
+		UPDATE rules
+		SET name = $2, description = $3, condition = $4, score_impact = $5,
+			risk_level = $6, priority = $7, enabled = $8, mode = $9,
+			rule_group = NULLIF($10, ''), effective_from = $11, effective_until = $12,
+			schedule = $13, test_cases = $14, updated_at = $15
+		WHERE id = $1
+	`
+
+	rule.UpdatedAt = time.Now()
+
+	scheduleBytes, err := encodeSchedule(rule.Schedule)
+	if err != nil {
+		return 0, err
+	}
+	testCasesBytes, err := encodeTestCases(rule.TestCases)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query,
+			rule.ID,
+			rule.Name,
+			rule.Description,
+			[]byte(rule.Condition),
+			rule.ScoreImpact,
+			rule.RiskLevel,
+			rule.Priority,
+			rule.Enabled,
+			rule.Mode,
+			rule.Group,
+			rule.EffectiveFrom,
+			rule.EffectiveUntil,
+			scheduleBytes,
+			testCasesBytes,
+			rule.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() == 0 {
+			return ErrRuleNotFound
+		}
+
+		version, err = r.publishVersion(ctx, tx, change, nil)
+		return err
+	})
+
+	return version, err
+}
+
+// SetEnabled enables or disables a rule and publishes the resulting rule set as a new version
+func (r *RuleRepository) SetEnabled(ctx context.Context, id string, enabled bool, change RuleSetChange) (int, error) {
+	query := `
+		UPDATE rules
+		SET enabled = $2, updated_at = $3
+		WHERE id = $1
+	`
+
+	var version int
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx, query, id, enabled, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() == 0 {
+			return ErrRuleNotFound
+		}
+
+		version, err = r.publishVersion(ctx, tx, change, nil)
+		return err
+	})
+
+	return version, err
+}
+
+// DisableExpired disables every enabled rule whose effective_until is at or before now and,
+// if any were disabled, publishes the resulting rule set as a new version.
+// It returns the disabled rules and the new version (0 when nothing expired).
+func (r *RuleRepository) DisableExpired(ctx context.Context, now time.Time, change RuleSetChange) ([]models.Rule, int, error) {
+	query := `
+		UPDATE rules
+		SET enabled = FALSE, updated_at = $1
+		WHERE enabled AND effective_until IS NOT NULL AND effective_until <= $1
+		RETURNING id, name, COALESCE(description, ''), condition, score_impact,
+				  risk_level, priority, enabled, mode, COALESCE(rule_group, ''),
+				  effective_from, effective_until, schedule, test_cases, created_at, updated_at
+	`
+
+	var expired []models.Rule
+	var version int
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, now)
+		if err != nil {
+			return err
+		}
+		expired, err = r.scanRules(rows)
+		rows.Close()
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+
+		version, err = r.publishVersion(ctx, tx, change, nil)
+		return err
+	})
+
+	return expired, version, err
+}
+
+// Rollback restores the rules table to an earlier version and publishes it as a new version.
+// The earlier version itself is never modified.
+func (r *RuleRepository) Rollback(ctx context.Context, version int, change RuleSetChange) (int, error) {
+	target, err := r.GetRuleSetVersion(ctx, version)
+	if err != nil {
+		return 0, err
+	}
+
+	insertQuery := `
+		INSERT INTO rules (id, name, description, condition, score_impact, risk_level, priority, enabled, mode, rule_group,
+						   effective_from, effective_until, schedule, test_cases, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13, $14, $15, $16)
+	`
+
+	var newVersion int
+	err = r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM rules`); err != nil {
+			return err
+		}
+
+		if err := setAggregation(ctx, tx, target.Aggregation); err != nil {
+			return err
+		}
+
+		for _, rule := range target.Rules {
+			scheduleBytes, err := encodeSchedule(rule.Schedule)
+			if err != nil {
+				return err
+			}
+			testCasesBytes, err := encodeTestCases(rule.TestCases)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, insertQuery,
+				rule.ID,
+				rule.Name,
+				rule.Description,
+				[]byte(rule.Condition),
+				rule.ScoreImpact,
+				rule.RiskLevel,
+				rule.Priority,
+				rule.Enabled,
+				rule.Mode,
+				rule.Group,
+				rule.EffectiveFrom,
+				rule.EffectiveUntil,
+				scheduleBytes,
+				testCasesBytes,
+				rule.CreatedAt,
+				time.Now(),
+			); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		newVersion, err = r.publishVersion(ctx, tx, change, &version)
+		return err
+	})
+
+	return newVersion, err
+}
+
+// SetAggregation changes the rule set's score aggregation strategy and publishes a new version
+func (r *RuleRepository) SetAggregation(ctx context.Context, aggregation models.ScoreAggregation, change RuleSetChange) (int, error) {
+	var version int
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := setAggregation(ctx, tx, aggregation); err != nil {
+			return err
+		}
+
+		var err error
+		version, err = r.publishVersion(ctx, tx, change, nil)
+		return err
+	})
+
+	return version, err
+}
+
+func setAggregation(ctx context.Context, tx pgx.Tx, aggregation models.ScoreAggregation) error {
+	query := `
+		INSERT INTO rule_set_settings (id, aggregation, updated_at)
+		VALUES (TRUE, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET aggregation = EXCLUDED.aggregation, updated_at = EXCLUDED.updated_at
+	`
+
+	aggregationBytes, err := json.Marshal(aggregation)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, aggregationBytes, time.Now())
+	return err
+}
+
+// publishVersion snapshots every rule and the rule-set settings into rule_set_versions
+// as the next version number
+func (r *RuleRepository) publishVersion(ctx context.Context, tx pgx.Tx, change RuleSetChange, rolledBackFrom *int) (int, error) {
+	// Serialize publishers so version numbers are gapless and each snapshot sees the committed rules
+	if _, err := tx.Exec(ctx, `LOCK TABLE rule_set_versions IN EXCLUSIVE MODE`); err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO rule_set_versions (version, rules, aggregation, description, rolled_back_from, created_by, created_at)
+		SELECT (SELECT COALESCE(MAX(version), 0) + 1 FROM rule_set_versions),
+			   COALESCE(jsonb_agg(jsonb_build_object(
+				   'id', id,
+				   'name', name,
+				   'description', COALESCE(description, ''),
+				   'condition', condition,
+				   'score_impact', score_impact,
+				   'risk_level', risk_level,
+				   'priority', priority,
+				   'enabled', enabled,
+				   'mode', mode,
+				   'group', COALESCE(rule_group, ''),
+				   'effective_from', effective_from,
+				   'effective_until', effective_until,
+				   'schedule', schedule,
+				   'test_cases', test_cases,
+				   'created_at', created_at,
+				   'updated_at', updated_at
+			   ) ORDER BY priority, id), '[]'::jsonb),
+			   COALESCE((SELECT aggregation FROM rule_set_settings WHERE id), '{"strategy": "sum_capped"}'::jsonb),
+			   $1, $2, $3, $4
+		FROM rules
+		RETURNING version
+	`
+
+	var version int
+	err := tx.QueryRow(ctx, query, change.Description, rolledBackFrom, change.CreatedBy, time.Now()).Scan(&version)
+	return version, err
+}
+
+// GetActiveRuleSet retrieves the latest published rule-set version
+func (r *RuleRepository) GetActiveRuleSet(ctx context.Context) (*models.RuleSetVersion, error) {
+	query := `
+		SELECT version, rules, aggregation, COALESCE(description, ''), rolled_back_from, created_by, created_at
+		FROM rule_set_versions
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	return r.scanRuleSetVersion(r.db.Pool.QueryRow(ctx, query))
+}
+
+// GetRuleSetVersion retrieves a specific rule-set version
+func (r *RuleRepository) GetRuleSetVersion(ctx context.Context, version int) (*models.RuleSetVersion, error) {
+	query := `
+		SELECT version, rules, aggregation, COALESCE(description, ''), rolled_back_from, created_by, created_at
+		FROM rule_set_versions
+		WHERE version = $1
+	`
+
+	return r.scanRuleSetVersion(r.db.Pool.QueryRow(ctx, query, version))
+}
+
+// ListRuleSetVersions retrieves rule-set versions, newest first, without their rules
+func (r *RuleRepository) ListRuleSetVersions(ctx context.Context, page, pageSize int) ([]*models.RuleSetVersion, int, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM rule_set_versions`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT version, jsonb_array_length(rules), aggregation, COALESCE(description, ''), rolled_back_from, created_by, created_at
+		FROM rule_set_versions
+		ORDER BY version DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var versions []*models.RuleSetVersion
+	for rows.Next() {
+		v := &models.RuleSetVersion{}
+		var aggregationBytes []byte
+		if err := rows.Scan(
+			&v.Version,
+			&v.RuleCount,
+			&aggregationBytes,
+			&v.Description,
+			&v.RolledBackFrom,
+			&v.CreatedBy,
+			&v.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(aggregationBytes, &v.Aggregation); err != nil {
+			return nil, 0, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, total, rows.Err()
+}
+
+// ruleMode maps the empty mode of snapshots published before shadow mode existed to live
+func ruleMode(mode string) string {
+	if mode == "" {
+		return models.RuleModeLive
+	}
+	return mode
+}
+
+// encodeSchedule converts a rule schedule to a JSONB value (NULL when unset)
+func encodeSchedule(schedule *models.RuleSchedule) ([]byte, error) {
+	if schedule == nil {
+		return nil, nil
+	}
+	return json.Marshal(schedule)
+}
+
+func decodeSchedule(data []byte) (*models.RuleSchedule, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var schedule *models.RuleSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// encodeTestCases converts rule test cases to a JSONB value (NULL when there are none)
+func encodeTestCases(cases []models.RuleTestCase) ([]byte, error) {
+	if len(cases) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(cases)
+}
+
+func decodeTestCases(data []byte, cases *[]models.RuleTestCase) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, cases)
+}
+
+func (r *RuleRepository) scanRuleSetVersion(row pgx.Row) (*models.RuleSetVersion, error) {
+	v := &models.RuleSetVersion{}
+	var rulesBytes, aggregationBytes []byte
+
+	err := row.Scan(
+		&v.Version,
+		&rulesBytes,
+		&aggregationBytes,
+		&v.Description,
+		&v.RolledBackFrom,
+		&v.CreatedBy,
+		&v.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRuleSetVersionNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rulesBytes, &v.Rules); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(aggregationBytes, &v.Aggregation); err != nil {
+		return nil, err
+	}
+	for i := range v.Rules {
+		v.Rules[i].Mode = ruleMode(v.Rules[i].Mode)
+	}
+	v.RuleCount = len(v.Rules)
+	return v, nil
+}
+
+func (r *RuleRepository) scanRules(rows pgx.Rows) ([]models.Rule, error) {
+	var rules []models.Rule
+	for rows.Next() {
+		var rule models.Rule
+		var conditionBytes, scheduleBytes, testCasesBytes []byte
+
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.Name,
+			&rule.Description,
+			&conditionBytes,
+			&rule.ScoreImpact,
+			&rule.RiskLevel,
+			&rule.Priority,
+			&rule.Enabled,
+			&rule.Mode,
+			&rule.Group,
+			&rule.EffectiveFrom,
+			&rule.EffectiveUntil,
+			&scheduleBytes,
+			&testCasesBytes,
+			&rule.CreatedAt,
+			&rule.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		rule.Condition = conditionBytes
+		schedule, err := decodeSchedule(scheduleBytes)
+		if err != nil {
+			return nil, err
+		}
+		rule.Schedule = schedule
+		if err := decodeTestCases(testCasesBytes, &rule.TestCases); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
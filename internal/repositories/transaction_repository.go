@@ -13,8 +13,9 @@ import (
 )
 
 var (
-	ErrTransactionNotFound    = errors.New("transaction not found")
-	ErrDuplicateTransaction   = errors.New("duplicate transaction (idempotency key exists)")
+	ErrTransactionNotFound      = errors.New("transaction not found")
+	ErrDuplicateTransaction     = errors.New("duplicate transaction (idempotency key exists)")
+	ErrTransactionStatusChanged = errors.New("transaction status changed concurrently")
 )
 
 // TransactionRepository handles transaction database operations
@@ -32,12 +33,14 @@ func (r *TransactionRepository) Create(ctx context.Context, tx *models.Transacti
 	query := `
 		INSERT INTO transactions (
 			id, account_id, amount, currency, merchant, merchant_category,
-			location, country, channel, status, idempotency_key, metadata, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			location, country, channel, status, idempotency_key, metadata, created_at,
+			device_id, device_fingerprint, user_agent, ip_address, counterparty_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+			NULLIF($14, ''), NULLIF($15, ''), NULLIF($16, ''), NULLIF($17::text, '')::inet, NULLIF($18, ''))
 	`
 
 	tx.ID = uuid.New()
-	tx.CreatedAt = time.Now()
+	tx.CreatedAt = time.Now().Truncate(time.Microsecond) // as Postgres stores it
 	tx.Status = models.TransactionStatusPending
 
 	metadataBytes, _ := tx.Metadata.Value()
@@ -56,6 +59,11 @@ func (r *TransactionRepository) Create(ctx context.Context, tx *models.Transacti
 		tx.IdempotencyKey,
 		metadataBytes,
 		tx.CreatedAt,
+		tx.DeviceID,
+		tx.DeviceFingerprint,
+		tx.UserAgent,
+		tx.IPAddress,
+		tx.CounterpartyID,
 	)
 
 	if err != nil {
@@ -68,24 +76,27 @@ func (r *TransactionRepository) Create(ctx context.Context, tx *models.Transacti
 	return nil
 }
 
-// CreateBatch creates multiple transactions in a batch
-func (r *TransactionRepository) CreateBatch(ctx context.Context, transactions []*models.Transaction) error {
+// CreateBatch creates multiple transactions in a batch and returns those inserted.
+// Transactions whose idempotency key already exists are skipped.
+func (r *TransactionRepository) CreateBatch(ctx context.Context, transactions []*models.Transaction) ([]*models.Transaction, error) {
 	if len(transactions) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	batch := &pgx.Batch{}
 	query := `
 		INSERT INTO transactions (
 			id, account_id, amount, currency, merchant, merchant_category,
-			location, country, channel, status, idempotency_key, metadata, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			location, country, channel, status, idempotency_key, metadata, created_at,
+			device_id, device_fingerprint, user_agent, ip_address, counterparty_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+			NULLIF($14, ''), NULLIF($15, ''), NULLIF($16, ''), NULLIF($17::text, '')::inet, NULLIF($18, ''))
 		ON CONFLICT (idempotency_key) DO NOTHING
 	`
 
 	for _, tx := range transactions {
 		tx.ID = uuid.New()
-		tx.CreatedAt = time.Now()
+		tx.CreatedAt = time.Now().Truncate(time.Microsecond) // as Postgres stores it
 		tx.Status = models.TransactionStatusPending
 		metadataBytes, _ := tx.Metadata.Value()
 
@@ -103,19 +114,29 @@ func (r *TransactionRepository) CreateBatch(ctx context.Context, transactions []
 			tx.IdempotencyKey,
 			metadataBytes,
 			tx.CreatedAt,
+			tx.DeviceID,
+			tx.DeviceFingerprint,
+			tx.UserAgent,
+			tx.IPAddress,
+			tx.CounterpartyID,
 		)
 	}
 
 	br := r.db.Pool.SendBatch(ctx, batch)
 	defer br.Close()
 
-	for range transactions {
-		if _, err := br.Exec(); err != nil {
-			return err
+	inserted := make([]*models.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		tag, err := br.Exec()
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() > 0 {
+			inserted = append(inserted, tx)
 		}
 	}
 
-	return nil
+	return inserted, nil
 }
 
 // GetByID retrieves a transaction by ID
@@ -123,7 +144,8 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 	query := `
 		SELECT id, account_id, amount, currency, merchant, merchant_category,
 			   location, country, channel, status, idempotency_key, metadata,
-			   created_at, processed_at
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
 		FROM transactions
 		WHERE id = $1
 	`
@@ -146,6 +168,11 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 		&metadataBytes,
 		&tx.CreatedAt,
 		&tx.ProcessedAt,
+		&tx.DeviceID,
+		&tx.DeviceFingerprint,
+		&tx.UserAgent,
+		&tx.IPAddress,
+		&tx.CounterpartyID,
 	)
 
 	if err != nil {
@@ -164,7 +191,8 @@ func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key str
 	query := `
 		SELECT id, account_id, amount, currency, merchant, merchant_category,
 			   location, country, channel, status, idempotency_key, metadata,
-			   created_at, processed_at
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
 		FROM transactions
 		WHERE idempotency_key = $1
 	`
@@ -187,6 +215,11 @@ func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key str
 		&metadataBytes,
 		&tx.CreatedAt,
 		&tx.ProcessedAt,
+		&tx.DeviceID,
+		&tx.DeviceFingerprint,
+		&tx.UserAgent,
+		&tx.IPAddress,
+		&tx.CounterpartyID,
 	)
 
 	if err != nil {
@@ -200,23 +233,55 @@ func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key str
 	return tx, nil
 }
 
-// UpdateStatus updates a transaction's status
-func (r *TransactionRepository) UpdateStatus(ctx context.Context, id uuid.UUID, createdAt time.Time, status string) error {
+// UpdatePendingStatus sets the status a pending transaction is scored to. It fails with
+// ErrTransactionStatusChanged if the transaction has already been decided, so rescoring
+// never overwrites a decision or its resolution.
+func (r *TransactionRepository) UpdatePendingStatus(ctx context.Context, id uuid.UUID, createdAt time.Time, status string) error {
 	query := `
-		UPDATE transactions
-		SET status = $3, processed_at = $4
-		WHERE id = $1 AND created_at = $2
+		WITH updated AS (
+			UPDATE transactions
+			SET status = $3, processed_at = $4
+			WHERE id = $1 AND created_at = $2 AND status = $5
+			RETURNING id
+		)
+		SELECT EXISTS (SELECT 1 FROM updated),
+			   EXISTS (SELECT 1 FROM transactions WHERE id = $1 AND created_at = $2)
 	`
 
 	processedAt := time.Now()
-	result, err := r.db.Pool.Exec(ctx, query, id, createdAt, status, processedAt)
+	var updated, found bool
+	err := r.db.Pool.QueryRow(ctx, query, id, createdAt, status, processedAt, models.TransactionStatusPending).Scan(&updated, &found)
 	if err != nil {
 		return err
 	}
 
-	if result.RowsAffected() == 0 {
+	if !found {
 		return ErrTransactionNotFound
 	}
+	if !updated {
+		return ErrTransactionStatusChanged
+	}
+
+	return nil
+}
+
+// TransitionStatus moves a transaction from one status to another. It fails with
+// ErrTransactionStatusChanged if the transaction is no longer in the from status.
+func (r *TransactionRepository) TransitionStatus(ctx context.Context, id uuid.UUID, createdAt time.Time, from, to string) error {
+	query := `
+		UPDATE transactions
+		SET status = $4
+		WHERE id = $1 AND created_at = $2 AND status = $3
+	`
+
+	result, err := r.db.Pool.Exec(ctx, query, id, createdAt, from, to)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrTransactionStatusChanged
+	}
 
 	return nil
 }
@@ -239,7 +304,8 @@ func (r *TransactionRepository) GetByAccountID(ctx context.Context, accountID uu
 	query := `
 		SELECT id, account_id, amount, currency, merchant, merchant_category,
 			   location, country, channel, status, idempotency_key, metadata,
-			   created_at, processed_at
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
 		FROM transactions
 		WHERE account_id = $1
 		AND ($4::timestamptz IS NULL OR created_at >= $4)
@@ -257,11 +323,11 @@ func (r *TransactionRepository) GetByAccountID(ctx context.Context, accountID uu
 	return r.scanTransactions(rows, total)
 }
 
-// GetFlagged retrieves flagged/blocked transactions with pagination
+// GetFlagged retrieves transactions held for step-up or review, or declined, with pagination
 func (r *TransactionRepository) GetFlagged(ctx context.Context, page, pageSize int) ([]*models.Transaction, int, error) {
 	offset := (page - 1) * pageSize
 
-	countQuery := `SELECT COUNT(*) FROM transactions WHERE status IN ('flagged', 'blocked')`
+	countQuery := `SELECT COUNT(*) FROM transactions WHERE status IN ('step_up_pending', 'review_pending', 'declined')`
 	var total int
 	if err := r.db.Pool.QueryRow(ctx, countQuery).Scan(&total); err != nil {
 		return nil, 0, err
@@ -270,9 +336,10 @@ func (r *TransactionRepository) GetFlagged(ctx context.Context, page, pageSize i
 	query := `
 		SELECT id, account_id, amount, currency, merchant, merchant_category,
 			   location, country, channel, status, idempotency_key, metadata,
-			   created_at, processed_at
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
 		FROM transactions
-		WHERE status IN ('flagged', 'blocked')
+		WHERE status IN ('step_up_pending', 'review_pending', 'declined')
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -299,7 +366,8 @@ func (r *TransactionRepository) GetRecent(ctx context.Context, page, pageSize in
 	query := `
 		SELECT id, account_id, amount, currency, merchant, merchant_category,
 			   location, country, channel, status, idempotency_key, metadata,
-			   created_at, processed_at
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
 		FROM transactions
 		WHERE created_at >= NOW() - INTERVAL '7 days'
 		ORDER BY created_at DESC
@@ -320,7 +388,8 @@ func (r *TransactionRepository) GetRecentByAccount(ctx context.Context, accountI
 	query := `
 		SELECT id, account_id, amount, currency, merchant, merchant_category,
 			   location, country, channel, status, idempotency_key, metadata,
-			   created_at, processed_at
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
 		FROM transactions
 		WHERE account_id = $1 AND created_at >= $2
 		ORDER BY created_at DESC
@@ -336,6 +405,74 @@ func (r *TransactionRepository) GetRecentByAccount(ctx context.Context, accountI
 	return transactions, err
 }
 
+// GetByAccountInRange retrieves an account's transactions in the interval (from, to], newest first
+func (r *TransactionRepository) GetByAccountInRange(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT id, account_id, amount, currency, merchant, merchant_category,
+			   location, country, channel, status, idempotency_key, metadata,
+			   created_at, processed_at, COALESCE(device_id, ''), COALESCE(device_fingerprint, ''),
+			   COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), COALESCE(counterparty_id, '')
+		FROM transactions
+		WHERE account_id = $1 AND created_at > $2 AND created_at <= $3
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions, _, err := r.scanTransactions(rows, 0)
+	return transactions, err
+}
+
+// GetAccountHistory retrieves an account's transactions in [from, before), oldest first,
+// with the decision and time of each one's first risk score, if made before before
+func (r *TransactionRepository) GetAccountHistory(ctx context.Context, accountID uuid.UUID, from, before time.Time) ([]*models.TransactionHistoryEntry, error) {
+	query := `
+		SELECT t.id, t.amount, COALESCE(t.merchant, ''), COALESCE(t.location, ''),
+			   COALESCE(t.country, ''), t.channel, COALESCE(rs.decision, ''), rs.created_at, t.created_at
+		FROM transactions t
+		LEFT JOIN LATERAL (
+			SELECT decision, created_at FROM risk_scores
+			WHERE transaction_id = t.id AND transaction_created_at = t.created_at
+			  AND created_at < $3
+			ORDER BY created_at
+			LIMIT 1
+		) rs ON true
+		WHERE t.account_id = $1 AND t.created_at >= $2 AND t.created_at < $3
+		ORDER BY t.created_at, t.id
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, accountID, from, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.TransactionHistoryEntry
+	for rows.Next() {
+		entry := &models.TransactionHistoryEntry{}
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.Amount,
+			&entry.Merchant,
+			&entry.Location,
+			&entry.Country,
+			&entry.Channel,
+			&entry.Decision,
+			&entry.DecidedAt,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
 // GetTransactionStats retrieves transaction statistics for an account
 func (r *TransactionRepository) GetTransactionStats(ctx context.Context, accountID uuid.UUID, days int) (map[string]interface{}, error) {
 	query := `
@@ -401,6 +538,11 @@ func (r *TransactionRepository) scanTransactions(rows pgx.Rows, total int) ([]*m
 			&metadataBytes,
 			&tx.CreatedAt,
 			&tx.ProcessedAt,
+			&tx.DeviceID,
+			&tx.DeviceFingerprint,
+			&tx.UserAgent,
+			&tx.IPAddress,
+			&tx.CounterpartyID,
 		); err != nil {
 			return nil, 0, err
 		}
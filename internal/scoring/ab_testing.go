@@ -27,18 +27,20 @@ type ABTestManager struct {
 
 // Experiment represents an A/B test experiment
 type Experiment struct {
-	ID           string            `json:"id"`
-	Name         string            `json:"name"`
-	Description  string            `json:"description"`
-	Status       ExperimentStatus  `json:"status"`
-	ControlRules []string          `json:"control_rules"`  // Rule IDs for control group
-	TestRules    []string          `json:"test_rules"`     // Rule IDs for test group (can be modified rules)
-	TrafficSplit float64           `json:"traffic_split"`  // 0.0-1.0, percentage going to test group
-	StartTime    time.Time         `json:"start_time"`
-	EndTime      *time.Time        `json:"end_time,omitempty"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
-	Metadata     map[string]string `json:"metadata,omitempty"`
+	ID                 string                   `json:"id"`
+	Name               string                   `json:"name"`
+	Description        string                   `json:"description"`
+	Status             ExperimentStatus         `json:"status"`
+	ControlRules       []string                 `json:"control_rules"`                 // Rule IDs for control group
+	TestRules          []string                 `json:"test_rules"`                    // Rule IDs for test group (can be modified rules)
+	ControlAggregation *models.ScoreAggregation `json:"control_aggregation,omitempty"` // nil = rule set's strategy
+	TestAggregation    *models.ScoreAggregation `json:"test_aggregation,omitempty"`    // nil = rule set's strategy
+	TrafficSplit       float64                  `json:"traffic_split"`                 // 0.0-1.0, percentage going to test group
+	StartTime          time.Time                `json:"start_time"`
+	EndTime            *time.Time               `json:"end_time,omitempty"`
+	CreatedAt          time.Time                `json:"created_at"`
+	UpdatedAt          time.Time                `json:"updated_at"`
+	Metadata           map[string]string        `json:"metadata,omitempty"`
 }
 
 // ExperimentStatus represents the status of an experiment
@@ -105,6 +107,16 @@ func (m *ABTestManager) CreateExperiment(exp *Experiment) error {
 		return fmt.Errorf("traffic_split must be between 0.0 and 1.0")
 	}
 
+	// Validate score aggregation overrides
+	for _, agg := range []*models.ScoreAggregation{exp.ControlAggregation, exp.TestAggregation} {
+		if agg == nil {
+			continue
+		}
+		if err := ValidateAggregation(agg); err != nil {
+			return err
+		}
+	}
+
 	m.experiments[exp.ID] = exp
 	m.results[exp.ID] = &ExperimentResults{
 		ExperimentID: exp.ID,
@@ -0,0 +1,89 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// ErrInvalidAggregation is returned when a score aggregation fails validation
+var ErrInvalidAggregation = errors.New("invalid score aggregation")
+
+// defaultDiminishingDecay halves the weight of each further triggered rule
+const defaultDiminishingDecay = 0.5
+
+// DefaultAggregation is today's behaviour: impacts are summed and capped at 100
+var DefaultAggregation = models.ScoreAggregation{Strategy: models.AggregationSumCapped}
+
+// ValidateAggregation checks a score aggregation and fills in defaults in place.
+// Failures wrap ErrInvalidAggregation.
+func ValidateAggregation(agg *models.ScoreAggregation) error {
+	switch agg.Strategy {
+	case "":
+		agg.Strategy = models.AggregationSumCapped
+	case models.AggregationSumCapped, models.AggregationMax, models.AggregationNoisyOr, models.AggregationDiminishing:
+	default:
+		return fmt.Errorf("%w: unknown strategy %q (want sum_capped, max, noisy_or or diminishing)",
+			ErrInvalidAggregation, agg.Strategy)
+	}
+
+	if agg.Strategy != models.AggregationDiminishing {
+		if agg.Decay != 0 {
+			return fmt.Errorf("%w: decay only applies to the diminishing strategy", ErrInvalidAggregation)
+		}
+		return nil
+	}
+
+	if agg.Decay == 0 {
+		agg.Decay = defaultDiminishingDecay
+	}
+	if agg.Decay <= 0 || agg.Decay >= 1 {
+		return fmt.Errorf("%w: decay must be between 0 and 1 (exclusive)", ErrInvalidAggregation)
+	}
+	return nil
+}
+
+// combineScores combines the score impacts (0-100) of the triggered live rules into a 0-100 score.
+//
+//	sum_capped:  min(100, Σ impact)
+//	max:         the strongest single impact
+//	noisy_or:    100 · (1 - Π(1 - impact/100)), treating impacts as independent probabilities
+//	diminishing: min(100, Σ impact_i · decay^i) with impacts sorted strongest first
+func combineScores(impacts []float64, agg models.ScoreAggregation) float64 {
+	var score float64
+
+	switch agg.Strategy {
+	case models.AggregationMax:
+		for _, impact := range impacts {
+			if impact > score {
+				score = impact
+			}
+		}
+	case models.AggregationNoisyOr:
+		miss := 1.0
+		for _, impact := range impacts {
+			miss *= 1 - impact/100
+		}
+		score = 100 * (1 - miss)
+	case models.AggregationDiminishing:
+		sorted := append([]float64(nil), impacts...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		weight := 1.0
+		for _, impact := range sorted {
+			score += impact * weight
+			weight *= agg.Decay
+		}
+	default:
+		for _, impact := range impacts {
+			score += impact
+		}
+	}
+
+	// Cap at 100
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
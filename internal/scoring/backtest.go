@@ -3,6 +3,8 @@ package scoring
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -37,15 +39,16 @@ type BacktestRequest struct {
 
 // BacktestResult represents the result of backtesting
 type BacktestResult struct {
-	TotalTransactions   int                    `json:"total_transactions"`
-	ProcessedCount      int                    `json:"processed_count"`
-	FailedCount         int                    `json:"failed_count"`
-	AverageScore        float64                `json:"average_score"`
-	RiskDistribution    map[string]int         `json:"risk_distribution"`
-	TopTriggeredRules   []models.RuleCount     `json:"top_triggered_rules"`
-	ProcessingTimeMs    int64                  `json:"processing_time_ms"`
-	TransactionResults  []TransactionBacktest  `json:"transaction_results,omitempty"`
-	ComparisonWithLive  *BacktestComparison    `json:"comparison_with_live,omitempty"`
+	TotalTransactions    int                   `json:"total_transactions"`
+	ProcessedCount       int                   `json:"processed_count"`
+	FailedCount          int                   `json:"failed_count"`
+	AverageScore         float64               `json:"average_score"`
+	RiskDistribution     map[string]int        `json:"risk_distribution"`
+	DecisionDistribution map[string]int        `json:"decision_distribution"`
+	TopTriggeredRules    []models.RuleCount    `json:"top_triggered_rules"`
+	ProcessingTimeMs     int64                 `json:"processing_time_ms"`
+	TransactionResults   []TransactionBacktest `json:"transaction_results,omitempty"`
+	ComparisonWithLive   *BacktestComparison   `json:"comparison_with_live,omitempty"`
 }
 
 // TransactionBacktest represents a single transaction backtest result
@@ -57,6 +60,7 @@ type TransactionBacktest struct {
 	BacktestLevel   string    `json:"backtest_level"`
 	RulesTriggered  []string  `json:"rules_triggered"`
 	ScoreDiff       float64   `json:"score_diff"`
+	FeatureDiffs    []string  `json:"feature_diffs,omitempty"` // features that differ from what live scoring saw
 }
 
 // BacktestComparison compares backtest results with live scoring
@@ -64,8 +68,10 @@ type BacktestComparison struct {
 	MatchingScores      int     `json:"matching_scores"`
 	DifferentScores     int     `json:"different_scores"`
 	AvgScoreDifference  float64 `json:"avg_score_difference"`
-	UpgradedRisk        int     `json:"upgraded_risk"`   // Backtest scored higher
-	DowngradedRisk      int     `json:"downgraded_risk"` // Backtest scored lower
+	UpgradedRisk        int     `json:"upgraded_risk"`      // Backtest scored higher
+	DowngradedRisk      int     `json:"downgraded_risk"`    // Backtest scored lower
+	MatchingFeatures    int     `json:"matching_features"`  // Recomputed features identical to live
+	DifferentFeatures   int     `json:"different_features"` // Recomputed features differ from live
 }
 
 // RunBacktest runs a backtest on historical transactions
@@ -79,9 +85,10 @@ func (s *BacktestService) RunBacktest(ctx context.Context, req *BacktestRequest)
 		Msg("Starting backtest")
 
 	result := &BacktestResult{
-		RiskDistribution:   make(map[string]int),
-		TopTriggeredRules:  make([]models.RuleCount, 0),
-		TransactionResults: make([]TransactionBacktest, 0),
+		RiskDistribution:     make(map[string]int),
+		DecisionDistribution: make(map[string]int),
+		TopTriggeredRules:    make([]models.RuleCount, 0),
+		TransactionResults:   make([]TransactionBacktest, 0),
 	}
 
 	// Get historical transactions
@@ -135,6 +142,7 @@ func (s *BacktestService) RunBacktest(ctx context.Context, req *BacktestRequest)
 		result.ProcessedCount++
 		totalScore += score.Score
 		result.RiskDistribution[score.RiskLevel]++
+		result.DecisionDistribution[score.Decision]++
 
 		// Track triggered rules
 		for _, ruleID := range score.RulesTriggered {
@@ -155,6 +163,7 @@ func (s *BacktestService) RunBacktest(ctx context.Context, req *BacktestRequest)
 			txResult.OriginalScore = originalScore.Score
 			txResult.OriginalLevel = originalScore.RiskLevel
 			txResult.ScoreDiff = score.Score - originalScore.Score
+			txResult.FeatureDiffs = featureDiffs(originalScore.Features, score.Features)
 			scoreDiffs = append(scoreDiffs, txResult.ScoreDiff)
 		}
 
@@ -221,26 +230,32 @@ func (e *ScoringEngine) ScoreTransactionDryRun(ctx context.Context, event *model
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	// Compute features
+	// Compute features as of the transaction's own time, as live scoring did
 	features, err := e.computeFeatures(ctx, accountID, tx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute features: %w", err)
 	}
+	e.mlScorer.ComputeEnhancedFeatures(ctx, accountID, tx, features)
 
 	// Apply rules and compute score
-	score, triggeredRules := e.applyRules(features, tx)
+	ruleResult := e.applyRules(features, tx)
 
-	// Determine risk level
-	riskLevel := e.determineRiskLevel(score)
+	// Determine risk level and decision from the decision policy
+	decision := e.decide(ctx, accountID, ruleResult.Score, tx)
 
 	// Return without persisting
 	return &models.RiskScore{
-		TransactionID:  tx.ID,
-		Score:          score,
-		RiskLevel:      riskLevel,
-		RulesTriggered: triggeredRules,
-		Features:       e.featuresToJSONB(features),
-		ModelVersion:   e.modelVersion + "-backtest",
+		TransactionID:         tx.ID,
+		Score:                 ruleResult.Score,
+		RiskLevel:             decision.RiskLevel,
+		Decision:              decision.Decision,
+		RulesTriggered:        ruleResult.Triggered,
+		ShadowRulesTriggered:  ruleResult.ShadowTriggered,
+		Features:              e.featuresToJSONB(features),
+		ModelVersion:          e.modelVersion + "-backtest",
+		RuleSetVersion:        ruleResult.RuleSetVersion,
+		DecisionPolicyVersion: decision.PolicyVersion,
+		PolicySegment:         decision.Segment,
 	}, nil
 }
 
@@ -271,6 +286,14 @@ func (s *BacktestService) buildComparison(results []TransactionBacktest, scoreDi
 			}
 		}
 		totalDiff += absFloat(result.ScoreDiff)
+
+		if result.OriginalLevel != "" {
+			if len(result.FeatureDiffs) == 0 {
+				comparison.MatchingFeatures++
+			} else {
+				comparison.DifferentFeatures++
+			}
+		}
 	}
 
 	if len(results) > 0 {
@@ -280,6 +303,32 @@ func (s *BacktestService) buildComparison(results []TransactionBacktest, scoreDi
 	return comparison
 }
 
+// unreplayableFeatures are computed from state that is not kept as of each transaction,
+// so a backtest sees today's values rather than those live scoring saw. Peer-group
+// baselines are replaced by each rebuild, and whether an account is flagged is its
+// current risk profile and status.
+var unreplayableFeatures = map[string]bool{
+	"peer_group_avg_spend":      true,
+	"peer_group_deviation":      true,
+	"flagged_beneficiary_count": true,
+}
+
+// featureDiffs returns the recomputed features whose values differ from the live score's,
+// leaving out unreplayableFeatures
+func featureDiffs(live, backtest models.JSONB) []string {
+	var diffs []string
+	for name, value := range backtest {
+		if unreplayableFeatures[name] {
+			continue
+		}
+		if !reflect.DeepEqual(live[name], value) {
+			diffs = append(diffs, name)
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
 func sortRuleCounts(rules []models.RuleCount) {
 	// Simple bubble sort for small arrays
 	for i := 0; i < len(rules)-1; i++ {
@@ -0,0 +1,51 @@
+package scoring
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// counterpartyWindow is the window of the counterparty fan-in and fan-out features
+const counterpartyWindow = 24 * time.Hour
+
+// computeCounterpartyFeatures sets the fan-in and fan-out features of a transfer from
+// transactions and counterparty-graph edges strictly before it. Both counts include the
+// transfer itself, so a first transfer to a new counterparty counts 1. Which accounts
+// are flagged is read as of now, not as of the transfer. Transactions without a
+// counterparty leave the features unset.
+func (e *ScoringEngine) computeCounterpartyFeatures(ctx context.Context, tx *models.Transaction, features *models.RiskFeatures) {
+	if e.counterpartyRepo == nil || tx.CounterpartyID == "" {
+		return
+	}
+
+	stats, err := e.counterpartyRepo.GetStats(ctx, tx, counterpartyWindow)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Msg("Failed to fetch counterparty graph features")
+		return
+	}
+
+	features.SharedBeneficiaryCount = stats.OtherSenders + 1
+	features.CounterpartyCount24h = stats.OtherCounterparties + 1
+	features.FlaggedBeneficiaryCount = stats.FlaggedShared
+}
+
+// recordCounterparty adds a scored transfer's edge to the counterparty graph
+func (e *ScoringEngine) recordCounterparty(ctx context.Context, tx *models.Transaction) {
+	if e.counterpartyRepo == nil || tx.CounterpartyID == "" {
+		return
+	}
+
+	if err := e.counterpartyRepo.Record(ctx, tx); err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Msg("Failed to update counterparty graph")
+	}
+}
@@ -0,0 +1,382 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// ErrInvalidDecisionPolicy is returned when a decision policy fails validation
+var ErrInvalidDecisionPolicy = errors.New("invalid decision policy")
+
+// DefaultRiskThresholds are the built-in 25/50/70 risk-level boundaries
+var DefaultRiskThresholds = models.RiskThresholds{Medium: 25, High: 50, Critical: 70}
+
+// defaultPolicyActions declines critical and holds high risk for review for every account
+var defaultPolicyActions = map[string]string{
+	models.RiskLevelLow:      models.DecisionApprove,
+	models.RiskLevelMedium:   models.DecisionApprove,
+	models.RiskLevelHigh:     models.DecisionReview,
+	models.RiskLevelCritical: models.DecisionDecline,
+}
+
+// DefaultFallbackDecision approves under monitoring when inline authorization runs out
+// of time; full scoring still follows on the async path
+const DefaultFallbackDecision = models.DecisionApproveWithMonitoring
+
+// legacyPolicyActions maps the transaction statuses policies used as actions before
+// decisions existed, so earlier policy versions stay loadable and can be rolled back to
+var legacyPolicyActions = map[string]string{
+	"processed": models.DecisionApprove,
+	"flagged":   models.DecisionReview,
+	"blocked":   models.DecisionDecline,
+}
+
+// accountTypes mirrors the accounts.account_type CHECK constraint
+var accountTypes = map[string]bool{"standard": true, "premium": true, "business": true}
+
+// DecisionPolicySource fetches the active decision policy
+type DecisionPolicySource interface {
+	GetActiveDecisionPolicy(ctx context.Context) (*models.DecisionPolicy, error)
+}
+
+// PolicyDecision is the outcome of applying the decision policy to a score
+type PolicyDecision struct {
+	RiskLevel     string
+	Decision      string // approve, approve_with_monitoring, step_up, review or decline
+	Status        string // transaction status the decision starts in
+	PolicyVersion int    // 0 = built-in default policy
+	Segment       string // matching segment, "" for the policy's own thresholds and actions
+}
+
+// PolicyEngine resolves risk levels and transaction statuses from the active decision
+// policy. It starts with the built-in default policy until LoadFromDB succeeds.
+type PolicyEngine struct {
+	mu           sync.RWMutex
+	policy       models.DecisionPolicy
+	reloadPeriod time.Duration
+}
+
+// NewPolicyEngine creates a new policy engine
+func NewPolicyEngine(reloadPeriod time.Duration) *PolicyEngine {
+	return &PolicyEngine{
+		policy:       DefaultDecisionPolicy(),
+		reloadPeriod: reloadPeriod,
+	}
+}
+
+// DefaultDecisionPolicy returns the built-in policy (version 0)
+func DefaultDecisionPolicy() models.DecisionPolicy {
+	actions := make(map[string]string, len(defaultPolicyActions))
+	for level, action := range defaultPolicyActions {
+		actions[level] = action
+	}
+	return models.DecisionPolicy{
+		Thresholds:  DefaultRiskThresholds,
+		Actions:     actions,
+		Fallback:    DefaultFallbackDecision,
+		Segments:    []models.PolicySegment{},
+		Description: "Built-in default policy",
+	}
+}
+
+// LoadFromDB installs the latest published decision policy. An invalid policy is
+// rejected and the previously installed one stays in place.
+func (pe *PolicyEngine) LoadFromDB(ctx context.Context, source DecisionPolicySource) error {
+	policy, err := source.GetActiveDecisionPolicy(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch decision policy: %w", err)
+	}
+
+	pe.mu.RLock()
+	unchanged := pe.policy.Version == policy.Version
+	pe.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := ValidateDecisionPolicy(policy); err != nil {
+		return fmt.Errorf("decision policy version %d: %w", policy.Version, err)
+	}
+
+	pe.mu.Lock()
+	pe.policy = *policy
+	pe.mu.Unlock()
+
+	log.Info().
+		Int("decision_policy_version", policy.Version).
+		Int("segment_count", len(policy.Segments)).
+		Msg("Decision policy loaded from database")
+	return nil
+}
+
+// StartReloader periodically reloads the decision policy until ctx is cancelled.
+// A failed reload keeps the previously loaded policy in place.
+func (pe *PolicyEngine) StartReloader(ctx context.Context, source DecisionPolicySource) {
+	if pe.reloadPeriod <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(pe.reloadPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := pe.LoadFromDB(ctx, source); err != nil {
+					log.Error().Err(err).Msg("Failed to reload decision policy")
+				}
+			}
+		}
+	}()
+}
+
+// Version returns the decision policy version currently installed (0 = built-in default)
+func (pe *PolicyEngine) Version() int {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+	return pe.policy.Version
+}
+
+// Decide maps a final score to a risk level and decision using the first policy
+// segment matching the account type and transaction
+func (pe *PolicyEngine) Decide(score float64, accountType string, tx *models.Transaction) PolicyDecision {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+
+	return decide(pe.policy, score, accountType, tx)
+}
+
+// Fallback returns the policy's fallback decision for the account type and transaction,
+// used when the decision cannot be reached in time. The risk level is left empty.
+func (pe *PolicyEngine) Fallback(accountType string, tx *models.Transaction) PolicyDecision {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+
+	decision := PolicyDecision{PolicyVersion: pe.policy.Version, Decision: pe.policy.Fallback}
+	if segment := matchSegment(pe.policy, accountType, tx); segment != nil {
+		decision.Segment = segment.Name
+		if segment.Fallback != "" {
+			decision.Decision = segment.Fallback
+		}
+	}
+	decision.Status = models.DecisionStatuses[decision.Decision]
+	return decision
+}
+
+func decide(policy models.DecisionPolicy, score float64, accountType string, tx *models.Transaction) PolicyDecision {
+	decision := PolicyDecision{PolicyVersion: policy.Version}
+	thresholds := policy.Thresholds
+	var overrides map[string]string
+
+	if segment := matchSegment(policy, accountType, tx); segment != nil {
+		decision.Segment = segment.Name
+		if segment.Thresholds != nil {
+			thresholds = *segment.Thresholds
+		}
+		overrides = segment.Actions
+	}
+
+	decision.RiskLevel = riskLevelFor(score, thresholds)
+	decision.Decision = policy.Actions[decision.RiskLevel]
+	if action, ok := overrides[decision.RiskLevel]; ok {
+		decision.Decision = action
+	}
+	decision.Status = models.DecisionStatuses[decision.Decision]
+	return decision
+}
+
+// matchSegment returns the first policy segment matching the transaction, or nil
+func matchSegment(policy models.DecisionPolicy, accountType string, tx *models.Transaction) *models.PolicySegment {
+	for i := range policy.Segments {
+		if segmentMatches(policy.Segments[i].Match, accountType, tx) {
+			return &policy.Segments[i]
+		}
+	}
+	return nil
+}
+
+func riskLevelFor(score float64, t models.RiskThresholds) string {
+	switch {
+	case score >= t.Critical:
+		return models.RiskLevelCritical
+	case score >= t.High:
+		return models.RiskLevelHigh
+	case score >= t.Medium:
+		return models.RiskLevelMedium
+	default:
+		return models.RiskLevelLow
+	}
+}
+
+// segmentMatches reports whether a transaction falls in a policy segment
+func segmentMatches(m models.SegmentMatch, accountType string, tx *models.Transaction) bool {
+	if m.MinAmount != nil && tx.Amount < *m.MinAmount {
+		return false
+	}
+	if m.MaxAmount != nil && tx.Amount >= *m.MaxAmount {
+		return false
+	}
+	return matchesAny(m.AccountTypes, accountType) &&
+		matchesAny(m.Channels, tx.Channel) &&
+		matchesAny(m.Countries, tx.Country)
+}
+
+// matchesAny reports whether value is in values; an empty list matches everything
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDecisionPolicy checks a decision policy and normalizes it in place: actions
+// missing from the policy take the built-in default, legacy status actions are
+// replaced by their decision, list values are normalized to the case they are
+// stored in, and segments fill in missing names.
+// Failures wrap ErrInvalidDecisionPolicy.
+func ValidateDecisionPolicy(policy *models.DecisionPolicy) error {
+	if err := validateThresholds(policy.Thresholds, "thresholds"); err != nil {
+		return err
+	}
+
+	actions, err := normalizeActions(policy.Actions, "actions")
+	if err != nil {
+		return err
+	}
+	for level, action := range defaultPolicyActions {
+		if _, ok := actions[level]; !ok {
+			actions[level] = action
+		}
+	}
+	policy.Actions = actions
+
+	if policy.Fallback == "" {
+		policy.Fallback = DefaultFallbackDecision
+	}
+	if policy.Fallback, err = normalizeDecision(policy.Fallback, "fallback"); err != nil {
+		return err
+	}
+
+	if policy.Segments == nil {
+		policy.Segments = []models.PolicySegment{}
+	}
+	names := make(map[string]bool, len(policy.Segments))
+	for i := range policy.Segments {
+		segment := &policy.Segments[i]
+		field := fmt.Sprintf("segments[%d]", i)
+
+		segment.Name = strings.TrimSpace(segment.Name)
+		if segment.Name == "" {
+			segment.Name = fmt.Sprintf("segment-%d", i+1)
+		}
+		if len(segment.Name) > 100 {
+			return fmt.Errorf("%w: %s name must be at most 100 characters", ErrInvalidDecisionPolicy, field)
+		}
+		if names[segment.Name] {
+			return fmt.Errorf("%w: duplicate segment name %q", ErrInvalidDecisionPolicy, segment.Name)
+		}
+		names[segment.Name] = true
+
+		if err := normalizeSegmentMatch(&segment.Match, field+".match"); err != nil {
+			return err
+		}
+		if segment.Thresholds != nil {
+			if err := validateThresholds(*segment.Thresholds, field+".thresholds"); err != nil {
+				return err
+			}
+		}
+		if segment.Actions, err = normalizeActions(segment.Actions, field+".actions"); err != nil {
+			return err
+		}
+		if segment.Fallback != "" {
+			if segment.Fallback, err = normalizeDecision(segment.Fallback, field+".fallback"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateThresholds(t models.RiskThresholds, field string) error {
+	if !(t.Medium > 0 && t.Medium < t.High && t.High < t.Critical && t.Critical <= 100) {
+		return fmt.Errorf("%w: %s must satisfy 0 < medium < high < critical <= 100", ErrInvalidDecisionPolicy, field)
+	}
+	return nil
+}
+
+func normalizeActions(actions map[string]string, field string) (map[string]string, error) {
+	normalized := make(map[string]string, len(actions))
+	for level, action := range actions {
+		level, action = strings.ToLower(level), strings.ToLower(action)
+		if _, ok := defaultPolicyActions[level]; !ok {
+			return nil, fmt.Errorf("%w: %s has unknown risk level %q (want low, medium, high or critical)", ErrInvalidDecisionPolicy, field, level)
+		}
+		decision, err := normalizeDecision(action, field+"."+level)
+		if err != nil {
+			return nil, err
+		}
+		normalized[level] = decision
+	}
+	return normalized, nil
+}
+
+// normalizeDecision lower-cases a decision and replaces a legacy status action by its decision
+func normalizeDecision(action, field string) (string, error) {
+	action = strings.ToLower(action)
+	if decision, ok := legacyPolicyActions[action]; ok {
+		action = decision
+	}
+	if _, ok := models.DecisionStatuses[action]; !ok {
+		return "", fmt.Errorf("%w: %s has unknown action %q (want approve, approve_with_monitoring, step_up, review or decline)", ErrInvalidDecisionPolicy, field, action)
+	}
+	return action, nil
+}
+
+// normalizeSegmentMatch validates a segment match: account types and channels are
+// lower-cased and countries upper-cased
+func normalizeSegmentMatch(m *models.SegmentMatch, field string) error {
+	for i, accountType := range m.AccountTypes {
+		m.AccountTypes[i] = strings.ToLower(accountType)
+		if !accountTypes[m.AccountTypes[i]] {
+			return fmt.Errorf("%w: %s has unknown account type %q (want standard, premium or business)", ErrInvalidDecisionPolicy, field, accountType)
+		}
+	}
+	for i, channel := range m.Channels {
+		m.Channels[i] = strings.ToLower(channel)
+		switch m.Channels[i] {
+		case models.ChannelOnline, models.ChannelPOS, models.ChannelATM:
+		default:
+			return fmt.Errorf("%w: %s has unknown channel %q (want online, pos or atm)", ErrInvalidDecisionPolicy, field, channel)
+		}
+	}
+	for i, country := range m.Countries {
+		m.Countries[i] = strings.ToUpper(country)
+		if len(m.Countries[i]) != 2 {
+			return fmt.Errorf("%w: %s country %q must be a 2-letter code", ErrInvalidDecisionPolicy, field, country)
+		}
+	}
+	if m.MinAmount != nil && *m.MinAmount < 0 {
+		return fmt.Errorf("%w: %s min_amount must not be negative", ErrInvalidDecisionPolicy, field)
+	}
+	if m.MinAmount != nil && m.MaxAmount != nil && *m.MaxAmount <= *m.MinAmount {
+		return fmt.Errorf("%w: %s max_amount must be greater than min_amount", ErrInvalidDecisionPolicy, field)
+	}
+	return nil
+}
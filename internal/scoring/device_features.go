@@ -0,0 +1,41 @@
+package scoring
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// computeDeviceFeatures sets the device features of a transaction from its account's
+// device history strictly before the transaction. An account's first device is not
+// new: there is no known device it replaces. Without device history (no repository, or
+// a transaction without device attributes) the features stay unset.
+func (e *ScoringEngine) computeDeviceFeatures(ctx context.Context, tx *models.Transaction, features *models.RiskFeatures) {
+	if e.deviceRepo == nil {
+		return
+	}
+
+	deviceKey := tx.DeviceKey()
+	history, err := e.deviceRepo.GetHistory(ctx, tx.AccountID, deviceKey, tx.CreatedAt)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Msg("Failed to fetch device history")
+		return
+	}
+
+	features.AccountDeviceCount = history.KnownDevices
+	if deviceKey == "" {
+		return
+	}
+
+	if history.FirstSeenAt == nil {
+		features.IsNewDevice = history.KnownDevices > 0
+		features.AccountDeviceCount++
+		return
+	}
+	features.DeviceAgeDays = tx.CreatedAt.Sub(*history.FirstSeenAt).Hours() / 24
+}
@@ -3,6 +3,7 @@ package scoring
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"time"
@@ -15,22 +16,26 @@ import (
 	"github.com/enterprise/risk-engine/internal/repositories"
 )
 
-// High-risk countries list (example)
-var highRiskCountries = map[string]bool{
-	"NK": true, "IR": true, "SY": true, "CU": true,
-	"VE": true, "MM": true, "BY": true, "ZW": true,
-}
-
 // ScoringEngine computes risk scores for transactions
 type ScoringEngine struct {
 	txRepo        *repositories.TransactionRepository
 	accountRepo   *repositories.AccountRepository
 	riskScoreRepo *repositories.RiskScoreRepository
 	cacheClient   *queue.CacheClient
-	rules         []Rule
+	ruleEngine    *RuleEngine
 	modelVersion  string
 	abTestManager *ABTestManager
 	mlScorer      *MLScorer
+	reasonCodes   *ReasonCodeCatalog
+	policyEngine  *PolicyEngine
+	featureStore  *FeatureStore
+	gazetteer     *Gazetteer
+	deviceRepo    *repositories.DeviceRepository
+
+	counterpartyRepo   *repositories.CounterpartyRepository
+	merchantReputation *MerchantReputation
+	peerGroups         *PeerGroups
+	probeThresholds    ProbeThresholds
 	
 	// Scoring weights for hybrid model
 	ruleWeight       float64
@@ -38,30 +43,27 @@ type ScoringEngine struct {
 	behavioralWeight float64
 }
 
-// Rule represents a scoring rule
-type Rule struct {
-	ID          string
-	Name        string
-	Evaluate    func(features *models.RiskFeatures, tx *models.Transaction) bool
-	ScoreImpact float64
-	RiskLevel   string
-	Priority    int
-}
-
 // NewScoringEngine creates a new scoring engine
 func NewScoringEngine(
 	txRepo *repositories.TransactionRepository,
 	accountRepo *repositories.AccountRepository,
 	riskScoreRepo *repositories.RiskScoreRepository,
 	cacheClient *queue.CacheClient,
+	ruleEngine *RuleEngine,
 ) *ScoringEngine {
 	engine := &ScoringEngine{
 		txRepo:        txRepo,
 		accountRepo:   accountRepo,
 		riskScoreRepo: riskScoreRepo,
 		cacheClient:   cacheClient,
+		ruleEngine:    ruleEngine,
 		modelVersion:  "v2.0.0-hybrid",
 		abTestManager: NewABTestManager(cacheClient),
+		reasonCodes:   NewReasonCodeCatalog(DefaultReasonCodeLimit, "en", 0),
+		policyEngine:  NewPolicyEngine(0),
+		gazetteer:     NewGazetteer(0),
+
+		probeThresholds: DefaultProbeThresholds,
 		
 		// Hybrid scoring weights (Rule + Behavioral + ML)
 		// Final Score = (ruleWeight * RuleScore) + (behavioralWeight * BehavioralScore) + (mlWeight * MLScore)
@@ -76,9 +78,6 @@ func NewScoringEngine(
 		ModelVersion: "behavioral-v1",
 	})
 
-	// Initialize built-in rules
-	engine.initializeRules()
-
 	return engine
 }
 
@@ -87,186 +86,75 @@ func (e *ScoringEngine) GetABTestManager() *ABTestManager {
 	return e.abTestManager
 }
 
-// initializeRules sets up the default scoring rules
-func (e *ScoringEngine) initializeRules() {
-	e.rules = []Rule{
-		{
-			ID:          "RULE_CRITICAL_AMOUNT",
-			Name:        "Critical Amount",
-			ScoreImpact: 40.0,
-			RiskLevel:   models.RiskLevelCritical,
-			Priority:    5,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return tx.Amount > 10000
-			},
-		},
-		{
-			ID:          "RULE_SPIKE_ANOMALY",
-			Name:        "Spike Anomaly",
-			ScoreImpact: 30.0,
-			RiskLevel:   models.RiskLevelHigh,
-			Priority:    10,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.AmountDeviation > 3.0
-			},
-		},
-		{
-			ID:          "RULE_HIGH_RISK_COUNTRY",
-			Name:        "High Risk Country",
-			ScoreImpact: 35.0,
-			RiskLevel:   models.RiskLevelHigh,
-			Priority:    15,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.IsHighRiskCountry
-			},
-		},
-		{
-			ID:          "RULE_NEW_LOCATION_HIGH_AMOUNT",
-			Name:        "New Location High Amount",
-			ScoreImpact: 25.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    20,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.IsNewLocation && tx.Amount > 1000
-			},
-		},
-		{
-			ID:          "RULE_RAPID_SMALL_TRANSACTIONS",
-			Name:        "Rapid Small Transactions",
-			ScoreImpact: 25.0,
-			RiskLevel:   models.RiskLevelHigh,
-			Priority:    25,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.TransactionVelocity1h > 5 && tx.Amount < 100
-			},
-		},
-		{
-			ID:          "RULE_VELOCITY_BURST",
-			Name:        "Velocity Burst",
-			ScoreImpact: 20.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    30,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.TransactionVelocity1h > 10
-			},
-		},
-		{
-			ID:          "RULE_LOCATION_HOPPING",
-			Name:        "Location Hopping",
-			ScoreImpact: 15.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    40,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.LocationChangeCount > 3
-			},
-		},
-		{
-			ID:          "RULE_NEW_MERCHANT_HIGH_AMOUNT",
-			Name:        "New Merchant High Amount",
-			ScoreImpact: 15.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    50,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				return features.IsNewMerchant && tx.Amount > 500
-			},
-		},
-		{
-			ID:          "RULE_NIGHT_TRANSACTION",
-			Name:        "Night Transaction",
-			ScoreImpact: 10.0,
-			RiskLevel:   models.RiskLevelLow,
-			Priority:    60,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				hour := tx.CreatedAt.Hour()
-				return hour >= 0 && hour < 5
-			},
-		},
-		// Modern fraud pattern rules
-		{
-			ID:          "RULE_SEQUENCE_EXFIL_PATTERN",
-			Name:        "Sequence Exfiltration Pattern",
-			ScoreImpact: 35.0,
-			RiskLevel:   models.RiskLevelHigh,
-			Priority:    8,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// Small probe transaction followed by large transaction within short time
-				// Pattern: test with small amount, then exfiltrate with large amount
-				return features.FollowsProbePattern && tx.Amount > 1000
-			},
-		},
-		{
-			ID:          "RULE_PEER_GROUP_ANOMALY",
-			Name:        "Peer Group Anomaly",
-			ScoreImpact: 25.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    22,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// User deviates significantly from similar accounts
-				// 3 standard deviations from peer group average
-				return features.PeerGroupDeviation > 3.0
-			},
-		},
-		{
-			ID:          "RULE_SHARED_BENEFICIARY_NETWORK",
-			Name:        "Shared Beneficiary Network",
-			ScoreImpact: 30.0,
-			RiskLevel:   models.RiskLevelHigh,
-			Priority:    12,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// Multiple accounts sending to same target (mule account pattern)
-				return features.SharedBeneficiaryCount > 3
-			},
-		},
-		{
-			ID:          "RULE_RAPID_DEVICE_SWITCH",
-			Name:        "Rapid Device Switch",
-			ScoreImpact: 25.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    18,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// New device combined with high-value transaction
-				return features.IsNewDevice && tx.Amount > 500
-			},
-		},
-		{
-			ID:          "RULE_GEO_IMPOSSIBLE_TRAVEL",
-			Name:        "Impossible Travel",
-			ScoreImpact: 40.0,
-			RiskLevel:   models.RiskLevelCritical,
-			Priority:    3,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// Transaction from location impossible to reach in time
-				// Speed > 900 km/h (faster than commercial flight)
-				if features.DistanceFromLastTx > 0 && features.TimeSinceLastTx > 0 {
-					speed := features.DistanceFromLastTx / features.TimeSinceLastTx
-					return speed > 900
-				}
-				return false
-			},
-		},
-		{
-			ID:          "RULE_RAPID_CHANNEL_SWITCH",
-			Name:        "Rapid Channel Switching",
-			ScoreImpact: 15.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    45,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// Switching between online, POS, ATM rapidly
-				return features.ChannelSwitchCount > 3
-			},
-		},
-		{
-			ID:          "RULE_BEHAVIORAL_ANOMALY",
-			Name:        "Behavioral Pattern Anomaly",
-			ScoreImpact: 20.0,
-			RiskLevel:   models.RiskLevelMedium,
-			Priority:    35,
-			Evaluate: func(features *models.RiskFeatures, tx *models.Transaction) bool {
-				// Composite behavioral anomaly score exceeds threshold
-				return features.BehavioralAnomalyScore > 50
-			},
-		},
-	}
+// GetRuleEngine returns the declarative rule engine used for scoring
+func (e *ScoringEngine) GetRuleEngine() *RuleEngine {
+	return e.ruleEngine
+}
+
+// GetReasonCodeCatalog returns the catalog used to attach reason codes to decisions
+func (e *ScoringEngine) GetReasonCodeCatalog() *ReasonCodeCatalog {
+	return e.reasonCodes
+}
+
+// SetReasonCodeCatalog replaces the built-in reason-code catalog
+func (e *ScoringEngine) SetReasonCodeCatalog(catalog *ReasonCodeCatalog) {
+	e.reasonCodes = catalog
+}
+
+// GetPolicyEngine returns the decision policy engine that maps scores to risk levels and statuses
+func (e *ScoringEngine) GetPolicyEngine() *PolicyEngine {
+	return e.policyEngine
+}
+
+// SetPolicyEngine replaces the built-in default decision policy
+func (e *ScoringEngine) SetPolicyEngine(policyEngine *PolicyEngine) {
+	e.policyEngine = policyEngine
+}
+
+// GetFeatureStore returns the online feature store, or nil when features are computed from Postgres
+func (e *ScoringEngine) GetFeatureStore() *FeatureStore {
+	return e.featureStore
+}
+
+// SetFeatureStore reads account history for rolling features from the online feature
+// store instead of Postgres, and records each scored transaction in it
+func (e *ScoringEngine) SetFeatureStore(featureStore *FeatureStore) {
+	e.featureStore = featureStore
+}
+
+// GetGazetteer returns the gazetteer used to resolve transaction locations
+func (e *ScoringEngine) GetGazetteer() *Gazetteer {
+	return e.gazetteer
+}
+
+// SetGazetteer replaces the empty built-in gazetteer, enabling distance and travel-speed features
+func (e *ScoringEngine) SetGazetteer(gazetteer *Gazetteer) {
+	e.gazetteer = gazetteer
+}
+
+// SetDeviceRepository enables device features from each account's device history
+func (e *ScoringEngine) SetDeviceRepository(deviceRepo *repositories.DeviceRepository) {
+	e.deviceRepo = deviceRepo
+}
+
+// SetCounterpartyRepository enables fan-in and fan-out features from the counterparty graph
+func (e *ScoringEngine) SetCounterpartyRepository(counterpartyRepo *repositories.CounterpartyRepository) {
+	e.counterpartyRepo = counterpartyRepo
+}
+
+// SetMerchantReputation enables merchant risk scores from merchant profiles
+func (e *ScoringEngine) SetMerchantReputation(merchantReputation *MerchantReputation) {
+	e.merchantReputation = merchantReputation
+}
+
+// SetProbeThresholds replaces the default card-testing probe thresholds
+func (e *ScoringEngine) SetProbeThresholds(thresholds ProbeThresholds) {
+	e.probeThresholds = thresholds
+}
+
+// SetPeerGroups enables peer-group features from the peer-group baselines
+func (e *ScoringEngine) SetPeerGroups(peerGroups *PeerGroups) {
+	e.peerGroups = peerGroups
 }
 
 // ScoreTransaction computes the risk score for a transaction
@@ -304,8 +192,7 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 	activeExperiments := e.abTestManager.GetActiveExperiments()
 	
 	// Apply rules and compute rule score (potentially with A/B test modifications)
-	var ruleScore float64
-	var triggeredRules []string
+	var ruleResult RuleResult
 	modelVersion := e.modelVersion
 
 	if len(activeExperiments) > 0 {
@@ -313,44 +200,40 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 		abDecision, err = e.abTestManager.AssignGroup(exp.ID, event.AccountID)
 		if err == nil {
 			if abDecision.Group == "test" {
-				ruleScore, triggeredRules = e.applyRulesForABTest(features, tx, exp.TestRules)
+				ruleResult = e.applyRulesForABTest(features, tx, exp.TestRules, exp.TestAggregation)
 				modelVersion = e.modelVersion + "-test-" + exp.ID[:8]
 			} else {
-				ruleScore, triggeredRules = e.applyRulesForABTest(features, tx, exp.ControlRules)
+				ruleResult = e.applyRulesForABTest(features, tx, exp.ControlRules, exp.ControlAggregation)
 				modelVersion = e.modelVersion + "-control-" + exp.ID[:8]
 			}
 		} else {
-			ruleScore, triggeredRules = e.applyRules(features, tx)
+			ruleResult = e.applyRules(features, tx)
 		}
 	} else {
-		ruleScore, triggeredRules = e.applyRules(features, tx)
+		ruleResult = e.applyRules(features, tx)
 	}
+	ruleScore, triggeredRules := ruleResult.Score, ruleResult.Triggered
 
 	// Compute ML and behavioral scores
 	mlResult := e.mlScorer.Score(ctx, features, tx)
 
 	// Compute final hybrid score
 	// Final Score = (ruleWeight * RuleScore) + (behavioralWeight * BehavioralScore) + (mlWeight * MLScore)
-	var finalScore float64
+	ruleWeight, behavioralWeight, mlWeight := hybridWeights(e.ruleWeight, e.behavioralWeight, e.mlWeight, mlResult.MLScore != nil)
+	finalScore := (ruleWeight * ruleScore) + (behavioralWeight * mlResult.BehavioralScore)
 	if mlResult.MLScore != nil {
-		finalScore = (e.ruleWeight * ruleScore) + 
-		             (e.behavioralWeight * mlResult.BehavioralScore) + 
-		             (e.mlWeight * *mlResult.MLScore)
-	} else {
-		// If no ML score, redistribute weight to rule and behavioral
-		adjustedRuleWeight := e.ruleWeight + (e.mlWeight * 0.6)      // 60% of ML weight to rules
-		adjustedBehavioralWeight := e.behavioralWeight + (e.mlWeight * 0.4) // 40% to behavioral
-		finalScore = (adjustedRuleWeight * ruleScore) + (adjustedBehavioralWeight * mlResult.BehavioralScore)
+		finalScore += mlWeight * *mlResult.MLScore
 	}
 
 	// Normalize final score
 	finalScore = math.Round(math.Min(finalScore, 100)*100) / 100
 
-	// Determine risk level based on final score
-	riskLevel := e.determineRiskLevel(finalScore)
+	// Rank customer-facing reason codes by their points of the final score
+	reasonCodes := e.decisionReasons(ruleResult, ruleWeight, mlResult.BehavioralScore, behavioralWeight, features, tx)
 
-	// Determine transaction status based on risk
-	status := e.determineTransactionStatus(finalScore, riskLevel)
+	// Determine risk level and decision from the decision policy for the account's segment
+	decision := e.decide(ctx, accountID, finalScore, tx)
+	riskLevel, status := decision.RiskLevel, decision.Status
 
 	// Determine scoring path (for fast-path optimization)
 	scoringPath := "full"
@@ -358,26 +241,34 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 		scoringPath = "fast" // Low risk, could skip some checks
 	}
 
-	// Update transaction status
-	if err := e.txRepo.UpdateStatus(ctx, tx.ID, tx.CreatedAt, status); err != nil {
+	// Update the status of a pending transaction; a rescore keeps an earlier decision
+	if err := e.txRepo.UpdatePendingStatus(ctx, tx.ID, tx.CreatedAt, status); errors.Is(err, repositories.ErrTransactionStatusChanged) {
+		log.Debug().Str("transaction_id", tx.ID.String()).Msg("Transaction already decided, status kept")
+	} else if err != nil {
 		log.Error().Err(err).Str("transaction_id", tx.ID.String()).Msg("Failed to update transaction status")
 	}
 
 	// Create risk score record with hybrid scores
 	processingTime := time.Since(startTime)
 	riskScore := &models.RiskScore{
-		TransactionID:     tx.ID,
-		Score:             finalScore,
-		RuleScore:         ruleScore,
-		MLScore:           mlResult.MLScore,
-		BehavioralScore:   &mlResult.BehavioralScore,
-		RiskLevel:         riskLevel,
-		RulesTriggered:    triggeredRules,
-		AnomaliesDetected: mlResult.AnomaliesDetected,
-		Features:          e.featuresToJSONB(features),
-		ModelVersion:      modelVersion,
-		ScoringPath:       scoringPath,
-		ProcessingTimeMs:  processingTime.Milliseconds(),
+		TransactionID:         tx.ID,
+		Score:                 finalScore,
+		RuleScore:             ruleScore,
+		MLScore:               mlResult.MLScore,
+		BehavioralScore:       &mlResult.BehavioralScore,
+		RiskLevel:             riskLevel,
+		Decision:              decision.Decision,
+		RulesTriggered:        triggeredRules,
+		ShadowRulesTriggered:  ruleResult.ShadowTriggered,
+		AnomaliesDetected:     mlResult.AnomaliesDetected,
+		ReasonCodes:           reasonCodes,
+		Features:              e.featuresToJSONB(features),
+		ModelVersion:          modelVersion,
+		RuleSetVersion:        ruleResult.RuleSetVersion,
+		DecisionPolicyVersion: decision.PolicyVersion,
+		PolicySegment:         decision.Segment,
+		ScoringPath:           scoringPath,
+		ProcessingTimeMs:      processingTime.Milliseconds(),
 	}
 
 	// Add A/B test info to features if applicable
@@ -394,6 +285,7 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 		"rule_weight":      e.ruleWeight,
 		"behavioral_weight": e.behavioralWeight,
 		"ml_weight":        e.mlWeight,
+		"aggregation":      ruleResult.Aggregation,
 	}
 
 	if err := e.riskScoreRepo.CreateWithTransactionTime(ctx, riskScore, tx.CreatedAt); err != nil {
@@ -405,10 +297,19 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 		e.abTestManager.RecordResult(abDecision.ExperimentID, abDecision, riskScore, tx)
 	}
 
+	// Add the transaction to the account's history in the online feature store
+	e.recordFeatures(ctx, tx, riskScore)
+
+	// Count the transaction in its merchant's profile
+	e.recordMerchant(ctx, tx, decision.Decision)
+
+	// Add a transfer's edge to the counterparty graph
+	e.recordCounterparty(ctx, tx)
+
 	// Update account risk profile if needed
 	e.updateAccountRiskProfile(ctx, accountID, riskLevel)
 
-	// Cache the result
+	// Cache the result and notify clients waiting for it
 	e.cacheRiskScore(ctx, tx.ID.String(), riskScore)
 
 	logEvent := log.Info().
@@ -417,8 +318,13 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 		Float64("rule_score", ruleScore).
 		Float64("behavioral_score", mlResult.BehavioralScore).
 		Str("risk_level", riskLevel).
+		Str("decision", decision.Decision).
+		Int("rule_set_version", ruleResult.RuleSetVersion).
+		Int("decision_policy_version", decision.PolicyVersion).
+		Str("policy_segment", decision.Segment).
 		Str("scoring_path", scoringPath).
 		Strs("rules_triggered", triggeredRules).
+		Strs("shadow_rules_triggered", ruleResult.ShadowTriggered).
 		Strs("anomalies_detected", mlResult.AnomaliesDetected).
 		Int64("processing_time_ms", processingTime.Milliseconds())
 	
@@ -437,190 +343,105 @@ func (e *ScoringEngine) ScoreTransaction(ctx context.Context, event *models.Tran
 	return riskScore, nil
 }
 
-// applyRulesForABTest applies specific rules for A/B testing
-func (e *ScoringEngine) applyRulesForABTest(features *models.RiskFeatures, tx *models.Transaction, ruleIDs []string) (float64, []string) {
-	// If no specific rules defined, use all rules
-	if len(ruleIDs) == 0 {
-		return e.applyRules(features, tx)
+// hybridWeights returns the weights applied to the rule, behavioral and ML scores.
+// Without an ML score its weight is redistributed: 60% to rules, 40% to behavioral.
+func hybridWeights(ruleWeight, behavioralWeight, mlWeight float64, hasMLScore bool) (float64, float64, float64) {
+	if hasMLScore {
+		return ruleWeight, behavioralWeight, mlWeight
 	}
+	return ruleWeight + (mlWeight * 0.6), behavioralWeight + (mlWeight * 0.4), 0
+}
 
-	// Create a set of allowed rule IDs
-	allowedRules := make(map[string]bool)
-	for _, id := range ruleIDs {
-		allowedRules[id] = true
-	}
-
-	var totalScore float64
-	var triggeredRules []string
-
-	for _, rule := range e.rules {
-		// Skip rules not in the allowed set
-		if !allowedRules[rule.ID] {
-			continue
+// decisionReasons splits the weighted rule and behavioral points of the final score
+// between the triggered rules and detected anomalies, in proportion to the impact
+// or points each counted with, and ranks the reason codes they map to
+func (e *ScoringEngine) decisionReasons(ruleResult RuleResult, ruleWeight, behavioralScore, behavioralWeight float64, features *models.RiskFeatures, tx *models.Transaction) []models.DecisionReason {
+	rulePoints := make(map[string]float64, len(ruleResult.Impacts))
+	var totalImpact float64
+	for _, impact := range ruleResult.Impacts {
+		totalImpact += impact
+	}
+	if totalImpact > 0 {
+		for ruleID, impact := range ruleResult.Impacts {
+			rulePoints[ruleID] = ruleWeight * ruleResult.Score * impact / totalImpact
 		}
+	}
 
-		if rule.Evaluate(features, tx) {
-			totalScore += rule.ScoreImpact
-			triggeredRules = append(triggeredRules, rule.ID)
+	contributions := behavioralContributions(features, tx)
+	anomalyPoints := make(map[string]float64, len(contributions))
+	var totalPoints float64
+	for _, c := range contributions {
+		totalPoints += c.Points
+	}
+	if totalPoints > 0 {
+		for _, c := range contributions {
+			anomalyPoints[c.Anomaly] += behavioralWeight * behavioralScore * c.Points / totalPoints
 		}
 	}
 
-	// Cap score at 100
-	if totalScore > 100 {
-		totalScore = 100
-	}
+	return e.reasonCodes.Rank(rulePoints, anomalyPoints, tx)
+}
 
-	return math.Round(totalScore*100) / 100, triggeredRules
+// applyRulesForABTest applies specific rules and score aggregation for A/B testing
+func (e *ScoringEngine) applyRulesForABTest(features *models.RiskFeatures, tx *models.Transaction, ruleIDs []string, aggregation *models.ScoreAggregation) RuleResult {
+	// If no specific rules defined, the rule engine uses all rules;
+	// if no aggregation is defined, it uses the rule set's strategy
+	return e.ruleEngine.EvaluateSubset(features, tx, ruleIDs, aggregation)
 }
 
-// computeFeatures computes risk features for a transaction
+// computeFeatures computes risk features for a transaction as of its CreatedAt, from
+// the account's strictly earlier transactions
 func (e *ScoringEngine) computeFeatures(ctx context.Context, accountID uuid.UUID, tx *models.Transaction) (*models.RiskFeatures, error) {
-	features := &models.RiskFeatures{}
-
-	// Get recent transactions for the account
-	since7d := time.Now().Add(-7 * 24 * time.Hour)
-	since30d := time.Now().Add(-30 * 24 * time.Hour)
-	since1h := time.Now().Add(-1 * time.Hour)
-	since24h := time.Now().Add(-24 * time.Hour)
-
-	// Get transaction statistics
-	stats, err := e.txRepo.GetTransactionStats(ctx, accountID, 30)
+	aggs, recent, err := e.accountActivity(ctx, accountID, tx)
 	if err != nil {
-		log.Warn().Err(err).Msg("Failed to get transaction stats")
-	} else {
-		if avgAmount, ok := stats["avg_amount"].(float64); ok {
-			features.RollingAvgSpend30d = avgAmount
-		}
-		if stddev, ok := stats["stddev_amount"].(float64); ok && stddev > 0 {
-			features.AmountDeviation = (tx.Amount - features.RollingAvgSpend30d) / stddev
-		}
-		if uniqueLocations, ok := stats["unique_locations"].(int); ok {
-			features.UniqueLocations7d = uniqueLocations
-		}
+		return nil, fmt.Errorf("failed to load account history: %w", err)
 	}
 
-	// Get 7-day average
-	stats7d, err := e.txRepo.GetTransactionStats(ctx, accountID, 7)
-	if err == nil {
-		if avgAmount, ok := stats7d["avg_amount"].(float64); ok {
-			features.RollingAvgSpend7d = avgAmount
-		}
-	}
-
-	// Calculate velocity (transactions per hour and per day)
-	recentTx1h, _ := e.txRepo.GetRecentByAccount(ctx, accountID, since1h)
-	features.TransactionVelocity1h = len(recentTx1h)
-
-	recentTx24h, _ := e.txRepo.GetRecentByAccount(ctx, accountID, since24h)
-	features.TransactionVelocity24h = len(recentTx24h)
+	features := rollingFeatures(tx, aggs)
 
-	// Check for location changes
-	recentTx7d, _ := e.txRepo.GetRecentByAccount(ctx, accountID, since7d)
-	locations := make(map[string]bool)
-	var lastLocation string
-	locationChanges := 0
+	// Card-testing probes before the transaction
+	sequenceFeatures(e.probeThresholds, tx, recent, features)
 
-	for _, t := range recentTx7d {
-		if t.Location != "" {
-			locations[t.Location] = true
-			if lastLocation != "" && lastLocation != t.Location {
-				locationChanges++
-			}
-			lastLocation = t.Location
-		}
-	}
+	// Distance and implied speed from the previous located transaction
+	travelFeatures(e.gazetteer, tx, aggs.recentPlaces(), features)
 
-	features.UniqueLocations7d = len(locations)
-	features.LocationChangeCount = locationChanges
-
-	// Check if new location
-	if tx.Location != "" {
-		features.IsNewLocation = !locations[tx.Location]
-	}
+	// Location and country risk from geo_locations
+	locationRiskFeatures(e.gazetteer, tx, features)
 
-	// Check if new merchant
-	recentMerchants := make(map[string]bool)
-	for _, t := range recentTx7d {
-		if t.Merchant != "" {
-			recentMerchants[t.Merchant] = true
-		}
-	}
-	if tx.Merchant != "" {
-		features.IsNewMerchant = !recentMerchants[tx.Merchant]
-	}
+	// New device, device age and devices per account
+	e.computeDeviceFeatures(ctx, tx, features)
 
-	// Check for high-risk country
-	if tx.Country != "" {
-		features.IsHighRiskCountry = highRiskCountries[tx.Country]
-	}
+	// Fan-in and fan-out of transfers in the counterparty graph
+	e.computeCounterpartyFeatures(ctx, tx, features)
 
-	// Calculate time since last transaction
-	if len(recentTx24h) > 1 {
-		lastTx := recentTx24h[1] // Index 0 is current transaction
-		features.TimeSinceLastTx = time.Since(lastTx.CreatedAt).Hours()
-	}
+	// Smoothed merchant risk from the merchant's profile
+	e.computeMerchantFeatures(ctx, tx, features)
 
-	// Calculate anomaly ratio (flagged transactions / total)
-	flaggedCount := 0
-	for _, t := range recentTx7d {
-		if t.Status == models.TransactionStatusFlagged || t.Status == models.TransactionStatusBlocked {
-			flaggedCount++
-		}
-	}
-	if len(recentTx7d) > 0 {
-		features.AnomalyRatio = float64(flaggedCount) / float64(len(recentTx7d))
-	}
+	// Spend relative to the account's peer group
+	e.computePeerFeatures(ctx, accountID, tx, features)
 
-	// Get 30-day transactions for additional stats
-	_ = since30d // Used in stats query
+	// Windowed aggregates referenced by window_aggregate rule conditions
+	e.computeWindowAggregates(ctx, accountID, tx, features)
 
 	return features, nil
 }
 
-// applyRules applies all rules and returns the score and triggered rules
-func (e *ScoringEngine) applyRules(features *models.RiskFeatures, tx *models.Transaction) (float64, []string) {
-	var totalScore float64
-	var triggeredRules []string
-
-	for _, rule := range e.rules {
-		if rule.Evaluate(features, tx) {
-			totalScore += rule.ScoreImpact
-			triggeredRules = append(triggeredRules, rule.ID)
-		}
-	}
-
-	// Cap score at 100
-	if totalScore > 100 {
-		totalScore = 100
-	}
-
-	return math.Round(totalScore*100) / 100, triggeredRules
-}
-
-// determineRiskLevel determines the risk level based on score
-func (e *ScoringEngine) determineRiskLevel(score float64) string {
-	switch {
-	case score >= 70:
-		return models.RiskLevelCritical
-	case score >= 50:
-		return models.RiskLevelHigh
-	case score >= 25:
-		return models.RiskLevelMedium
-	default:
-		return models.RiskLevelLow
-	}
+// applyRules applies all rules and returns the score, triggered rules and rule-set version
+func (e *ScoringEngine) applyRules(features *models.RiskFeatures, tx *models.Transaction) RuleResult {
+	return e.ruleEngine.Evaluate(features, tx)
 }
 
-// determineTransactionStatus determines the transaction status based on risk
-func (e *ScoringEngine) determineTransactionStatus(score float64, riskLevel string) string {
-	switch riskLevel {
-	case models.RiskLevelCritical:
-		return models.TransactionStatusBlocked
-	case models.RiskLevelHigh:
-		return models.TransactionStatusFlagged
-	default:
-		return models.TransactionStatusProcessed
+// decide determines the risk level and decision from the decision policy.
+// The account's type selects the policy segment along with the transaction's channel,
+// country and amount.
+func (e *ScoringEngine) decide(ctx context.Context, accountID uuid.UUID, score float64, tx *models.Transaction) PolicyDecision {
+	accountType := ""
+	if account, err := e.accountRepo.GetByID(ctx, accountID); err == nil {
+		accountType = account.AccountType
+	} else {
+		log.Warn().Err(err).Str("account_id", accountID.String()).Msg("Failed to get account for decision policy")
 	}
+	return e.policyEngine.Decide(score, accountType, tx)
 }
 
 // updateAccountRiskProfile updates the account risk profile based on scoring
@@ -655,7 +476,7 @@ func (e *ScoringEngine) featuresToJSONB(features *models.RiskFeatures) models.JS
 	return jsonb
 }
 
-// cacheRiskScore caches the risk score
+// cacheRiskScore caches the risk score and publishes it on the transaction's channel
 func (e *ScoringEngine) cacheRiskScore(ctx context.Context, txID string, score *models.RiskScore) {
 	if e.cacheClient == nil {
 		return
@@ -665,6 +486,11 @@ func (e *ScoringEngine) cacheRiskScore(ctx context.Context, txID string, score *
 	if err := e.cacheClient.Set(ctx, key, score, 24*time.Hour); err != nil {
 		log.Warn().Err(err).Str("transaction_id", txID).Msg("Failed to cache risk score")
 	}
+
+	// Wake ingestion requests waiting for this decision
+	if err := e.cacheClient.Publish(ctx, queue.RiskScoreChannel(txID), score); err != nil {
+		log.Warn().Err(err).Str("transaction_id", txID).Msg("Failed to publish risk score")
+	}
 }
 
 // GetCachedRiskScore retrieves a cached risk score
@@ -0,0 +1,312 @@
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// RuleSetSource fetches published rule-set versions
+type RuleSetSource interface {
+	GetRuleSetVersion(ctx context.Context, version int) (*models.RuleSetVersion, error)
+}
+
+// ExplanationService explains stored scoring decisions: which rules fired and why,
+// which behavioral anomalies were detected, and how each hybrid component contributed.
+// It replays the stored features against the rule-set version that produced the score.
+type ExplanationService struct {
+	engine   *ScoringEngine
+	ruleSets RuleSetSource
+}
+
+// NewExplanationService creates a new explanation service
+func NewExplanationService(engine *ScoringEngine, ruleSets RuleSetSource) *ExplanationService {
+	return &ExplanationService{
+		engine:   engine,
+		ruleSets: ruleSets,
+	}
+}
+
+// DecisionExplanation explains a single scoring decision
+type DecisionExplanation struct {
+	TransactionID  uuid.UUID                `json:"transaction_id"`
+	Score          float64                  `json:"score"`
+	RiskLevel      string                   `json:"risk_level"`
+	RuleSetVersion int                      `json:"rule_set_version"`
+	Aggregation    string                   `json:"aggregation"`
+	Components     []ComponentContribution  `json:"components"`
+	Rules          []RuleExplanation        `json:"rules"`
+	Behavioral     []BehavioralContribution `json:"behavioral"`
+}
+
+// ComponentContribution is one hybrid component's weighted part of the final score
+type ComponentContribution struct {
+	Component string  `json:"component"` // rule, behavioral, ml
+	Score     float64 `json:"score"`
+	Weight    float64 `json:"weight"`
+	Points    float64 `json:"points"` // weight * score
+	Share     float64 `json:"share"`  // fraction of the summed points (before the 100 cap)
+}
+
+// RuleExplanation explains why a fired rule fired and what it contributed
+type RuleExplanation struct {
+	RuleID       string          `json:"rule_id"`
+	Name         string          `json:"name,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Group        string          `json:"group,omitempty"`
+	ScoreImpact  float64         `json:"score_impact"`
+	Contribution float64         `json:"contribution"` // proportional share of the rule score
+	Condition    *RuleCondition  `json:"condition,omitempty"`
+	Trace        *ConditionTrace `json:"trace,omitempty"`
+	Note         string          `json:"note,omitempty"`
+}
+
+// ConditionTrace records the values a condition compared when it was evaluated
+type ConditionTrace struct {
+	Type       string                 `json:"type"`
+	Field      string                 `json:"field,omitempty"`
+	Operator   string                 `json:"operator,omitempty"`
+	Expected   interface{}            `json:"expected,omitempty"` // threshold, [start, end] hours or expression
+	Actual     interface{}            `json:"actual,omitempty"`
+	Matched    bool                   `json:"matched"`
+	Values     map[string]interface{} `json:"values,omitempty"` // fields referenced by an expression
+	Conditions []ConditionTrace       `json:"conditions,omitempty"`
+}
+
+// ExplainTransaction explains the stored risk score of a transaction
+func (s *ExplanationService) ExplainTransaction(ctx context.Context, txID uuid.UUID) (*DecisionExplanation, error) {
+	score, err := s.engine.riskScoreRepo.GetByTransactionID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.engine.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Features are stored as JSON; unknown keys such as score_breakdown are ignored
+	features := &models.RiskFeatures{}
+	if data, err := json.Marshal(score.Features); err == nil {
+		if err := json.Unmarshal(data, features); err != nil {
+			return nil, fmt.Errorf("failed to decode stored features: %w", err)
+		}
+	}
+	breakdown := scoreBreakdown(score.Features)
+
+	rules, aggregation, err := s.rulesForVersion(ctx, score.RuleSetVersion)
+	if err != nil {
+		return nil, err
+	}
+	if breakdown.aggregation != "" {
+		aggregation = breakdown.aggregation // A/B experiments may override the rule set's strategy
+	}
+
+	explanation := &DecisionExplanation{
+		TransactionID:  txID,
+		Score:          score.Score,
+		RiskLevel:      score.RiskLevel,
+		RuleSetVersion: score.RuleSetVersion,
+		Aggregation:    aggregation,
+		Components:     breakdown.components(),
+		Rules:          explainRules(rules, score.RulesTriggered, breakdown.ruleScore, features, tx),
+		Behavioral:     behavioralContributions(features, tx),
+	}
+	if explanation.Behavioral == nil {
+		explanation.Behavioral = []BehavioralContribution{}
+	}
+
+	return explanation, nil
+}
+
+// rulesForVersion returns the rules of a rule-set version by ID (built-in defaults for version 0)
+func (s *ExplanationService) rulesForVersion(ctx context.Context, version int) (map[string]DBRule, string, error) {
+	rules := make(map[string]DBRule)
+	if version == 0 {
+		for _, rule := range getDefaultDBRules() {
+			rules[rule.ID] = rule
+		}
+		return rules, DefaultAggregation.Strategy, nil
+	}
+
+	ruleSet, err := s.ruleSets.GetRuleSetVersion(ctx, version)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, r := range ruleSet.Rules {
+		rule, err := parseDBRule(r)
+		if err != nil {
+			continue // reported as missing below; the engine could not have fired it
+		}
+		rules[rule.ID] = rule
+	}
+
+	aggregation := ruleSet.Aggregation
+	if err := ValidateAggregation(&aggregation); err != nil {
+		aggregation = DefaultAggregation
+	}
+	return rules, aggregation.Strategy, nil
+}
+
+// explainRules traces each fired rule and splits the rule score between them in
+// proportion to the impact each one counted with (one rule per group)
+func explainRules(rules map[string]DBRule, triggered []string, ruleScore float64, features *models.RiskFeatures, tx *models.Transaction) []RuleExplanation {
+	re := &RuleEngine{}
+	ctx := buildEvaluationContext(features, tx)
+
+	// The strongest fired rule of each group is the one that counted
+	groupWinner := make(map[string]string)
+	for _, id := range triggered {
+		rule, ok := rules[id]
+		if !ok || rule.Group == "" {
+			continue
+		}
+		if winner, seen := groupWinner[rule.Group]; !seen || rule.ScoreImpact > rules[winner].ScoreImpact {
+			groupWinner[rule.Group] = id
+		}
+	}
+
+	explanations := make([]RuleExplanation, 0, len(triggered))
+	counted := make([]float64, 0, len(triggered))
+	var total float64
+
+	for _, id := range triggered {
+		rule, ok := rules[id]
+		if !ok {
+			explanations = append(explanations, RuleExplanation{RuleID: id, Note: "rule not found in this rule-set version"})
+			counted = append(counted, 0)
+			continue
+		}
+
+		condition := rule.Condition
+		trace := re.traceCondition(condition, ctx)
+		explanation := RuleExplanation{
+			RuleID:      rule.ID,
+			Name:        rule.Name,
+			Description: rule.Description,
+			Group:       rule.Group,
+			ScoreImpact: rule.ScoreImpact,
+			Condition:   &condition,
+			Trace:       &trace,
+		}
+
+		impact := rule.ScoreImpact
+		if rule.Group != "" && groupWinner[rule.Group] != id {
+			impact = 0
+			explanation.Note = fmt.Sprintf("superseded by %s in group %s", groupWinner[rule.Group], rule.Group)
+		}
+
+		explanations = append(explanations, explanation)
+		counted = append(counted, impact)
+		total += impact
+	}
+
+	if total > 0 {
+		for i := range explanations {
+			explanations[i].Contribution = math.Round(ruleScore*counted[i]/total*100) / 100
+		}
+	}
+	return explanations
+}
+
+// traceCondition evaluates a condition tree, recording the compared values at every node
+func (re *RuleEngine) traceCondition(cond RuleCondition, ctx evaluationContext) ConditionTrace {
+	trace := ConditionTrace{
+		Type:    cond.Type,
+		Matched: re.evaluateCondition(cond, ctx),
+	}
+
+	switch cond.Type {
+	case "threshold":
+		trace.Field = cond.Field
+		trace.Operator = cond.Operator
+		trace.Expected = cond.Value
+		trace.Actual = getFieldValue(cond.Field, ctx)
+	case "compound":
+		trace.Operator = cond.Operator
+		for _, sub := range cond.Conditions {
+			trace.Conditions = append(trace.Conditions, re.traceCondition(sub, ctx))
+		}
+	case "time_range":
+		trace.Field = "hour"
+		trace.Expected = []int{cond.Start, cond.End}
+		trace.Actual = ctx.Hour
+	case "expression":
+		trace.Expected = cond.Expression
+		compiled := cond.compiled
+		if compiled == nil {
+			compiled, _ = compileExpression(cond.Expression)
+		}
+		if compiled != nil {
+			trace.Values = compiled.RefValues(ctx)
+		}
+	case "window_aggregate":
+		trace.Operator = cond.Operator
+		trace.Expected = cond.Value
+		if agg := cond.aggregate; agg != nil {
+			trace.Field = agg.key
+			if value, ok := ctx.Features.WindowAggregates[agg.key]; ok {
+				trace.Actual = value
+			}
+		}
+	}
+
+	return trace
+}
+
+// storedBreakdown is the score_breakdown recorded with each risk score
+type storedBreakdown struct {
+	ruleScore, behavioralScore             float64
+	mlScore                                *float64
+	ruleWeight, behavioralWeight, mlWeight float64
+	aggregation                            string
+}
+
+func scoreBreakdown(features models.JSONB) storedBreakdown {
+	var b storedBreakdown
+	raw, _ := features["score_breakdown"].(map[string]interface{})
+
+	number := func(key string) float64 {
+		v, _ := toFloat64(raw[key])
+		return v
+	}
+	b.ruleScore = number("rule_score")
+	b.behavioralScore = number("behavioral_score")
+	b.ruleWeight = number("rule_weight")
+	b.behavioralWeight = number("behavioral_weight")
+	b.mlWeight = number("ml_weight")
+	if v, ok := toFloat64(raw["ml_score"]); ok {
+		b.mlScore = &v
+	}
+	b.aggregation, _ = raw["aggregation"].(string)
+	return b
+}
+
+// components splits the final score into the weighted rule, behavioral and ML parts
+func (b storedBreakdown) components() []ComponentContribution {
+	ruleWeight, behavioralWeight, mlWeight := hybridWeights(b.ruleWeight, b.behavioralWeight, b.mlWeight, b.mlScore != nil)
+
+	components := []ComponentContribution{
+		{Component: "rule", Score: b.ruleScore, Weight: ruleWeight},
+		{Component: "behavioral", Score: b.behavioralScore, Weight: behavioralWeight},
+	}
+	if b.mlScore != nil {
+		components = append(components, ComponentContribution{Component: "ml", Score: *b.mlScore, Weight: mlWeight})
+	}
+
+	var total float64
+	for i := range components {
+		components[i].Points = math.Round(components[i].Weight*components[i].Score*100) / 100
+		total += components[i].Points
+	}
+	if total > 0 {
+		for i := range components {
+			components[i].Share = math.Round(components[i].Points/total*10000) / 10000
+		}
+	}
+	return components
+}
@@ -0,0 +1,820 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Rule expressions are a small, side-effect free language for "expression" conditions:
+//
+//	amount > 5 * rolling_avg_spend_30d && channel in ['online', 'pos']
+//	merchant_category startsWith 'crypto' or metadata.device_os == 'rooted'
+//	not (country in ['US', 'CA']) and metadata["ip_country"] != country
+//
+// Operands are numbers, 'strings', true/false, [literal lists], any rule field
+// (see ruleFields) and Transaction.Metadata keys via metadata.key or metadata["key"].
+// Operators, loosest first: or/||, and/&&, not/!, comparisons (== != < <= > >=,
+// in, not in, startsWith, endsWith, contains, matches), + -, * / %, unary -.
+//
+// Expressions are parsed and type-checked once (at save or load time); the compiled
+// form is cached by source text and evaluated without re-parsing. Metadata values are
+// only known at runtime: a missing or mistyped value makes the enclosing comparison
+// unknown. and/or follow three-valued logic (unknown or true is true, unknown and false
+// is false, otherwise unknown), not keeps unknown unknown, and an unknown result never
+// fires a rule.
+
+// exprType is the static type of an expression node
+type exprType int
+
+const (
+	typeAny exprType = iota // metadata values, checked at runtime
+	typeNumber
+	typeString
+	typeBool
+	typeNumberList
+	typeStringList
+)
+
+func (t exprType) String() string {
+	switch t {
+	case typeNumber:
+		return "number"
+	case typeString:
+		return "string"
+	case typeBool:
+		return "bool"
+	case typeNumberList:
+		return "number list"
+	case typeStringList:
+		return "string list"
+	default:
+		return "any"
+	}
+}
+
+// exprFunc evaluates a node; ok is false when the value is unknown
+type exprFunc func(ctx *evaluationContext) (value interface{}, ok bool)
+
+// exprNode is a type-checked, compiled expression node
+type exprNode struct {
+	typ      exprType
+	eval     exprFunc
+	constant bool // literal value, known at compile time
+}
+
+// compiledExpression is a parsed and type-checked rule expression
+type compiledExpression struct {
+	source string
+	root   exprNode
+	refs   []string // fields and metadata.key references, in order of first use
+}
+
+// RefValues returns the current value of every field and metadata key the expression
+// references (nil for unknown metadata), for explanations
+func (c *compiledExpression) RefValues(ctx evaluationContext) map[string]interface{} {
+	values := make(map[string]interface{}, len(c.refs))
+	for _, ref := range c.refs {
+		if key, ok := strings.CutPrefix(ref, "metadata."); ok {
+			v, _ := metadataNode(key).eval(&ctx)
+			values[ref] = v
+			continue
+		}
+		values[ref] = getFieldValue(ref, ctx)
+	}
+	return values
+}
+
+// Evaluate reports whether the expression holds; unknown results are false
+func (c *compiledExpression) Evaluate(ctx evaluationContext) bool {
+	v, ok := c.root.eval(&ctx)
+	b, isBool := v.(bool)
+	return ok && isBool && b
+}
+
+// exprScope names the set of fields an expression may reference
+type exprScope struct {
+	name   string
+	fields map[string]fieldType
+}
+
+var (
+	// ruleScope is used by expression conditions: every rule field
+	ruleScope = exprScope{name: "rule", fields: ruleFields}
+
+	// transactionScope is used by window_aggregate filters, which run against
+	// historical transactions that have no computed features
+	transactionScope = exprScope{name: "transaction", fields: transactionFields}
+)
+
+// expressionCache holds compiled expressions keyed by scope and source text
+var expressionCache sync.Map
+
+// compileExpression parses and type-checks a rule-scope expression, reusing a cached compilation
+func compileExpression(src string) (*compiledExpression, error) {
+	return compileScopedExpression(ruleScope, src)
+}
+
+// compileScopedExpression parses and type-checks an expression that may only reference scope's fields
+func compileScopedExpression(scope exprScope, src string) (*compiledExpression, error) {
+	cacheKey := scope.name + ":" + src
+	if cached, ok := expressionCache.Load(cacheKey); ok {
+		return cached.(*compiledExpression), nil
+	}
+
+	tokens, err := lexExpression(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, scope: scope}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	if root.typ != typeBool && root.typ != typeAny {
+		return nil, fmt.Errorf("expression must be boolean, got %s", root.typ)
+	}
+
+	compiled := &compiledExpression{source: src, root: root}
+	seen := make(map[string]bool, len(p.refs))
+	for _, ref := range p.refs {
+		if !seen[ref] {
+			seen[ref] = true
+			compiled.refs = append(compiled.refs, ref)
+		}
+	}
+	expressionCache.Store(cacheKey, compiled)
+	return compiled, nil
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// twoCharPunct lists the two-character operators, checked before single characters
+var twoCharPunct = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func lexExpression(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", src[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: n, pos: start})
+
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(src) {
+				if src[i] == '\\' && i+1 < len(src) {
+					sb.WriteByte(src[i+1])
+					i += 2
+					continue
+				}
+				if src[i] == c {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			matched := false
+			for _, op := range twoCharPunct {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokPunct, text: op, pos: i})
+					i += 2
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if !strings.ContainsRune("()[],+-*/%<>!", rune(c)) {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokPunct, text: string(c), pos: i})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of expression", pos: len(src)}), nil
+}
+
+// Parser
+
+type exprParser struct {
+	tokens []token
+	pos    int
+	scope  exprScope
+	refs   []string
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp reports whether tok is the punctuation or keyword operator op
+func (tok token) isOp(ops ...string) bool {
+	if tok.kind != tokPunct && tok.kind != tokIdent {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) expect(text string) error {
+	tok := p.next()
+	if tok.kind != tokPunct || tok.text != text {
+		return fmt.Errorf("expected %q at position %d, got %q", text, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for p.peek().isOp("||", "or") {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if err := requireTypes(tok, typeBool, left, right); err != nil {
+			return exprNode{}, err
+		}
+		l, r := left.eval, right.eval
+		left = exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			// Unknown unless either side is true or both are known
+			lb, lok := exprBool(l(ctx))
+			if lok && lb {
+				return true, true
+			}
+			rb, rok := exprBool(r(ctx))
+			if rok && rb {
+				return true, true
+			}
+			if !lok || !rok {
+				return nil, false
+			}
+			return false, true
+		}}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for p.peek().isOp("&&", "and") {
+		tok := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if err := requireTypes(tok, typeBool, left, right); err != nil {
+			return exprNode{}, err
+		}
+		l, r := left.eval, right.eval
+		left = exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			// Unknown unless either side is false or both are known
+			lb, lok := exprBool(l(ctx))
+			if lok && !lb {
+				return false, true
+			}
+			rb, rok := exprBool(r(ctx))
+			if rok && !rb {
+				return false, true
+			}
+			if !lok || !rok {
+				return nil, false
+			}
+			return true, true
+		}}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.peek().isOp("!", "not") {
+		tok := p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if err := requireTypes(tok, typeBool, operand); err != nil {
+			return exprNode{}, err
+		}
+		eval := operand.eval
+		return exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			b, ok := exprBool(eval(ctx))
+			if !ok {
+				return nil, false
+			}
+			return !b, true
+		}}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return exprNode{}, err
+	}
+
+	tok := p.peek()
+	op := tok.text
+	switch {
+	case tok.kind == tokPunct && tok.isOp("==", "!=", "<", "<=", ">", ">="):
+	case tok.kind == tokIdent && tok.isOp("in", "startsWith", "endsWith", "contains", "matches"):
+	case tok.kind == tokIdent && tok.text == "not" && p.peekAt(1).kind == tokIdent && p.peekAt(1).text == "in":
+		p.next()
+		op = "not in"
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return exprNode{}, err
+	}
+
+	l, r := left.eval, right.eval
+	switch op {
+	case "==", "!=":
+		if !compatible(left.typ, right.typ) || isList(left.typ) || isList(right.typ) {
+			return exprNode{}, typeMismatch(tok, left.typ, right.typ)
+		}
+		negate := op == "!="
+		return exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			a, okA := l(ctx)
+			b, okB := r(ctx)
+			if !okA || !okB {
+				return nil, false
+			}
+			eq, comparable := exprEqual(a, b)
+			if !comparable {
+				return nil, false
+			}
+			return eq != negate, true
+		}}, nil
+
+	case "<", "<=", ">", ">=":
+		if err := requireTypes(tok, typeNumber, left, right); err != nil {
+			return exprNode{}, err
+		}
+		cmp := numberComparisons[op]
+		return exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			a, okA := exprNumber(l(ctx))
+			b, okB := exprNumber(r(ctx))
+			if !okA || !okB {
+				return nil, false
+			}
+			return cmp(a, b), true
+		}}, nil
+
+	case "in", "not in":
+		elem, ok := listElem(right.typ)
+		if !ok || !compatible(left.typ, elem) {
+			return exprNode{}, typeMismatch(tok, left.typ, right.typ)
+		}
+		negate := op == "not in"
+		return exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			a, okA := l(ctx)
+			list, okB := r(ctx)
+			if !okA || !okB {
+				return nil, false
+			}
+			for _, item := range list.([]interface{}) {
+				if eq, comparable := exprEqual(a, item); comparable && eq {
+					return !negate, true
+				}
+			}
+			return negate, true
+		}}, nil
+
+	case "matches":
+		if err := requireTypes(tok, typeString, left, right); err != nil {
+			return exprNode{}, err
+		}
+		if !right.constant {
+			return exprNode{}, fmt.Errorf("matches at position %d needs a literal pattern", tok.pos)
+		}
+		pattern, _ := r(nil)
+		re, err := regexp.Compile(pattern.(string))
+		if err != nil {
+			return exprNode{}, fmt.Errorf("invalid pattern at position %d: %w", tok.pos, err)
+		}
+		return exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			s, ok := exprString(l(ctx))
+			if !ok {
+				return nil, false
+			}
+			return re.MatchString(s), true
+		}}, nil
+
+	default: // startsWith, endsWith, contains
+		if err := requireTypes(tok, typeString, left, right); err != nil {
+			return exprNode{}, err
+		}
+		match := stringOperators[op]
+		return exprNode{typ: typeBool, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			a, okA := exprString(l(ctx))
+			b, okB := exprString(r(ctx))
+			if !okA || !okB {
+				return nil, false
+			}
+			return match(a, b), true
+		}}, nil
+	}
+}
+
+var numberComparisons = map[string]func(a, b float64) bool{
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+}
+
+var stringOperators = map[string]func(a, b string) bool{
+	"startsWith": strings.HasPrefix,
+	"endsWith":   strings.HasSuffix,
+	"contains":   strings.Contains,
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for p.peek().kind == tokPunct && p.peek().isOp("+", "-") {
+		tok := p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if left, err = arithmetic(tok, left, right); err != nil {
+			return exprNode{}, err
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for p.peek().kind == tokPunct && p.peek().isOp("*", "/", "%") {
+		tok := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if left, err = arithmetic(tok, left, right); err != nil {
+			return exprNode{}, err
+		}
+	}
+	return left, nil
+}
+
+// arithmetic builds a numeric binary operation; division by zero is unknown
+func arithmetic(tok token, left, right exprNode) (exprNode, error) {
+	if err := requireTypes(tok, typeNumber, left, right); err != nil {
+		return exprNode{}, err
+	}
+	l, r := left.eval, right.eval
+	op := tok.text
+	return exprNode{typ: typeNumber, eval: func(ctx *evaluationContext) (interface{}, bool) {
+		a, okA := exprNumber(l(ctx))
+		b, okB := exprNumber(r(ctx))
+		if !okA || !okB {
+			return nil, false
+		}
+		switch op {
+		case "+":
+			return a + b, true
+		case "-":
+			return a - b, true
+		case "*":
+			return a * b, true
+		case "/":
+			if b == 0 {
+				return nil, false
+			}
+			return a / b, true
+		default:
+			if b == 0 {
+				return nil, false
+			}
+			return math.Mod(a, b), true
+		}
+	}}, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek().kind == tokPunct && p.peek().text == "-" {
+		tok := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if err := requireTypes(tok, typeNumber, operand); err != nil {
+			return exprNode{}, err
+		}
+		eval := operand.eval
+		return exprNode{typ: typeNumber, constant: operand.constant, eval: func(ctx *evaluationContext) (interface{}, bool) {
+			n, ok := exprNumber(eval(ctx))
+			if !ok {
+				return nil, false
+			}
+			return -n, true
+		}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return constantNode(typeNumber, tok.num), nil
+	case tokString:
+		return constantNode(typeString, tok.text), nil
+	case tokIdent:
+		return p.parseIdent(tok)
+	case tokPunct:
+		switch tok.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return exprNode{}, err
+			}
+			if err := p.expect(")"); err != nil {
+				return exprNode{}, err
+			}
+			return node, nil
+		case "[":
+			return p.parseList(tok)
+		}
+	}
+	return exprNode{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *exprParser) parseIdent(tok token) (exprNode, error) {
+	switch tok.text {
+	case "true":
+		return constantNode(typeBool, true), nil
+	case "false":
+		return constantNode(typeBool, false), nil
+	case "metadata":
+		if err := p.expect("["); err != nil {
+			return exprNode{}, err
+		}
+		key := p.next()
+		if key.kind != tokString {
+			return exprNode{}, fmt.Errorf("expected metadata key string at position %d", key.pos)
+		}
+		if err := p.expect("]"); err != nil {
+			return exprNode{}, err
+		}
+		p.refs = append(p.refs, "metadata."+key.text)
+		return metadataNode(key.text), nil
+	}
+
+	if key, ok := strings.CutPrefix(tok.text, "metadata."); ok && key != "" {
+		p.refs = append(p.refs, tok.text)
+		return metadataNode(key), nil
+	}
+
+	ft, ok := p.scope.fields[tok.text]
+	if !ok {
+		return exprNode{}, fmt.Errorf("unknown %s field %q at position %d", p.scope.name, tok.text, tok.pos)
+	}
+
+	field := tok.text
+	p.refs = append(p.refs, field)
+	typ := map[fieldType]exprType{fieldNumber: typeNumber, fieldBool: typeBool, fieldString: typeString}[ft]
+	return exprNode{typ: typ, eval: func(ctx *evaluationContext) (interface{}, bool) {
+		v := getFieldValue(field, *ctx)
+		if v == nil {
+			return nil, false
+		}
+		if typ == typeNumber {
+			return exprNumber(v, true)
+		}
+		return v, true
+	}}, nil
+}
+
+// parseList parses a non-empty list of number or string literals
+func (p *exprParser) parseList(open token) (exprNode, error) {
+	var items []interface{}
+	elem := typeAny
+	for {
+		item, err := p.parseUnary()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if !item.constant || (item.typ != typeNumber && item.typ != typeString) {
+			return exprNode{}, fmt.Errorf("list at position %d may only contain number or string literals", open.pos)
+		}
+		if elem != typeAny && item.typ != elem {
+			return exprNode{}, fmt.Errorf("list at position %d mixes %s and %s", open.pos, elem, item.typ)
+		}
+		elem = item.typ
+		v, _ := item.eval(nil)
+		items = append(items, v)
+
+		if p.peek().kind == tokPunct && p.peek().text == "," {
+			p.next()
+			continue
+		}
+		if err := p.expect("]"); err != nil {
+			return exprNode{}, err
+		}
+		break
+	}
+
+	typ := typeStringList
+	if elem == typeNumber {
+		typ = typeNumberList
+	}
+	return constantNode(typ, items), nil
+}
+
+func constantNode(typ exprType, v interface{}) exprNode {
+	return exprNode{typ: typ, constant: true, eval: func(*evaluationContext) (interface{}, bool) {
+		return v, true
+	}}
+}
+
+// metadataNode reads a Transaction.Metadata key; numbers are normalized to float64
+func metadataNode(key string) exprNode {
+	return exprNode{typ: typeAny, eval: func(ctx *evaluationContext) (interface{}, bool) {
+		if ctx.Tx == nil || ctx.Tx.Metadata == nil {
+			return nil, false
+		}
+		v, ok := ctx.Tx.Metadata[key]
+		if !ok || v == nil {
+			return nil, false
+		}
+		if n, isNumber := toFloat64(v); isNumber {
+			return n, true
+		}
+		return v, true
+	}}
+}
+
+// Type checking helpers
+
+func compatible(a, b exprType) bool {
+	return a == b || a == typeAny || b == typeAny
+}
+
+func isList(t exprType) bool {
+	return t == typeNumberList || t == typeStringList
+}
+
+func listElem(t exprType) (exprType, bool) {
+	switch t {
+	case typeNumberList:
+		return typeNumber, true
+	case typeStringList:
+		return typeString, true
+	default:
+		return typeAny, false
+	}
+}
+
+// requireTypes checks that every operand of tok has type want (or is a runtime-typed value)
+func requireTypes(tok token, want exprType, operands ...exprNode) error {
+	for _, operand := range operands {
+		if !compatible(operand.typ, want) || isList(operand.typ) {
+			return fmt.Errorf("operator %q at position %d expects %s operands, got %s", tok.text, tok.pos, want, operand.typ)
+		}
+	}
+	return nil
+}
+
+func typeMismatch(tok token, left, right exprType) error {
+	return fmt.Errorf("operator %q at position %d cannot compare %s with %s", tok.text, tok.pos, left, right)
+}
+
+// Runtime helpers
+
+// exprBool returns a boolean value; ok is false when it is unknown
+func exprBool(v interface{}, ok bool) (bool, bool) {
+	if !ok {
+		return false, false
+	}
+	b, isBool := v.(bool)
+	return b, isBool
+}
+
+func exprNumber(v interface{}, ok bool) (float64, bool) {
+	if !ok {
+		return 0, false
+	}
+	return toFloat64(v)
+}
+
+func exprString(v interface{}, ok bool) (string, bool) {
+	if !ok {
+		return "", false
+	}
+	s, isString := v.(string)
+	return s, isString
+}
+
+// exprEqual compares two scalar values; comparable is false when their runtime types differ
+func exprEqual(a, b interface{}) (eq bool, comparable bool) {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		return ok && av == bv, ok
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv, ok
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv, ok
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,109 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+func expressionContext(metadata models.JSONB) evaluationContext {
+	tx := &models.Transaction{
+		Amount:           2500,
+		Channel:          "online",
+		Country:          "US",
+		MerchantCategory: "crypto_exchange",
+		Metadata:         metadata,
+		CreatedAt:        time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC),
+	}
+	features := &models.RiskFeatures{RollingAvgSpend30d: 400}
+	return buildEvaluationContext(features, tx)
+}
+
+func TestCompileExpression(t *testing.T) {
+	valid := []string{
+		"amount > 5 * rolling_avg_spend_30d && channel in ['online', 'pos']",
+		"merchant_category startsWith 'crypto' or metadata.device_os == 'rooted'",
+		"not (country in ['US', 'CA']) and metadata[\"ip_country\"] != country",
+		"!(amount <= 100) || channel not in ['atm']",
+		"(amount + 10) % 7 == 3 and -amount < 0",
+	}
+	for _, src := range valid {
+		if _, err := compileExpression(src); err != nil {
+			t.Errorf("compileExpression(%q): %v", src, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"amount > 'large'":            "expects number operands",
+		"channel == 1":                "cannot compare",
+		"amount and channel == 'pos'": "operator",
+		"no_such_field > 1":           "unknown rule field",
+		"amount > (1 + 2":             "expected",
+		"channel in ['pos', 3]":       "",
+		"not amount":                  "",
+		"":                            "",
+	}
+	for src, want := range invalid {
+		_, err := compileExpression(src)
+		if err == nil {
+			t.Errorf("compileExpression(%q) succeeded, want an error", src)
+			continue
+		}
+		if want != "" && !strings.Contains(err.Error(), want) {
+			t.Errorf("compileExpression(%q) = %v, want an error containing %q", src, err, want)
+		}
+	}
+}
+
+func TestEvaluateExpression(t *testing.T) {
+	withOS := models.JSONB{"device_os": "ios", "attempts": float64(3)}
+
+	tests := []struct {
+		src      string
+		metadata models.JSONB
+		want     bool
+	}{
+		{"amount > 5 * rolling_avg_spend_30d", nil, true},
+		{"channel in ['pos', 'atm']", nil, false},
+		{"merchant_category startsWith 'crypto' and country == 'US'", nil, true},
+		{"metadata.device_os == 'ios'", withOS, true},
+		{"metadata.attempts >= 3", withOS, true},
+		{"metadata.attempts == 'three'", withOS, false}, // mistyped: unknown
+		{"not (metadata.device_os == 'android')", withOS, true},
+
+		// A missing key is unknown, and so is its negation
+		{"metadata.device_os == 'ios'", nil, false},
+		{"metadata.device_os != 'ios'", nil, false},
+		{"not (metadata.device_os == 'ios')", nil, false},
+
+		// Three-valued or: unknown unless a side is true
+		{"metadata.device_os == 'ios' or amount > 1000", nil, true},
+		{"amount > 1000 or metadata.device_os == 'ios'", nil, true},
+		{"metadata.device_os == 'ios' or amount < 10", nil, false},
+		{"not (metadata.device_os == 'ios' or amount < 10)", nil, false},
+		{"not (metadata.device_os == 'ios' or amount > 1000)", nil, false},
+
+		// Three-valued and: unknown unless a side is false
+		{"metadata.device_os == 'ios' and amount < 10", nil, false},
+		{"not (metadata.device_os == 'ios' and amount < 10)", nil, true},
+		{"not (amount < 10 and metadata.device_os == 'ios')", nil, true},
+		{"not (metadata.device_os == 'ios' and amount > 1000)", nil, false},
+		{"metadata.device_os == 'ios' and amount > 1000", withOS, true},
+
+		// Division by zero is unknown
+		{"amount / (rolling_avg_spend_30d - 400) > 1", nil, false},
+		{"not (amount / (rolling_avg_spend_30d - 400) > 1)", nil, false},
+	}
+
+	for _, tt := range tests {
+		compiled, err := compileExpression(tt.src)
+		if err != nil {
+			t.Fatalf("compileExpression(%q): %v", tt.src, err)
+		}
+		if got := compiled.Evaluate(expressionContext(tt.metadata)); got != tt.want {
+			t.Errorf("%q with metadata %v = %v, want %v", tt.src, tt.metadata, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,494 @@
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/queue"
+	"github.com/enterprise/risk-engine/internal/repositories"
+)
+
+// featureRetention is how long the feature store keeps an account's transactions: the
+// feature history span plus a day, so messages delayed by up to a day are still served
+// from the store. Idle accounts expire after it.
+const featureRetention = featureHistorySpan + 24*time.Hour
+
+// featureRecordAttempts is how many times Record retries when a concurrent write to the
+// same account gets in first
+const featureRecordAttempts = 5
+
+// FeatureStore is the online feature store: each account's recent activity in Redis,
+// updated incrementally as each transaction is scored, so that computing features does
+// not query Postgres. Activity is pre-aggregated in hourly buckets of counts, sums, sums
+// of squares, location and channel changes, and location, merchant and place sets. A
+// window is read as of any moment in the retention from the whole buckets it covers
+// plus the transactions in the partial buckets at its edges, so features use exactly
+// the transactions before it. Postgres remains the source of truth; an account missing
+// from the store (new, expired or flushed) is rebuilt from its transactions on first read.
+//
+// Each account has four keys:
+//
+//	features:<account>:timeline    sorted set of transaction IDs by created_at
+//	features:<account>:entries     hash of transaction ID to history entry
+//	features:<account>:buckets     sorted set of hourly bucket aggregates by bucket start
+//	features:<account>:rebuilt_at  when the account was rebuilt from Postgres
+type FeatureStore struct {
+	cache  *queue.CacheClient
+	txRepo *repositories.TransactionRepository
+}
+
+// NewFeatureStore creates a new online feature store
+func NewFeatureStore(cache *queue.CacheClient, txRepo *repositories.TransactionRepository) *FeatureStore {
+	return &FeatureStore{
+		cache:  cache,
+		txRepo: txRepo,
+	}
+}
+
+func featureKey(accountID uuid.UUID, kind string) string {
+	return fmt.Sprintf("features:%s:%s", accountID, kind)
+}
+
+func featureKeys(accountID uuid.UUID) []string {
+	return []string{
+		featureKey(accountID, "timeline"),
+		featureKey(accountID, "entries"),
+		featureKey(accountID, "buckets"),
+		featureKey(accountID, "rebuilt_at"),
+	}
+}
+
+func unixScore(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func unixString(t time.Time) string {
+	return strconv.FormatFloat(unixScore(t), 'f', -1, 64)
+}
+
+// Covers reports whether the store still holds the full feature history before the given time
+func (s *FeatureStore) Covers(before time.Time) bool {
+	return before.Add(-featureHistorySpan).After(time.Now().Add(-featureRetention))
+}
+
+// Snapshot returns the account's rolling aggregates as of asOf and its transactions in
+// [asOf - recent, asOf), oldest first. An account missing from the store is first
+// rebuilt from Postgres.
+func (s *FeatureStore) Snapshot(ctx context.Context, accountID uuid.UUID, asOf time.Time, recent time.Duration) (*AccountAggregates, []*models.TransactionHistoryEntry, error) {
+	aggs, entries, built, err := s.read(ctx, accountID, asOf, recent)
+	if err != nil || built {
+		return aggs, entries, err
+	}
+
+	if err := s.Rebuild(ctx, accountID); err != nil {
+		return nil, nil, err
+	}
+
+	aggs, entries, _, err = s.read(ctx, accountID, asOf, recent)
+	return aggs, entries, err
+}
+
+// Aggregates returns the account's rolling aggregates as of asOf
+func (s *FeatureStore) Aggregates(ctx context.Context, accountID uuid.UUID, asOf time.Time) (*AccountAggregates, error) {
+	aggs, _, err := s.Snapshot(ctx, accountID, asOf, 0)
+	return aggs, err
+}
+
+// edgeRanges are the intervals whose transactions are read individually for features as
+// of asOf: the partial buckets at the start of each window and at asOf, and the recent span
+func edgeRanges(asOf time.Time, recent time.Duration) [][2]time.Time {
+	ranges := make([][2]time.Time, 0, len(featureWindows)+2)
+	for _, fw := range featureWindows {
+		from := asOf.Add(-fw.span)
+		ranges = append(ranges, [2]time.Time{from, nextBucketStart(from)})
+	}
+	ranges = append(ranges, [2]time.Time{bucketStart(asOf), asOf})
+	if recent > 0 {
+		ranges = append(ranges, [2]time.Time{asOf.Add(-recent), asOf})
+	}
+	return ranges
+}
+
+func (s *FeatureStore) read(ctx context.Context, accountID uuid.UUID, asOf time.Time, recent time.Duration) (*AccountAggregates, []*models.TransactionHistoryEntry, bool, error) {
+	ranges := edgeRanges(asOf, recent)
+
+	// Transaction scores are float seconds; widen each range by a second and filter on
+	// the exact times. Bucket scores are whole seconds.
+	var (
+		rebuilt *redis.IntCmd
+		buckets *redis.StringSliceCmd
+		ids     = make([]*redis.StringSliceCmd, len(ranges))
+	)
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rebuilt = pipe.Exists(ctx, featureKey(accountID, "rebuilt_at"))
+		buckets = pipe.ZRangeByScore(ctx, featureKey(accountID, "buckets"), &redis.ZRangeBy{
+			Min: strconv.FormatInt(nextBucketStart(asOf.Add(-featureHistorySpan)).Unix(), 10),
+			Max: "(" + strconv.FormatInt(bucketStart(asOf).Unix(), 10),
+		})
+		for i, r := range ranges {
+			ids[i] = pipe.ZRangeByScore(ctx, featureKey(accountID, "timeline"), &redis.ZRangeBy{
+				Min: unixString(r[0].Add(-time.Second)),
+				Max: unixString(r[1].Add(time.Second)),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if rebuilt.Val() == 0 {
+		return nil, nil, false, nil
+	}
+
+	bucketList, err := decodeBuckets(buckets.Val())
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	// Buckets holding a decision made since asOf are re-aggregated from their transactions
+	if unsettled := unsettledRanges(bucketList, asOf); len(unsettled) > 0 {
+		more := make([]*redis.StringSliceCmd, len(unsettled))
+		if _, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, r := range unsettled {
+				more[i] = pipe.ZRangeByScore(ctx, featureKey(accountID, "timeline"), &redis.ZRangeBy{
+					Min: unixString(r[0].Add(-time.Second)),
+					Max: unixString(r[1].Add(time.Second)),
+				})
+			}
+			return nil
+		}); err != nil {
+			return nil, nil, false, err
+		}
+		ids = append(ids, more...)
+	}
+
+	seen := make(map[string]bool)
+	var entryIDs []string
+	for _, cmd := range ids {
+		for _, id := range cmd.Val() {
+			if !seen[id] {
+				seen[id] = true
+				entryIDs = append(entryIDs, id)
+			}
+		}
+	}
+	entries, err := s.entries(ctx, s.cache, accountID, entryIDs)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return aggregateBuckets(accountID, asOf, bucketList, entries), entriesSince(entries, asOf.Add(-recent), asOf), true, nil
+}
+
+// decodeBuckets decodes stored bucket aggregates
+func decodeBuckets(buckets []string) ([]*featureBucket, error) {
+	decoded := make([]*featureBucket, 0, len(buckets))
+	for _, data := range buckets {
+		bucket := &featureBucket{}
+		if err := json.Unmarshal([]byte(data), bucket); err != nil {
+			return nil, fmt.Errorf("invalid feature store bucket: %w", err)
+		}
+		decoded = append(decoded, bucket)
+	}
+	return decoded, nil
+}
+
+// entriesSince returns the entries in [since, before)
+func entriesSince(entries []*models.TransactionHistoryEntry, since, before time.Time) []*models.TransactionHistoryEntry {
+	out := make([]*models.TransactionHistoryEntry, 0)
+	for _, entry := range entries {
+		if !entry.CreatedAt.Before(since) && entry.CreatedAt.Before(before) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// entryReader reads history entries: the cache client, or a transaction watching them
+type entryReader interface {
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+}
+
+// entries fetches and decodes the account's history entries with the given IDs, oldest first
+func (s *FeatureStore) entries(ctx context.Context, reader entryReader, accountID uuid.UUID, ids []string) ([]*models.TransactionHistoryEntry, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var values *redis.SliceCmd
+	if _, err := reader.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		values = pipe.HMGet(ctx, featureKey(accountID, "entries"), ids...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*models.TransactionHistoryEntry, 0, len(ids))
+	for _, value := range values.Val() {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		entry := &models.TransactionHistoryEntry{}
+		if err := json.Unmarshal([]byte(data), entry); err != nil {
+			return nil, fmt.Errorf("invalid feature store entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sortHistory(entries)
+	return entries, nil
+}
+
+// sortHistory orders entries as Postgres does: by created_at, then ID
+func sortHistory(entries []*models.TransactionHistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		}
+		return entries[i].ID.String() < entries[j].ID.String()
+	})
+}
+
+// Record adds a transaction to the account's history and re-aggregates the bucket it
+// falls in: once when it is ingested, with no risk score, and again when it is scored,
+// with its risk score's decision. An entry keeps the first decision it is recorded
+// with, as Postgres history keeps the first risk score's. Record is a no-op for an account that
+// is not in the store yet: its next read rebuilds it from Postgres, which includes the
+// transaction. If the store cannot be updated, the account is dropped from it so that
+// its next read rebuilds it.
+func (s *FeatureStore) Record(ctx context.Context, tx *models.Transaction, score *models.RiskScore) error {
+	entry := historyEntry(tx, score)
+	if entry.CreatedAt.Before(time.Now().Add(-featureRetention)) {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < featureRecordAttempts; attempt++ {
+		err = s.cache.Watch(ctx, func(rtx *redis.Tx) error {
+			return s.record(ctx, rtx, tx.AccountID, entry)
+		}, featureKeys(tx.AccountID)...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+	if err != nil {
+		if delErr := s.cache.Delete(ctx, featureKeys(tx.AccountID)...); delErr != nil {
+			log.Warn().Err(delErr).
+				Str("account_id", tx.AccountID.String()).
+				Msg("Failed to drop account from online feature store")
+		}
+	}
+	return err
+}
+
+func (s *FeatureStore) record(ctx context.Context, rtx *redis.Tx, accountID uuid.UUID, entry *models.TransactionHistoryEntry) error {
+	rebuilt, err := rtx.Exists(ctx, featureKey(accountID, "rebuilt_at")).Result()
+	if err != nil || rebuilt == 0 {
+		return err
+	}
+
+	// The transactions already in the entry's bucket, and those past the retention
+	start := bucketStart(entry.CreatedAt)
+	cutoff := time.Now().Add(-featureRetention)
+	var inBucket, expired *redis.StringSliceCmd
+	if _, err := rtx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		inBucket = pipe.ZRangeByScore(ctx, featureKey(accountID, "timeline"), &redis.ZRangeBy{
+			Min: unixString(start.Add(-time.Second)),
+			Max: unixString(start.Add(featureBucketSpan + time.Second)),
+		})
+		expired = pipe.ZRangeByScore(ctx, featureKey(accountID, "timeline"), &redis.ZRangeBy{
+			Min: "-inf",
+			Max: "(" + unixString(cutoff),
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+	existing, err := s.entries(ctx, rtx, accountID, inBucket.Val())
+	if err != nil {
+		return err
+	}
+	entry, bucket := withEntry(existing, entry)
+
+	_, err = rtx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if err := s.queueEntry(ctx, pipe, accountID, entry); err != nil {
+			return err
+		}
+		if err := s.queueBucket(ctx, pipe, accountID, bucket); err != nil {
+			return err
+		}
+		if len(expired.Val()) > 0 {
+			pipe.HDel(ctx, featureKey(accountID, "entries"), expired.Val()...)
+			pipe.ZRem(ctx, featureKey(accountID, "timeline"), toInterfaces(expired.Val())...)
+		}
+		pipe.ZRemRangeByScore(ctx, featureKey(accountID, "buckets"), "-inf", "("+strconv.FormatInt(cutoff.Unix(), 10))
+		for _, key := range featureKeys(accountID) {
+			pipe.Expire(ctx, key, featureRetention)
+		}
+		return nil
+	})
+	return err
+}
+
+// Rebuild replaces an account's history and buckets with its transactions in Postgres
+// over the retention
+func (s *FeatureStore) Rebuild(ctx context.Context, accountID uuid.UUID) error {
+	now := time.Now()
+	history, err := s.txRepo.GetAccountHistory(ctx, accountID, now.Add(-featureRetention), now)
+	if err != nil {
+		return fmt.Errorf("failed to load account history: %w", err)
+	}
+
+	_, err = s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, featureKeys(accountID)...)
+		for _, entry := range history {
+			if err := s.queueEntry(ctx, pipe, accountID, entry); err != nil {
+				return err
+			}
+		}
+		for _, bucket := range bucketHistory(history) {
+			if err := s.queueBucket(ctx, pipe, accountID, bucket); err != nil {
+				return err
+			}
+		}
+		pipe.Set(ctx, featureKey(accountID, "rebuilt_at"), now.UnixNano(), featureRetention)
+		pipe.Expire(ctx, featureKey(accountID, "timeline"), featureRetention)
+		pipe.Expire(ctx, featureKey(accountID, "entries"), featureRetention)
+		pipe.Expire(ctx, featureKey(accountID, "buckets"), featureRetention)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Debug().
+		Str("account_id", accountID.String()).
+		Int("transactions", len(history)).
+		Msg("Feature store rebuilt from Postgres")
+
+	return nil
+}
+
+func (s *FeatureStore) queueEntry(ctx context.Context, pipe redis.Pipeliner, accountID uuid.UUID, entry *models.TransactionHistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	id := entry.ID.String()
+	pipe.ZAdd(ctx, featureKey(accountID, "timeline"), redis.Z{Score: unixScore(entry.CreatedAt), Member: id})
+	pipe.HSet(ctx, featureKey(accountID, "entries"), id, data)
+	return nil
+}
+
+// queueBucket replaces the aggregates of the bucket starting at bucket.Start
+func (s *FeatureStore) queueBucket(ctx context.Context, pipe redis.Pipeliner, accountID uuid.UUID, bucket *featureBucket) error {
+	data, err := json.Marshal(bucket)
+	if err != nil {
+		return err
+	}
+	start := strconv.FormatInt(bucket.Start.Unix(), 10)
+	pipe.ZRemRangeByScore(ctx, featureKey(accountID, "buckets"), start, start)
+	pipe.ZAdd(ctx, featureKey(accountID, "buckets"), redis.Z{Score: float64(bucket.Start.Unix()), Member: data})
+	return nil
+}
+
+// withEntry adds entry to the entries of its bucket (oldest first), replacing an earlier
+// entry of the same transaction but keeping its decision if it had one, and returns the
+// entry to store with the bucket's new aggregates, counting every decision
+func withEntry(bucketEntries []*models.TransactionHistoryEntry, entry *models.TransactionHistoryEntry) (*models.TransactionHistoryEntry, *featureBucket) {
+	entries := make([]*models.TransactionHistoryEntry, 0, len(bucketEntries)+1)
+	for _, other := range bucketEntries {
+		if other.ID != entry.ID {
+			entries = append(entries, other)
+			continue
+		}
+		if other.Decision != "" {
+			merged := *entry
+			merged.Decision = other.Decision
+			merged.DecidedAt = other.DecidedAt
+			entry = &merged
+		}
+	}
+	entries = append(entries, entry)
+	sortHistory(entries)
+
+	start := bucketStart(entry.CreatedAt)
+	return entry, summarizeEntries(entries, start, start.Add(featureBucketSpan), time.Time{})
+}
+
+// historyEntry is a transaction's history entry, with the decision of its risk score
+// if it has been scored
+func historyEntry(tx *models.Transaction, score *models.RiskScore) *models.TransactionHistoryEntry {
+	entry := &models.TransactionHistoryEntry{
+		ID:        tx.ID,
+		Amount:    tx.Amount,
+		Merchant:  tx.Merchant,
+		Location:  tx.Location,
+		Country:   tx.Country,
+		Channel:   tx.Channel,
+		CreatedAt: tx.CreatedAt,
+	}
+	if score != nil {
+		decidedAt := score.CreatedAt
+		entry.Decision = score.Decision
+		entry.DecidedAt = &decidedAt
+	}
+	return entry
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// accountActivity returns the account's rolling aggregates as of tx and its transactions
+// over the probe window before it: from the online feature store when one is set and
+// still holds the feature history span, otherwise from Postgres
+func (e *ScoringEngine) accountActivity(ctx context.Context, accountID uuid.UUID, tx *models.Transaction) (*AccountAggregates, []*models.TransactionHistoryEntry, error) {
+	recent := min(e.probeThresholds.Window, featureHistorySpan)
+
+	if e.featureStore != nil && e.featureStore.Covers(tx.CreatedAt) {
+		aggs, entries, err := e.featureStore.Snapshot(ctx, accountID, tx.CreatedAt, recent)
+		if err == nil {
+			return aggs, entries, nil
+		}
+		log.Warn().Err(err).
+			Str("account_id", accountID.String()).
+			Msg("Online feature store unavailable, reading account history from Postgres")
+	}
+
+	history, err := e.txRepo.GetAccountHistory(ctx, accountID, tx.CreatedAt.Add(-featureHistorySpan), tx.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aggregateHistory(accountID, history, tx.CreatedAt), history, nil
+}
+
+// recordFeatures records a scored transaction's decision in the account's history in
+// the online feature store
+func (e *ScoringEngine) recordFeatures(ctx context.Context, tx *models.Transaction, score *models.RiskScore) {
+	if e.featureStore == nil {
+		return
+	}
+
+	if err := e.featureStore.Record(ctx, tx, score); err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("account_id", tx.AccountID.String()).
+			Msg("Failed to update online feature store")
+	}
+}
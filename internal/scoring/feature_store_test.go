@@ -0,0 +1,262 @@
+package scoring
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// testFeatureStore keeps an account's feature store state in memory as FeatureStore
+// keeps it in Redis: encoded entries and bucket aggregates, updated with withEntry
+type testFeatureStore struct {
+	entries map[uuid.UUID]string
+	buckets map[int64]string // by bucket start
+}
+
+func newTestFeatureStore() *testFeatureStore {
+	return &testFeatureStore{
+		entries: make(map[uuid.UUID]string),
+		buckets: make(map[int64]string),
+	}
+}
+
+func (s *testFeatureStore) decode(t *testing.T, data string) *models.TransactionHistoryEntry {
+	t.Helper()
+	entry := &models.TransactionHistoryEntry{}
+	if err := json.Unmarshal([]byte(data), entry); err != nil {
+		t.Fatalf("invalid entry: %v", err)
+	}
+	return entry
+}
+
+func (s *testFeatureStore) record(t *testing.T, tx *models.Transaction, score *models.RiskScore) {
+	t.Helper()
+	entry := historyEntry(tx, score)
+
+	var inBucket []*models.TransactionHistoryEntry
+	for _, data := range s.entries {
+		if other := s.decode(t, data); bucketStart(other.CreatedAt).Equal(bucketStart(entry.CreatedAt)) {
+			inBucket = append(inBucket, other)
+		}
+	}
+	sortHistory(inBucket)
+
+	entry, bucket := withEntry(inBucket, entry)
+	entryData, _ := json.Marshal(entry)
+	bucketData, _ := json.Marshal(bucket)
+	s.entries[entry.ID] = string(entryData)
+	s.buckets[bucket.Start.Unix()] = string(bucketData)
+}
+
+// snapshot reads the account as of asOf as FeatureStore.read does
+func (s *testFeatureStore) snapshot(t *testing.T, accountID uuid.UUID, asOf time.Time, recent time.Duration) (*AccountAggregates, []*models.TransactionHistoryEntry) {
+	t.Helper()
+
+	from, to := nextBucketStart(asOf.Add(-featureHistorySpan)).Unix(), bucketStart(asOf).Unix()
+	var starts []int64
+	for start := range s.buckets {
+		if start >= from && start < to {
+			starts = append(starts, start)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	encoded := make([]string, len(starts))
+	for i, start := range starts {
+		encoded[i] = s.buckets[start]
+	}
+	buckets, err := decodeBuckets(encoded)
+	if err != nil {
+		t.Fatalf("decode buckets: %v", err)
+	}
+
+	ranges := append(edgeRanges(asOf, recent), unsettledRanges(buckets, asOf)...)
+	var entries []*models.TransactionHistoryEntry
+	for _, data := range s.entries {
+		entry := s.decode(t, data)
+		for _, r := range ranges {
+			if !entry.CreatedAt.Before(r[0]) && entry.CreatedAt.Before(r[1]) {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	sortHistory(entries)
+
+	return aggregateBuckets(accountID, asOf, buckets, entries), entriesSince(entries, asOf.Add(-recent), asOf)
+}
+
+func testGazetteer() *Gazetteer {
+	coords := func(lat, lon float64) (*float64, *float64) { return &lat, &lon }
+	nyLat, nyLon := coords(40.71, -74.01)
+	ldnLat, ldnLon := coords(51.51, -0.13)
+	parLat, parLon := coords(48.86, 2.35)
+	frLat, frLon := coords(46.6, 2.2)
+
+	g := NewGazetteer(0)
+	g.install([]models.GeoLocation{
+		{CountryCode: "US", CityName: "New York", Latitude: nyLat, Longitude: nyLon, Population: 8000000},
+		{CountryCode: "GB", CityName: "London", Latitude: ldnLat, Longitude: ldnLon, Population: 9000000},
+		{CountryCode: "FR", CityName: "Paris", Latitude: parLat, Longitude: parLon, Population: 2000000},
+		{CountryCode: "FR", Latitude: frLat, Longitude: frLon},
+	})
+	return g
+}
+
+// TestLiveFeaturesMatchBacktest scores an account's transactions as they arrive,
+// reading features from the feature store while earlier transactions are still being
+// scored, fail scoring or are rescored, and checks that replaying each one from its
+// Postgres history computes exactly the same features
+func TestLiveFeaturesMatchBacktest(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	accountID := uuid.New()
+	thresholds := DefaultProbeThresholds
+	gazetteer := testGazetteer()
+	recent := min(thresholds.Window, featureHistorySpan)
+
+	places := []struct{ location, country string }{
+		{"New York", "US"}, {"London", "GB"}, {"Paris", "FR"}, {"", "FR"}, {"Atlantis", ""}, {"", ""},
+	}
+	decisions := []string{models.DecisionApprove, models.DecisionStepUp, models.DecisionReview, models.DecisionDecline}
+
+	type event struct {
+		at    time.Time
+		tx    int
+		score *models.RiskScore // nil for ingestion
+		first bool              // the transaction's first scoring
+	}
+
+	// Transactions at least a minute apart over three days; most are scored within a
+	// second, some only after the next three arrived, some never, and some are rescored
+	at := time.Date(2026, 10, 10, 9, 17, 23, 0, time.UTC)
+	var txs []*models.Transaction
+	var events []event
+	for i := 0; i < 300; i++ {
+		at = at.Add(time.Minute + time.Duration(rng.Int63n(int64(40*time.Minute))))
+		place := places[rng.Intn(len(places))]
+		amount := float64(rng.Intn(200000)) / 100
+		if rng.Intn(4) == 0 {
+			amount = float64(rng.Intn(500)) / 100
+		}
+		txs = append(txs, &models.Transaction{
+			ID:        uuid.New(),
+			AccountID: accountID,
+			Amount:    amount,
+			Merchant:  []string{"", "Acme", "Globex", "Initech"}[rng.Intn(4)],
+			Location:  place.location,
+			Country:   place.country,
+			Channel:   []string{"online", "pos", "atm"}[rng.Intn(3)],
+			CreatedAt: at.Add(time.Duration(rng.Intn(1000)) * time.Microsecond),
+		})
+		events = append(events, event{at: txs[i].CreatedAt, tx: i})
+	}
+	scoreAt := func(at time.Time, decision string) event {
+		return event{at: at, score: &models.RiskScore{Decision: decision, CreatedAt: at}}
+	}
+	for i, tx := range txs {
+		decision := decisions[rng.Intn(len(decisions))]
+		var scores []event
+		switch r := rng.Intn(20); {
+		case r < 2: // never scored
+		case r < 6 && i+3 < len(txs): // scored after the next three
+			scores = append(scores, scoreAt(txs[i+3].CreatedAt.Add(30*time.Second), decision))
+		default:
+			scores = append(scores, scoreAt(tx.CreatedAt.Add(time.Second), decision))
+			if r == 19 {
+				scores = append(scores, scoreAt(tx.CreatedAt.Add(2*time.Second), models.DecisionApprove))
+			}
+		}
+		for j, ev := range scores {
+			ev.tx, ev.first = i, j == 0
+			events = append(events, ev)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	compute := func(tx *models.Transaction, aggs *AccountAggregates, recentEntries []*models.TransactionHistoryEntry) *models.RiskFeatures {
+		features := rollingFeatures(tx, aggs)
+		sequenceFeatures(thresholds, tx, recentEntries, features)
+		travelFeatures(gazetteer, tx, aggs.recentPlaces(), features)
+		return features
+	}
+
+	store := newTestFeatureStore()
+	live := make(map[int]*models.RiskFeatures)
+	firstScore := make(map[int]event)
+	for _, ev := range events {
+		tx := txs[ev.tx]
+		if ev.first {
+			aggs, recentEntries := store.snapshot(t, accountID, tx.CreatedAt, recent)
+			live[ev.tx] = compute(tx, aggs, recentEntries)
+			firstScore[ev.tx] = ev
+		}
+		store.record(t, tx, ev.score)
+	}
+
+	// Postgres history: every transaction, with its first risk score's decision if made
+	// before asOf, as GetAccountHistory returns it
+	historyBefore := func(asOf time.Time) []*models.TransactionHistoryEntry {
+		var history []*models.TransactionHistoryEntry
+		for i, tx := range txs {
+			if tx.CreatedAt.Before(asOf.Add(-featureHistorySpan)) || !tx.CreatedAt.Before(asOf) {
+				continue
+			}
+			var score *models.RiskScore
+			if first, ok := firstScore[i]; ok && first.at.Before(asOf) {
+				score = first.score
+			}
+			history = append(history, historyEntry(tx, score))
+		}
+		return history
+	}
+
+	compared := 0
+	for i, tx := range txs {
+		liveFeatures, ok := live[i]
+		if !ok {
+			continue
+		}
+		history := historyBefore(tx.CreatedAt)
+		backtest := compute(tx, aggregateHistory(accountID, history, tx.CreatedAt), history)
+		if !reflect.DeepEqual(liveFeatures, backtest) {
+			t.Fatalf("transaction %d: live features\n%+v\ndiffer from backtest\n%+v", i, liveFeatures, backtest)
+		}
+		compared++
+	}
+	if compared < 250 {
+		t.Fatalf("compared %d transactions, want at least 250", compared)
+	}
+}
+
+func TestWithEntryKeepsFirstDecision(t *testing.T) {
+	tx := &models.Transaction{
+		ID:        uuid.New(),
+		Amount:    120,
+		Channel:   "online",
+		CreatedAt: time.Date(2026, 10, 10, 9, 30, 0, 0, time.UTC),
+	}
+
+	review := &models.RiskScore{Decision: models.DecisionReview, CreatedAt: tx.CreatedAt.Add(time.Second)}
+	approve := &models.RiskScore{Decision: models.DecisionApprove, CreatedAt: tx.CreatedAt.Add(time.Minute)}
+
+	entry, bucket := withEntry(nil, historyEntry(tx, nil))
+	if entry.Decision != "" || bucket.Count != 1 || bucket.Held != 0 {
+		t.Fatalf("ingested: decision %q, count %d, held %d", entry.Decision, bucket.Count, bucket.Held)
+	}
+
+	entry, bucket = withEntry([]*models.TransactionHistoryEntry{entry}, historyEntry(tx, review))
+	if entry.Decision != models.DecisionReview || bucket.Count != 1 || bucket.Held != 1 {
+		t.Fatalf("scored: decision %q, count %d, held %d", entry.Decision, bucket.Count, bucket.Held)
+	}
+
+	entry, bucket = withEntry([]*models.TransactionHistoryEntry{entry}, historyEntry(tx, approve))
+	if entry.Decision != models.DecisionReview || !entry.DecidedAt.Equal(review.CreatedAt) || bucket.Count != 1 || bucket.Held != 1 {
+		t.Fatalf("rescored: decision %q, count %d, held %d", entry.Decision, bucket.Count, bucket.Held)
+	}
+}
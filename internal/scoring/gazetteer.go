@@ -0,0 +1,211 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// earthRadiusKm is the mean Earth radius used for great-circle distances
+const earthRadiusKm = 6371.0
+
+// minTravelHours is the shortest elapsed time an implied speed is computed over
+const minTravelHours = 1.0 / 60
+
+// GeoLocationSource lists the gazetteer's cities and countries
+type GeoLocationSource interface {
+	GetAll(ctx context.Context) ([]models.GeoLocation, error)
+}
+
+// Gazetteer resolves transaction locations to coordinates and risk levels from the
+// geo_locations reference data, held in memory. It is empty, resolving nothing, until
+// Load succeeds.
+type Gazetteer struct {
+	mu           sync.RWMutex
+	cities       map[string]*models.GeoLocation // country code|city name -> city
+	citiesByName map[string]*models.GeoLocation // city name -> most populous city of that name
+	countries    map[string]*models.GeoLocation // country code -> country centroid
+	countryRisk  map[string]LocationRisk        // country code -> country-level risk
+	reloadPeriod time.Duration
+}
+
+// NewGazetteer creates an empty gazetteer
+func NewGazetteer(reloadPeriod time.Duration) *Gazetteer {
+	return &Gazetteer{
+		cities:       make(map[string]*models.GeoLocation),
+		citiesByName: make(map[string]*models.GeoLocation),
+		countries:    make(map[string]*models.GeoLocation),
+		countryRisk:  make(map[string]LocationRisk),
+		reloadPeriod: reloadPeriod,
+	}
+}
+
+// Load installs the gazetteer from the source. An empty source is rejected, keeping the
+// last gazetteer loaded in place.
+func (g *Gazetteer) Load(ctx context.Context, source GeoLocationSource) error {
+	locations, err := source.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch geo locations: %w", err)
+	}
+	if len(locations) == 0 {
+		return errors.New("no geo locations found")
+	}
+	g.install(locations)
+
+	log.Debug().Int("geo_location_count", len(locations)).Msg("Geo locations loaded from database")
+	return nil
+}
+
+// StartReloader periodically reloads the gazetteer until ctx is cancelled.
+// A failed reload keeps the previously loaded gazetteer in place.
+func (g *Gazetteer) StartReloader(ctx context.Context, source GeoLocationSource) {
+	if g.reloadPeriod <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(g.reloadPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := g.Load(ctx, source); err != nil {
+					log.Error().Err(err).Msg("Failed to reload geo locations")
+				}
+			}
+		}
+	}()
+}
+
+func (g *Gazetteer) install(locations []models.GeoLocation) {
+	cities := make(map[string]*models.GeoLocation)
+	citiesByName := make(map[string]*models.GeoLocation)
+	countries := make(map[string]*models.GeoLocation)
+
+	for i := range locations {
+		loc := &locations[i]
+		country := strings.ToUpper(loc.CountryCode)
+		if loc.CityName == "" {
+			countries[country] = loc
+			continue
+		}
+
+		name := normalizePlace(loc.CityName)
+		cities[country+"|"+name] = loc
+		if existing, ok := citiesByName[name]; !ok || loc.Population > existing.Population {
+			citiesByName[name] = loc
+		}
+	}
+
+	countryRisk := indexCountryRisk(locations)
+
+	g.mu.Lock()
+	g.cities = cities
+	g.citiesByName = citiesByName
+	g.countries = countries
+	g.countryRisk = countryRisk
+	g.mu.Unlock()
+}
+
+// Resolve returns the gazetteer entry of a transaction's location and country. The
+// location is matched as a city ("New York" or "New York, NY") within the country, or
+// by name alone when the country is unknown; an unmatched city falls back to the
+// country's centroid. It returns false when neither resolves.
+func (g *Gazetteer) Resolve(location, country string) (*models.GeoLocation, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	country = normalizeCountry(country)
+	if loc, ok := g.resolveCityLocked(location, country); ok {
+		return loc, true
+	}
+
+	loc, ok := g.countries[country]
+	return loc, ok
+}
+
+func (g *Gazetteer) resolveCityLocked(location, country string) (*models.GeoLocation, bool) {
+	name := normalizePlace(location)
+	if name == "" {
+		return nil, false
+	}
+
+	candidates := []string{name}
+	if i := strings.Index(name, ","); i > 0 {
+		candidates = append(candidates, strings.TrimSpace(name[:i]))
+	}
+	for _, candidate := range candidates {
+		if country != "" {
+			if loc, ok := g.cities[country+"|"+candidate]; ok {
+				return loc, true
+			}
+		} else if loc, ok := g.citiesByName[candidate]; ok {
+			return loc, true
+		}
+	}
+	return nil, false
+}
+
+func hasCoordinates(loc *models.GeoLocation) bool {
+	return loc.Latitude != nil && loc.Longitude != nil
+}
+
+func normalizePlace(place string) string {
+	return strings.Join(strings.Fields(strings.ToLower(place)), " ")
+}
+
+// haversineKm returns the great-circle distance between two points in kilometres
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// travelFeatures sets the distance from the account's previous located transaction
+// (the latest earlier one whose location resolves) and the speed implied by covering
+// it in the time since. Both stay zero when either end cannot be resolved, or when a
+// country centroid would be compared with a place in the same country. places are the
+// account's earlier places, most recently seen first.
+func travelFeatures(gazetteer *Gazetteer, tx *models.Transaction, places []featurePlace, features *models.RiskFeatures) {
+	if gazetteer == nil || len(places) == 0 {
+		return
+	}
+
+	to, ok := gazetteer.Resolve(tx.Location, tx.Country)
+	if !ok || !hasCoordinates(to) {
+		return
+	}
+
+	for _, prev := range places {
+		from, ok := gazetteer.Resolve(prev.Location, prev.Country)
+		if !ok || !hasCoordinates(from) {
+			continue
+		}
+		if (from.CityName == "" || to.CityName == "") && from.CountryCode == to.CountryCode {
+			return
+		}
+
+		distance := haversineKm(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude)
+		features.DistanceFromLastTx = math.Round(distance*10) / 10
+
+		// Floor the elapsed time at a minute so simultaneous transactions give a finite speed
+		hours := math.Max(tx.CreatedAt.Sub(prev.SeenAt).Hours(), minTravelHours)
+		features.ImpliedSpeedKmh = math.Round(distance/hours*10) / 10
+		return
+	}
+}
@@ -0,0 +1,158 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// ErrInvalidLocationRisk is returned when a location risk update fails validation
+var ErrInvalidLocationRisk = errors.New("invalid location risk")
+
+// Location risk levels of geo_locations, lowest first
+const (
+	LocationRiskLow        = "low"
+	LocationRiskMedium     = "medium"
+	LocationRiskHigh       = "high"
+	LocationRiskSanctioned = "sanctioned"
+)
+
+// locationRiskRanks grades the risk levels; an unknown level ranks 0
+var locationRiskRanks = map[string]int{
+	LocationRiskLow:        1,
+	LocationRiskMedium:     2,
+	LocationRiskHigh:       3,
+	LocationRiskSanctioned: 4,
+}
+
+// locationRiskPoints are the behavioral location-risk points of each risk level
+var locationRiskPoints = map[string]float64{
+	LocationRiskMedium:     15,
+	LocationRiskHigh:       50,
+	LocationRiskSanctioned: 100,
+}
+
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// countryAliases maps country codes still sent by clients to their ISO 3166-1 code
+var countryAliases = map[string]string{
+	"NK": "KP", // North Korea, as the former built-in high-risk list had it
+}
+
+// normalizeCountry upper-cases a transaction's country code and resolves its alias
+func normalizeCountry(country string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if alias, ok := countryAliases[country]; ok {
+		return alias
+	}
+	return country
+}
+
+// LocationRisk is the risk of a location from the geo_locations reference data
+type LocationRisk struct {
+	Level      string `json:"level"` // empty when unknown
+	Sanctioned bool   `json:"sanctioned"`
+}
+
+// Rank grades the risk level: 0 unknown, 1 low ... 4 sanctioned
+func (r LocationRisk) Rank() int {
+	return locationRiskRanks[r.Level]
+}
+
+// ValidateLocationRisk checks a country risk update. Failures wrap ErrInvalidLocationRisk.
+func ValidateLocationRisk(loc *models.GeoLocation) error {
+	if !countryCodePattern.MatchString(loc.CountryCode) {
+		return fmt.Errorf("%w: country code must be two uppercase letters, got %q", ErrInvalidLocationRisk, loc.CountryCode)
+	}
+	if _, ok := locationRiskRanks[loc.RiskLevel]; !ok {
+		return fmt.Errorf("%w: risk level must be low, medium, high or sanctioned, got %q", ErrInvalidLocationRisk, loc.RiskLevel)
+	}
+	if loc.RiskLevel == LocationRiskSanctioned && !loc.IsSanctioned {
+		return fmt.Errorf("%w: a sanctioned risk level requires is_sanctioned", ErrInvalidLocationRisk)
+	}
+	return nil
+}
+
+// indexCountryRisk derives each country's risk: its country-level entry when it has
+// one, otherwise the highest risk among its cities
+func indexCountryRisk(locations []models.GeoLocation) map[string]LocationRisk {
+	countryRisk := make(map[string]LocationRisk)
+	fromCountry := make(map[string]bool)
+
+	for i := range locations {
+		loc := &locations[i]
+		country := strings.ToUpper(loc.CountryCode)
+		risk := LocationRisk{Level: loc.RiskLevel, Sanctioned: loc.IsSanctioned}
+
+		if loc.CityName == "" {
+			countryRisk[country] = risk
+			fromCountry[country] = true
+			continue
+		}
+		if fromCountry[country] {
+			continue
+		}
+
+		existing := countryRisk[country]
+		if risk.Rank() > existing.Rank() {
+			existing.Level = risk.Level
+		}
+		existing.Sanctioned = existing.Sanctioned || risk.Sanctioned
+		countryRisk[country] = existing
+	}
+
+	return countryRisk
+}
+
+// LocationRisk returns the risk of a transaction's location and the risk of its country,
+// as the get_location_risk database function does: the matched city's level, otherwise
+// the country's. A country missing from the reference data is medium risk; the risk is
+// unknown when the transaction has no country and its location matches no city, or
+// before the gazetteer is loaded.
+func (g *Gazetteer) LocationRisk(location, country string) (LocationRisk, LocationRisk) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.countryRisk) == 0 {
+		return LocationRisk{}, LocationRisk{}
+	}
+
+	country = normalizeCountry(country)
+	city, cityFound := g.resolveCityLocked(location, country)
+	if country == "" && cityFound {
+		country = strings.ToUpper(city.CountryCode)
+	}
+	if country == "" {
+		return LocationRisk{}, LocationRisk{}
+	}
+
+	countryRisk, ok := g.countryRisk[country]
+	if !ok {
+		countryRisk = LocationRisk{Level: LocationRiskMedium}
+	}
+	if !cityFound {
+		return countryRisk, countryRisk
+	}
+
+	return LocationRisk{
+		Level:      city.RiskLevel,
+		Sanctioned: city.IsSanctioned || countryRisk.Sanctioned,
+	}, countryRisk
+}
+
+// locationRiskFeatures sets the graded location risk of a transaction and whether its
+// country is high risk
+func locationRiskFeatures(gazetteer *Gazetteer, tx *models.Transaction, features *models.RiskFeatures) {
+	if gazetteer == nil {
+		return
+	}
+
+	risk, countryRisk := gazetteer.LocationRisk(tx.Location, tx.Country)
+	features.LocationRiskLevel = risk.Level
+	features.LocationRiskRank = risk.Rank()
+	features.IsSanctioned = risk.Sanctioned || risk.Level == LocationRiskSanctioned
+	features.IsHighRiskCountry = countryRisk.Rank() >= locationRiskRanks[LocationRiskHigh]
+}
@@ -0,0 +1,63 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+type staticGeoLocations struct {
+	locations []models.GeoLocation
+	err       error
+}
+
+func (s *staticGeoLocations) GetAll(ctx context.Context) ([]models.GeoLocation, error) {
+	return s.locations, s.err
+}
+
+func highRiskCountry(g *Gazetteer, country string) bool {
+	features := &models.RiskFeatures{}
+	locationRiskFeatures(g, &models.Transaction{Country: country}, features)
+	return features.IsHighRiskCountry
+}
+
+func TestGazetteerKeepsLastLoadedCountryRisk(t *testing.T) {
+	ctx := context.Background()
+	source := &staticGeoLocations{locations: []models.GeoLocation{
+		{CountryCode: "KP", RiskLevel: LocationRiskSanctioned, IsSanctioned: true},
+		{CountryCode: "VE", RiskLevel: LocationRiskHigh},
+		{CountryCode: "US", RiskLevel: LocationRiskLow},
+	}}
+
+	g := NewGazetteer(0)
+	if risk, _ := g.LocationRisk("", "KP"); risk.Level != "" {
+		t.Fatalf("KP before the gazetteer loads: level %q, want unknown", risk.Level)
+	}
+
+	if err := g.Load(ctx, source); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, country := range []string{"KP", "NK", "ve"} {
+		if !highRiskCountry(g, country) {
+			t.Errorf("%s: not high risk", country)
+		}
+	}
+	if highRiskCountry(g, "US") {
+		t.Error("US: high risk")
+	}
+
+	// A failed or empty reload keeps the countries last loaded
+	source.locations, source.err = nil, errors.New("connection refused")
+	if err := g.Load(ctx, source); err == nil {
+		t.Fatal("failed reload succeeded")
+	}
+	source.err = nil
+	if err := g.Load(ctx, source); err == nil {
+		t.Fatal("loading no geo locations succeeded")
+	}
+	if !highRiskCountry(g, "NK") || !highRiskCountry(g, "VE") {
+		t.Error("country risk lost after a failed reload")
+	}
+}
@@ -0,0 +1,159 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+)
+
+// Merchant reputation smoothing. Each rate is smoothed towards its prior as if the
+// merchant had merchantPriorWeight transactions at the prior rate, so a handful of
+// flagged transactions at a new merchant does not make it look as risky as a merchant
+// that flags at the same rate over thousands.
+const (
+	merchantPriorWeight     = 20.0
+	merchantPriorFlagged    = 0.05
+	merchantPriorBlocked    = 0.01
+	merchantPriorChargeback = 0.002
+
+	// Rates at which each component alone reaches the full score
+	merchantFlaggedCeiling    = 0.25
+	merchantBlockedCeiling    = 0.10
+	merchantChargebackCeiling = 0.02
+
+	// Points added for a merchant first seen today, declining to none at merchantNewPeriod
+	merchantNewPoints = 15.0
+	merchantNewPeriod = 30 * 24 * time.Hour
+)
+
+// MerchantReputation scores merchants from their profiles: the transactions scored at
+// each merchant, how they were decided and how many were charged back
+type MerchantReputation struct {
+	repo *repositories.MerchantProfileRepository
+}
+
+// NewMerchantReputation creates a merchant reputation backed by merchant profiles
+func NewMerchantReputation(repo *repositories.MerchantProfileRepository) *MerchantReputation {
+	return &MerchantReputation{repo: repo}
+}
+
+// MerchantKey is the profile key of a merchant: its normalized name and category. It is
+// empty for a transaction without a merchant.
+func MerchantKey(merchant, category string) string {
+	name := normalizeMerchant(merchant)
+	if name == "" {
+		return ""
+	}
+	return name + "|" + normalizeMerchant(category)
+}
+
+// normalizeMerchant lowercases a merchant name and collapses punctuation and whitespace,
+// so "ACME, Inc." and "acme inc" share a profile
+func normalizeMerchant(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// MerchantRiskScore computes a merchant's 0-100 risk score as of the given time from its
+// profile; a nil profile is a merchant never seen before. An override replaces the
+// computed score.
+func MerchantRiskScore(profile *models.MerchantProfile, asOf time.Time) float64 {
+	if profile != nil && profile.RiskOverride != nil {
+		return *profile.RiskOverride
+	}
+
+	var n, flagged, blocked, chargebacks float64
+	age := time.Duration(0)
+	if profile != nil {
+		n = float64(profile.TransactionCount)
+		flagged = float64(profile.FlaggedCount)
+		blocked = float64(profile.BlockedCount)
+		chargebacks = float64(profile.ChargebackCount)
+		if asOf.After(profile.FirstSeenAt) {
+			age = asOf.Sub(profile.FirstSeenAt)
+		}
+	}
+
+	smoothed := func(count, prior float64) float64 {
+		return (count + merchantPriorWeight*prior) / (n + merchantPriorWeight)
+	}
+	risk := 0.3*smoothed(flagged, merchantPriorFlagged)/merchantFlaggedCeiling +
+		0.3*smoothed(blocked, merchantPriorBlocked)/merchantBlockedCeiling +
+		0.4*smoothed(chargebacks, merchantPriorChargeback)/merchantChargebackCeiling
+	score := 100 * math.Min(risk, 1)
+
+	if age < merchantNewPeriod {
+		score += merchantNewPoints * (1 - float64(age)/float64(merchantNewPeriod))
+	}
+
+	return math.Round(math.Min(score, 100)*100) / 100
+}
+
+// Profile retrieves the profile of a transaction's merchant as it stood when the
+// transaction was made, leaving the transaction itself out; nil when the merchant has
+// none yet
+func (m *MerchantReputation) Profile(ctx context.Context, tx *models.Transaction) (*models.MerchantProfile, error) {
+	key := MerchantKey(tx.Merchant, tx.MerchantCategory)
+	if key == "" {
+		return nil, nil
+	}
+
+	profile, err := m.repo.GetAsOf(ctx, key, tx.CreatedAt, tx.ID)
+	if errors.Is(err, repositories.ErrMerchantProfileNotFound) {
+		return nil, nil
+	}
+	return profile, err
+}
+
+// Record adds a scored transaction to its merchant's profile
+func (m *MerchantReputation) Record(ctx context.Context, tx *models.Transaction, decision string) error {
+	key := MerchantKey(tx.Merchant, tx.MerchantCategory)
+	if key == "" {
+		return nil
+	}
+	return m.repo.RecordTransaction(ctx, key, tx, decision)
+}
+
+// computeMerchantFeatures sets the merchant risk score of a transaction from its
+// merchant's profile as of the transaction, so rescoring it reproduces the live score
+// unless a decision was resolved or the merchant's override changed since.
+func (e *ScoringEngine) computeMerchantFeatures(ctx context.Context, tx *models.Transaction, features *models.RiskFeatures) {
+	if e.merchantReputation == nil || tx.Merchant == "" {
+		return
+	}
+
+	profile, err := e.merchantReputation.Profile(ctx, tx)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("merchant", tx.Merchant).
+			Msg("Failed to fetch merchant profile")
+		return
+	}
+
+	features.MerchantRiskScore = MerchantRiskScore(profile, tx.CreatedAt)
+}
+
+// recordMerchant adds a scored transaction to its merchant's profile
+func (e *ScoringEngine) recordMerchant(ctx context.Context, tx *models.Transaction, decision string) {
+	if e.merchantReputation == nil {
+		return
+	}
+
+	if err := e.merchantReputation.Record(ctx, tx, decision); err != nil {
+		log.Warn().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Str("merchant", tx.Merchant).
+			Msg("Failed to update merchant profile")
+	}
+}
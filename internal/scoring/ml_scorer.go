@@ -81,77 +81,94 @@ func (s *MLScorer) Score(ctx context.Context, features *models.RiskFeatures, tx
 	return result
 }
 
+// BehavioralContribution is the points one detected anomaly added to the behavioral score
+type BehavioralContribution struct {
+	Anomaly string                 `json:"anomaly"`
+	Points  float64                `json:"points"`
+	Inputs  map[string]interface{} `json:"inputs"` // feature values that triggered it
+}
+
 // computeBehavioralScore calculates anomaly score using statistical methods
 func (s *MLScorer) computeBehavioralScore(features *models.RiskFeatures, tx *models.Transaction) (float64, []string) {
 	var totalScore float64
 	var anomalies []string
 
+	for _, c := range behavioralContributions(features, tx) {
+		totalScore += c.Points
+		anomalies = append(anomalies, c.Anomaly)
+	}
+
+	// Normalize to 0-100
+	if totalScore > 100 {
+		totalScore = 100
+	}
+
+	return math.Round(totalScore*100) / 100, anomalies
+}
+
+// behavioralContributions lists each detected anomaly with its points, before the 0-100 cap
+func behavioralContributions(features *models.RiskFeatures, tx *models.Transaction) []BehavioralContribution {
+	var contributions []BehavioralContribution
+	add := func(anomaly AnomalyType, points float64, inputs map[string]interface{}) {
+		contributions = append(contributions, BehavioralContribution{
+			Anomaly: string(anomaly),
+			Points:  points,
+			Inputs:  inputs,
+		})
+	}
+
 	// 1. Spending Z-Score Analysis
 	// If spending is > 2.5 standard deviations from mean, flag it
 	if features.SpendingZScore > 2.5 {
 		score := math.Min(features.SpendingZScore*10, 30) // Cap at 30
-		totalScore += score
-		anomalies = append(anomalies, string(AnomalySpendingSpike))
+		add(AnomalySpendingSpike, score, map[string]interface{}{"spending_z_score": features.SpendingZScore})
 		log.Debug().Float64("z_score", features.SpendingZScore).Msg("Spending spike detected")
 	}
 
 	// 2. Velocity Z-Score Analysis
 	if features.VelocityZScore > 2.0 {
 		score := math.Min(features.VelocityZScore*8, 25)
-		totalScore += score
-		anomalies = append(anomalies, string(AnomalyVelocityBurst))
+		add(AnomalyVelocityBurst, score, map[string]interface{}{"velocity_z_score": features.VelocityZScore})
 	}
 
 	// 3. Peer Group Deviation
 	// Compare against similar accounts (by account type, tenure, etc.)
 	if features.PeerGroupDeviation > 3.0 {
 		score := math.Min(features.PeerGroupDeviation*7, 25)
-		totalScore += score
-		anomalies = append(anomalies, string(AnomalyPeerDeviation))
+		add(AnomalyPeerDeviation, score, map[string]interface{}{"peer_group_deviation": features.PeerGroupDeviation})
 	}
 
 	// 4. Sequence/Exfiltration Pattern Detection
 	// Small probe transaction followed by large transaction
 	if features.FollowsProbePattern {
-		totalScore += 35
-		anomalies = append(anomalies, string(AnomalySequenceExfil))
+		add(AnomalySequenceExfil, 35, map[string]interface{}{"follows_probe_pattern": true})
 	}
 
 	// 5. Impossible Travel Detection
-	// If distance from last transaction is impossible given time elapsed
-	if features.DistanceFromLastTx > 0 && features.TimeSinceLastTx > 0 {
-		// Speed in km/h
-		speed := features.DistanceFromLastTx / features.TimeSinceLastTx
-		if speed > 900 { // Faster than commercial flight
-			totalScore += 30
-			anomalies = append(anomalies, string(AnomalyGeoImpossible))
-		}
+	// If distance from the previous located transaction is impossible given time elapsed
+	if features.ImpliedSpeedKmh > 900 { // Faster than commercial flight
+		add(AnomalyGeoImpossible, 30, map[string]interface{}{
+			"distance_from_last_tx_km": features.DistanceFromLastTx,
+			"implied_speed_kmh":        features.ImpliedSpeedKmh,
+		})
 	}
 
 	// 6. Unusual Time Pattern
 	if features.IsUnusualHour && features.DayOfWeekAnomaly {
-		totalScore += 10
-		anomalies = append(anomalies, string(AnomalyTimePattern))
+		add(AnomalyTimePattern, 10, map[string]interface{}{"is_unusual_hour": true, "day_of_week_anomaly": true})
 	}
 
 	// 7. Rapid Channel Switching
 	if features.ChannelSwitchCount > 3 {
-		totalScore += 15
-		anomalies = append(anomalies, string(AnomalyChannelSwitch))
+		add(AnomalyChannelSwitch, 15, map[string]interface{}{"channel_switch_count": features.ChannelSwitchCount})
 	}
 
 	// 8. New Device + High Value
 	if features.IsNewDevice && tx.Amount > 1000 {
-		totalScore += 20
-		anomalies = append(anomalies, string(AnomalyNewDeviceHighValue))
+		add(AnomalyNewDeviceHighValue, 20, map[string]interface{}{"is_new_device": true, "amount": tx.Amount})
 	}
 
-	// Normalize to 0-100
-	if totalScore > 100 {
-		totalScore = 100
-	}
-
-	return math.Round(totalScore*100) / 100, anomalies
+	return contributions
 }
 
 // computeLightweightMLScore provides a simple ML-like score using ensemble of features
@@ -189,10 +206,12 @@ func (s *MLScorer) computeLightweightMLScore(features *models.RiskFeatures, tx *
 	if features.IsNewLocation {
 		locationRisk += 30
 	}
-	if features.IsHighRiskCountry {
-		locationRisk += 50
+	locationRisk += locationRiskPoints[features.LocationRiskLevel]
+	if features.IsSanctioned {
+		locationRisk = 100
 	}
-	if features.DistanceFromLastTx > 500 && features.TimeSinceLastTx < 2 {
+	// More than 500 km from the previous location in under two hours
+	if features.DistanceFromLastTx > 500 && features.ImpliedSpeedKmh > features.DistanceFromLastTx/2 {
 		locationRisk += 40
 	}
 	score += weights["location_risk"] * math.Min(locationRisk, 100)
@@ -235,11 +254,6 @@ func (s *MLScorer) ComputeEnhancedFeatures(ctx context.Context, accountID uuid.U
 		baseFeatures.VelocityZScore = (float64(baseFeatures.TransactionVelocity1h) - avgVelocity) / stdVelocity
 	}
 
-	// Detect probe pattern (small tx followed by large tx within 10 minutes)
-	if baseFeatures.RecentSmallTxCount > 0 && tx.Amount > 1000 {
-		baseFeatures.FollowsProbePattern = true
-	}
-
 	// Unusual hour detection (based on user's historical pattern)
 	hour := tx.CreatedAt.Hour()
 	baseFeatures.IsUnusualHour = hour >= 0 && hour < 6
@@ -0,0 +1,203 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+)
+
+// peerGroupAllCategories is the merchant category of a peer group's baseline across all
+// categories
+const peerGroupAllCategories = "*"
+
+// PeerGroupSource lists the peer-group baselines
+type PeerGroupSource interface {
+	GetAll(ctx context.Context) ([]models.PeerGroupBaseline, error)
+}
+
+// PeerGroups holds the peer-group spend baselines in memory, so each transaction's
+// deviation from its peers costs a map lookup. It is empty, leaving the peer features
+// unset, until Load succeeds.
+type PeerGroups struct {
+	mu           sync.RWMutex
+	baselines    map[string]*models.PeerGroupBaseline // peerGroupKey -> baseline
+	reloadPeriod time.Duration
+}
+
+// NewPeerGroups creates an empty set of peer-group baselines
+func NewPeerGroups(reloadPeriod time.Duration) *PeerGroups {
+	return &PeerGroups{
+		baselines:    make(map[string]*models.PeerGroupBaseline),
+		reloadPeriod: reloadPeriod,
+	}
+}
+
+func peerGroupKey(accountType, riskProfile, ageBucket, category string) string {
+	return accountType + "|" + riskProfile + "|" + ageBucket + "|" + category
+}
+
+// peerGroupAgeBucket is the age bucket of an account at the time of a transaction, as
+// PeerGroupRepository.Rebuild computes it
+func peerGroupAgeBucket(accountCreatedAt, at time.Time) string {
+	const day = 24 * time.Hour
+	switch age := at.Sub(accountCreatedAt); {
+	case age < 30*day:
+		return "0-30d"
+	case age < 90*day:
+		return "30-90d"
+	case age < 365*day:
+		return "90-365d"
+	default:
+		return "365d+"
+	}
+}
+
+// peerGroupCategory normalizes a merchant category as PeerGroupRepository.Rebuild does
+func peerGroupCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// Load installs the baselines from the source
+func (p *PeerGroups) Load(ctx context.Context, source PeerGroupSource) error {
+	baselines, err := source.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch peer group baselines: %w", err)
+	}
+
+	index := make(map[string]*models.PeerGroupBaseline, len(baselines))
+	for i := range baselines {
+		b := &baselines[i]
+		index[peerGroupKey(b.AccountType, b.RiskProfile, b.AgeBucket, b.MerchantCategory)] = b
+	}
+
+	p.mu.Lock()
+	p.baselines = index
+	p.mu.Unlock()
+
+	log.Debug().Int("peer_group_count", len(baselines)).Msg("Peer group baselines loaded from database")
+	return nil
+}
+
+// StartReloader periodically reloads the baselines until ctx is cancelled.
+// A failed reload keeps the previously loaded baselines in place.
+func (p *PeerGroups) StartReloader(ctx context.Context, source PeerGroupSource) {
+	if p.reloadPeriod <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.reloadPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.Load(ctx, source); err != nil {
+					log.Error().Err(err).Msg("Failed to reload peer group baselines")
+				}
+			}
+		}
+	}()
+}
+
+// StartRebuildJob rebuilds the baselines from the transactions of the trailing window,
+// now and then every period until ctx is cancelled, installing each rebuild locally.
+// Other instances pick it up on their next reload.
+func (p *PeerGroups) StartRebuildJob(ctx context.Context, repo *repositories.PeerGroupRepository, period, window time.Duration, minTransactions int) {
+	if period <= 0 {
+		return
+	}
+
+	rebuild := func() {
+		end := time.Now()
+		count, err := repo.Rebuild(ctx, end.Add(-window), end, minTransactions)
+		if errors.Is(err, repositories.ErrPeerGroupRebuildInProgress) {
+			log.Debug().Msg("Peer group rebuild running elsewhere, skipping")
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to rebuild peer group baselines")
+			return
+		}
+		log.Info().Int64("peer_group_count", count).Dur("window", window).Msg("Peer group baselines rebuilt")
+
+		if err := p.Load(ctx, repo); err != nil {
+			log.Error().Err(err).Msg("Failed to reload peer group baselines after rebuild")
+		}
+	}
+
+	go func() {
+		rebuild()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rebuild()
+			}
+		}
+	}()
+}
+
+// Baseline returns the baseline of a transaction's peer group: the group at its merchant
+// category, or across all categories when that group was too small to keep
+func (p *PeerGroups) Baseline(account *models.Account, tx *models.Transaction) (*models.PeerGroupBaseline, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ageBucket := peerGroupAgeBucket(account.CreatedAt, tx.CreatedAt)
+	if b, ok := p.baselines[peerGroupKey(account.AccountType, account.RiskProfile, ageBucket, peerGroupCategory(tx.MerchantCategory))]; ok {
+		return b, true
+	}
+	b, ok := p.baselines[peerGroupKey(account.AccountType, account.RiskProfile, ageBucket, peerGroupAllCategories)]
+	return b, ok
+}
+
+// peerDeviation is the z-score of an amount's logarithm within its peer group. Spend is
+// roughly log-normal, so a deviation of 3 is the same multiple of typical spend in any
+// group.
+func peerDeviation(amount float64, baseline *models.PeerGroupBaseline) float64 {
+	if amount <= 0 || baseline.StdDevLogAmount <= 0 {
+		return 0
+	}
+	z := (math.Log(amount) - baseline.MeanLogAmount) / baseline.StdDevLogAmount
+	return math.Round(z*100) / 100
+}
+
+// computePeerFeatures sets the average spend of a transaction's peer group and the
+// transaction's deviation from it. The features stay unset without a baseline for the
+// group.
+func (e *ScoringEngine) computePeerFeatures(ctx context.Context, accountID uuid.UUID, tx *models.Transaction, features *models.RiskFeatures) {
+	if e.peerGroups == nil {
+		return
+	}
+
+	account, err := e.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		log.Warn().Err(err).Str("account_id", accountID.String()).Msg("Failed to get account for peer group")
+		return
+	}
+
+	baseline, ok := e.peerGroups.Baseline(account, tx)
+	if !ok {
+		return
+	}
+	features.PeerGroupAvgSpend = baseline.MeanAmount
+	features.PeerGroupDeviation = peerDeviation(tx.Amount, baseline)
+}
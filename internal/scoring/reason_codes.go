@@ -0,0 +1,500 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// ErrInvalidReasonCode is returned when a reason-code catalog entry fails validation
+var ErrInvalidReasonCode = errors.New("invalid reason code")
+
+// FallbackReasonCode covers contributions from rules and anomalies that have no code of their own
+const FallbackReasonCode = "RC99"
+
+// DefaultReasonCodeLimit is the number of reason codes returned with a decision
+const DefaultReasonCodeLimit = 4
+
+var (
+	reasonCodePattern   = regexp.MustCompile(`^[A-Z0-9_]{1,20}$`)
+	languagePattern     = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+	templatePlaceholder = regexp.MustCompile(`\{([a-z_]*)\}`)
+)
+
+// templateFields are the transaction values a message template may reference
+var templateFields = map[string]func(tx *models.Transaction) string{
+	"amount":   func(tx *models.Transaction) string { return fmt.Sprintf("%.2f", tx.Amount) },
+	"currency": func(tx *models.Transaction) string { return tx.Currency },
+	"merchant": func(tx *models.Transaction) string { return tx.Merchant },
+	"country":  func(tx *models.Transaction) string { return tx.Country },
+	"channel":  func(tx *models.Transaction) string { return tx.Channel },
+}
+
+// ReasonCodeSource lists the reason-code catalog
+type ReasonCodeSource interface {
+	GetAll(ctx context.Context) ([]models.ReasonCode, error)
+}
+
+// ReasonCodeCatalog maps fired rules and detected anomalies to customer-facing
+// reason codes and renders their messages. It starts with the built-in catalog
+// until Load succeeds.
+type ReasonCodeCatalog struct {
+	mu           sync.RWMutex
+	codes        map[string]models.ReasonCode
+	byRule       map[string]string // rule ID -> code
+	byAnomaly    map[string]string // anomaly type -> code
+	limit        int
+	language     string // default language of the messages stored with each decision
+	reloadPeriod time.Duration
+}
+
+// NewReasonCodeCatalog creates a catalog returning up to limit codes per decision,
+// rendered in language unless another one is requested
+func NewReasonCodeCatalog(limit int, language string, reloadPeriod time.Duration) *ReasonCodeCatalog {
+	if limit <= 0 {
+		limit = DefaultReasonCodeLimit
+	}
+	language = strings.ToLower(language)
+	if language == "" {
+		language = "en"
+	}
+
+	c := &ReasonCodeCatalog{
+		limit:        limit,
+		language:     language,
+		reloadPeriod: reloadPeriod,
+	}
+	if err := c.install(getDefaultReasonCodes()); err != nil {
+		panic(err) // the built-in catalog is always valid
+	}
+	return c
+}
+
+// Load installs the catalog from the source. An invalid catalog is rejected as a whole.
+func (c *ReasonCodeCatalog) Load(ctx context.Context, source ReasonCodeSource) error {
+	codes, err := source.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch reason codes: %w", err)
+	}
+	if err := c.install(codes); err != nil {
+		return err
+	}
+
+	log.Debug().Int("reason_code_count", len(codes)).Msg("Reason codes loaded from database")
+	return nil
+}
+
+// StartReloader periodically reloads the catalog until ctx is cancelled.
+// A failed reload keeps the previously loaded catalog in place.
+func (c *ReasonCodeCatalog) StartReloader(ctx context.Context, source ReasonCodeSource) {
+	if c.reloadPeriod <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.reloadPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.Load(ctx, source); err != nil {
+					log.Error().Err(err).Msg("Failed to reload reason codes")
+				}
+			}
+		}
+	}()
+}
+
+func (c *ReasonCodeCatalog) install(codes []models.ReasonCode) error {
+	byCode, byRule, byAnomaly, err := indexReasonCodes(codes)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.codes = byCode
+	c.byRule = byRule
+	c.byAnomaly = byAnomaly
+	c.mu.Unlock()
+	return nil
+}
+
+// DefaultLanguage returns the language decisions are stored in
+func (c *ReasonCodeCatalog) DefaultLanguage() string {
+	return c.language
+}
+
+// ValidateReasonCodes checks a whole catalog: every entry must be valid, and each
+// rule and anomaly type may map to only one code. Failures wrap ErrInvalidReasonCode.
+func ValidateReasonCodes(codes []models.ReasonCode) error {
+	_, _, _, err := indexReasonCodes(codes)
+	return err
+}
+
+func indexReasonCodes(codes []models.ReasonCode) (map[string]models.ReasonCode, map[string]string, map[string]string, error) {
+	byCode := make(map[string]models.ReasonCode, len(codes))
+	byRule := make(map[string]string)
+	byAnomaly := make(map[string]string)
+
+	for _, rc := range codes {
+		if err := ValidateReasonCode(&rc); err != nil {
+			return nil, nil, nil, err
+		}
+		if _, dup := byCode[rc.Code]; dup {
+			return nil, nil, nil, fmt.Errorf("%w: duplicate code %s", ErrInvalidReasonCode, rc.Code)
+		}
+		byCode[rc.Code] = rc
+
+		for _, ruleID := range rc.Rules {
+			if other, dup := byRule[ruleID]; dup {
+				return nil, nil, nil, fmt.Errorf("%w: rule %s is mapped to both %s and %s", ErrInvalidReasonCode, ruleID, other, rc.Code)
+			}
+			byRule[ruleID] = rc.Code
+		}
+		for _, anomaly := range rc.Anomalies {
+			if other, dup := byAnomaly[anomaly]; dup {
+				return nil, nil, nil, fmt.Errorf("%w: anomaly %s is mapped to both %s and %s", ErrInvalidReasonCode, anomaly, other, rc.Code)
+			}
+			byAnomaly[anomaly] = rc.Code
+		}
+	}
+
+	return byCode, byRule, byAnomaly, nil
+}
+
+// ValidateReasonCode checks a single catalog entry and normalizes it in place:
+// the code is upper-cased and language keys are lower-cased.
+// Failures wrap ErrInvalidReasonCode.
+func ValidateReasonCode(rc *models.ReasonCode) error {
+	rc.Code = strings.ToUpper(strings.TrimSpace(rc.Code))
+	if !reasonCodePattern.MatchString(rc.Code) {
+		return fmt.Errorf("%w: code %q must be 1-20 characters of A-Z, 0-9 or _", ErrInvalidReasonCode, rc.Code)
+	}
+	if len(rc.Messages) == 0 {
+		return fmt.Errorf("%w: %s has no messages", ErrInvalidReasonCode, rc.Code)
+	}
+
+	messages := make(map[string]string, len(rc.Messages))
+	for language, template := range rc.Messages {
+		language = strings.ToLower(language)
+		if !languagePattern.MatchString(language) {
+			return fmt.Errorf("%w: %s has an invalid language %q (want e.g. en or pt-br)", ErrInvalidReasonCode, rc.Code, language)
+		}
+		if strings.TrimSpace(template) == "" {
+			return fmt.Errorf("%w: %s has an empty %s message", ErrInvalidReasonCode, rc.Code, language)
+		}
+		for _, m := range templatePlaceholder.FindAllStringSubmatch(template, -1) {
+			if _, ok := templateFields[m[1]]; !ok {
+				return fmt.Errorf("%w: %s %s message uses unknown placeholder {%s} (want amount, currency, merchant, country or channel)",
+					ErrInvalidReasonCode, rc.Code, language, m[1])
+			}
+		}
+		messages[language] = template
+	}
+	rc.Messages = messages
+
+	for _, anomaly := range rc.Anomalies {
+		if !knownAnomaly(anomaly) {
+			return fmt.Errorf("%w: %s maps unknown anomaly type %q", ErrInvalidReasonCode, rc.Code, anomaly)
+		}
+	}
+	if rc.Rules == nil {
+		rc.Rules = []string{}
+	}
+	if rc.Anomalies == nil {
+		rc.Anomalies = []string{}
+	}
+	return nil
+}
+
+func knownAnomaly(anomaly string) bool {
+	switch AnomalyType(anomaly) {
+	case AnomalySpendingSpike, AnomalyVelocityBurst, AnomalyGeoImpossible, AnomalyPeerDeviation,
+		AnomalySequenceExfil, AnomalyTimePattern, AnomalyChannelSwitch, AnomalyNewDeviceHighValue:
+		return true
+	}
+	return false
+}
+
+// Rank attributes points of the final score to reason codes, by rule ID and by
+// anomaly type, and returns the top codes in the default language, highest
+// contribution first. Unmapped rules and anomalies count towards FallbackReasonCode.
+func (c *ReasonCodeCatalog) Rank(rulePoints, anomalyPoints map[string]float64, tx *models.Transaction) []models.DecisionReason {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	points := make(map[string]float64)
+	attribute := func(code string, p float64) {
+		if p <= 0 {
+			return
+		}
+		if code == "" {
+			code = FallbackReasonCode
+		}
+		if _, ok := c.codes[code]; ok {
+			points[code] += p
+		}
+	}
+	for ruleID, p := range rulePoints {
+		attribute(c.byRule[ruleID], p)
+	}
+	for anomaly, p := range anomalyPoints {
+		attribute(c.byAnomaly[anomaly], p)
+	}
+
+	reasons := make([]models.DecisionReason, 0, len(points))
+	for code, p := range points {
+		reasons = append(reasons, models.DecisionReason{
+			Code:         code,
+			Contribution: math.Round(p*100) / 100,
+		})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Contribution != reasons[j].Contribution {
+			return reasons[i].Contribution > reasons[j].Contribution
+		}
+		return reasons[i].Code < reasons[j].Code
+	})
+	if len(reasons) > c.limit {
+		reasons = reasons[:c.limit]
+	}
+
+	c.renderLocked(reasons, tx, []string{c.language})
+	return reasons
+}
+
+// Render returns a copy of a decision's reasons with messages in the first of the
+// preferred languages the catalog has (e.g. from Accept-Language), falling back to
+// the base language, the default language and then English.
+func (c *ReasonCodeCatalog) Render(reasons []models.DecisionReason, tx *models.Transaction, preferred []string) []models.DecisionReason {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rendered := make([]models.DecisionReason, len(reasons))
+	copy(rendered, reasons)
+	c.renderLocked(rendered, tx, preferred)
+	return rendered
+}
+
+func (c *ReasonCodeCatalog) renderLocked(reasons []models.DecisionReason, tx *models.Transaction, preferred []string) {
+	for i := range reasons {
+		rc, ok := c.codes[reasons[i].Code]
+		if !ok {
+			continue // removed from the catalog since; keep the stored message
+		}
+		language := c.resolveLanguage(rc, preferred)
+		reasons[i].Language = language
+		reasons[i].Message = renderTemplate(rc.Messages[language], tx)
+	}
+}
+
+func (c *ReasonCodeCatalog) resolveLanguage(rc models.ReasonCode, preferred []string) string {
+	for _, language := range preferred {
+		if _, ok := rc.Messages[language]; ok {
+			return language
+		}
+		if base, _, found := strings.Cut(language, "-"); found {
+			if _, ok := rc.Messages[base]; ok {
+				return base
+			}
+		}
+	}
+	for _, language := range []string{c.language, "en"} {
+		if _, ok := rc.Messages[language]; ok {
+			return language
+		}
+	}
+
+	languages := make([]string, 0, len(rc.Messages))
+	for language := range rc.Messages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages[0]
+}
+
+func renderTemplate(template string, tx *models.Transaction) string {
+	return templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		field, ok := templateFields[placeholder[1:len(placeholder)-1]]
+		if !ok || tx == nil {
+			return placeholder
+		}
+		return field(tx)
+	})
+}
+
+// ParseLanguages turns a lang parameter or Accept-Language header into a list of
+// lower-cased language tags in order of preference
+func ParseLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			fmt.Sscanf(v, "%g", &q)
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	languages := make([]string, len(tags))
+	for i, t := range tags {
+		languages[i] = t.tag
+	}
+	return languages
+}
+
+// getDefaultReasonCodes returns the built-in catalog; it matches the seed in 011_reason_codes.sql
+func getDefaultReasonCodes() []models.ReasonCode {
+	return []models.ReasonCode{
+		{
+			Code:        "RC01",
+			Description: "Amount unusually high for the account",
+			Rules:       []string{"RULE_SPIKE_ANOMALY", "RULE_CRITICAL_AMOUNT"},
+			Anomalies:   []string{string(AnomalySpendingSpike)},
+			Messages: map[string]string{
+				"en": "The amount of {amount} {currency} is unusually high for this account.",
+				"es": "El importe de {amount} {currency} es inusualmente alto para esta cuenta.",
+				"fr": "Le montant de {amount} {currency} est inhabituellement élevé pour ce compte.",
+			},
+		},
+		{
+			Code:        "RC02",
+			Description: "Unusual number of transactions in a short period",
+			Rules:       []string{"RULE_VELOCITY_BURST", "RULE_RAPID_SMALL_TRANSACTIONS"},
+			Anomalies:   []string{string(AnomalyVelocityBurst)},
+			Messages: map[string]string{
+				"en": "An unusual number of transactions was made in a short period.",
+				"es": "Se realizó un número inusual de transacciones en poco tiempo.",
+				"fr": "Un nombre inhabituel de transactions a été effectué en peu de temps.",
+			},
+		},
+		{
+			Code:        "RC03",
+			Description: "Location inconsistent with account history",
+			Rules:       []string{"RULE_NEW_LOCATION_HIGH_AMOUNT", "RULE_LOCATION_HOPPING", "RULE_GEO_IMPOSSIBLE_TRAVEL", "RULE_CROSS_BORDER"},
+			Anomalies:   []string{string(AnomalyGeoImpossible)},
+			Messages: map[string]string{
+				"en": "The transaction location is inconsistent with recent account activity.",
+				"es": "La ubicación de la transacción no coincide con la actividad reciente de la cuenta.",
+				"fr": "Le lieu de la transaction ne correspond pas à l'activité récente du compte.",
+			},
+		},
+		{
+			Code:        "RC04",
+			Description: "Transaction from a restricted country",
+			Rules:       []string{"RULE_HIGH_RISK_COUNTRY"},
+			Messages: map[string]string{
+				"en": "Transactions from {country} are subject to additional restrictions.",
+				"es": "Las transacciones desde {country} están sujetas a restricciones adicionales.",
+				"fr": "Les transactions depuis {country} sont soumises à des restrictions supplémentaires.",
+			},
+		},
+		{
+			Code:        "RC05",
+			Description: "High amount at an unfamiliar merchant",
+			Rules:       []string{"RULE_NEW_MERCHANT_HIGH_AMOUNT"},
+			Messages: map[string]string{
+				"en": "This is an unusually large payment to a merchant not used before.",
+				"es": "Es un pago inusualmente alto a un comercio no utilizado anteriormente.",
+				"fr": "Il s'agit d'un paiement inhabituellement élevé à un commerçant jamais utilisé.",
+			},
+		},
+		{
+			Code:        "RC06",
+			Description: "Activity at an unusual time",
+			Rules:       []string{"RULE_NIGHT_TRANSACTION"},
+			Anomalies:   []string{string(AnomalyTimePattern)},
+			Messages: map[string]string{
+				"en": "The transaction was made at an unusual time for this account.",
+				"es": "La transacción se realizó a una hora inusual para esta cuenta.",
+				"fr": "La transaction a été effectuée à une heure inhabituelle pour ce compte.",
+			},
+		},
+		{
+			Code:        "RC07",
+			Description: "Unrecognized device",
+			Rules:       []string{"RULE_RAPID_DEVICE_SWITCH"},
+			Anomalies:   []string{string(AnomalyNewDeviceHighValue)},
+			Messages: map[string]string{
+				"en": "The transaction was made from a device not previously used with this account.",
+				"es": "La transacción se realizó desde un dispositivo no utilizado antes con esta cuenta.",
+				"fr": "La transaction a été effectuée depuis un appareil jamais utilisé avec ce compte.",
+			},
+		},
+		{
+			Code:        "RC08",
+			Description: "Spending pattern differs from account or peer history",
+			Rules:       []string{"RULE_PEER_GROUP_ANOMALY", "RULE_BEHAVIORAL_ANOMALY"},
+			Anomalies:   []string{string(AnomalyPeerDeviation)},
+			Messages: map[string]string{
+				"en": "The spending pattern differs from the usual activity of this account.",
+				"es": "El patrón de gasto difiere de la actividad habitual de esta cuenta.",
+				"fr": "Les habitudes de dépense diffèrent de l'activité habituelle de ce compte.",
+			},
+		},
+		{
+			Code:        "RC09",
+			Description: "Small test payments followed by a large payment",
+			Rules:       []string{"RULE_SEQUENCE_EXFIL_PATTERN"},
+			Anomalies:   []string{string(AnomalySequenceExfil)},
+			Messages: map[string]string{
+				"en": "Small test payments were followed by a large payment.",
+				"es": "Se realizaron pequeños pagos de prueba seguidos de un pago grande.",
+				"fr": "De petits paiements de test ont été suivis d'un paiement important.",
+			},
+		},
+		{
+			Code:        "RC10",
+			Description: "Payee linked to suspicious activity",
+			Rules:       []string{"RULE_SHARED_BENEFICIARY_NETWORK"},
+			Messages: map[string]string{
+				"en": "The payee is associated with suspicious activity.",
+				"es": "El beneficiario está asociado a actividad sospechosa.",
+				"fr": "Le bénéficiaire est associé à une activité suspecte.",
+			},
+		},
+		{
+			Code:        "RC11",
+			Description: "Rapid switching between payment channels",
+			Rules:       []string{"RULE_RAPID_CHANNEL_SWITCH"},
+			Anomalies:   []string{string(AnomalyChannelSwitch)},
+			Messages: map[string]string{
+				"en": "Payment channels were switched rapidly before this {channel} transaction.",
+				"es": "Se cambió rápidamente de canal de pago antes de esta transacción {channel}.",
+				"fr": "Les canaux de paiement ont changé rapidement avant cette transaction {channel}.",
+			},
+		},
+		{
+			Code:        FallbackReasonCode,
+			Description: "Fallback for rules and anomalies without a code",
+			Messages: map[string]string{
+				"en": "The transaction did not pass our risk checks.",
+				"es": "La transacción no superó nuestros controles de riesgo.",
+				"fr": "La transaction n'a pas passé nos contrôles de risque.",
+			},
+		},
+	}
+}
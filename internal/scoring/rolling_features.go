@@ -0,0 +1,501 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// featureWindow is a rolling window of account activity
+type featureWindow struct {
+	name string
+	span time.Duration
+}
+
+// featureWindows are the rolling windows of the account features
+var featureWindows = []featureWindow{
+	{name: "1h", span: time.Hour},
+	{name: "24h", span: 24 * time.Hour},
+	{name: "7d", span: 7 * 24 * time.Hour},
+	{name: "30d", span: 30 * 24 * time.Hour},
+}
+
+// featureHistorySpan is how far back rolling features look: the longest window
+const featureHistorySpan = 30 * 24 * time.Hour
+
+// heldDecisions are the decisions that did not simply approve the transaction
+var heldDecisions = map[string]bool{
+	models.DecisionStepUp:  true,
+	models.DecisionReview:  true,
+	models.DecisionDecline: true,
+}
+
+// featureBucketSpan is the span of the buckets an account's transactions are
+// pre-aggregated in. A window is aggregated from the whole buckets it covers and the
+// transactions of the partial buckets at its edges.
+const featureBucketSpan = time.Hour
+
+// featurePlace is where an account last transacted from a location and country
+type featurePlace struct {
+	Location string    `json:"location,omitempty"`
+	Country  string    `json:"country,omitempty"`
+	SeenAt   time.Time `json:"seen_at"`
+	ID       uuid.UUID `json:"id"` // of the transaction, ordering places seen at the same time
+}
+
+// featureBucket summarizes an account's transactions from Start: a whole bucket of
+// featureBucketSpan, or the part of one at a window edge
+type featureBucket struct {
+	Start           time.Time            `json:"start"`
+	Count           int                  `json:"count"`
+	Sum             float64              `json:"sum"`
+	SumSquares      float64              `json:"sum_squares"`
+	Held            int                  `json:"held,omitempty"`
+	LocationChanges int                  `json:"location_changes,omitempty"`
+	ChannelSwitches int                  `json:"channel_switches,omitempty"`
+	FirstLocation   string               `json:"first_location,omitempty"`
+	LastLocation    string               `json:"last_location,omitempty"`
+	FirstChannel    string               `json:"first_channel,omitempty"`
+	LastChannel     string               `json:"last_channel,omitempty"`
+	LastTxAt        time.Time            `json:"last_transaction_at"`
+	LastDecidedAt   time.Time            `json:"last_decided_at,omitempty"` // latest decision counted in Held
+	Locations       map[string]time.Time `json:"locations,omitempty"`       // when each was last seen
+	Merchants       map[string]time.Time `json:"merchants,omitempty"`       // when each was last seen
+	Places          []featurePlace       `json:"places,omitempty"`
+}
+
+func newFeatureBucket(start time.Time) *featureBucket {
+	return &featureBucket{
+		Start:     start,
+		Locations: make(map[string]time.Time),
+		Merchants: make(map[string]time.Time),
+	}
+}
+
+// bucketStart returns the start of the bucket containing t
+func bucketStart(t time.Time) time.Time {
+	return t.Truncate(featureBucketSpan)
+}
+
+// nextBucketStart returns the start of the first bucket starting at or after t
+func nextBucketStart(t time.Time) time.Time {
+	start := bucketStart(t)
+	if start.Before(t) {
+		return start.Add(featureBucketSpan)
+	}
+	return start
+}
+
+// settled reports whether every decision counted in the bucket was made before asOf
+func (b *featureBucket) settled(asOf time.Time) bool {
+	return b.LastDecidedAt.Before(asOf)
+}
+
+// heldBefore reports whether a transaction was held by a decision made before asOf, or
+// at all when asOf is zero. Entries recorded without a decision time count as decided.
+func heldBefore(entry *models.TransactionHistoryEntry, asOf time.Time) bool {
+	if !heldDecisions[entry.Decision] {
+		return false
+	}
+	return asOf.IsZero() || entry.DecidedAt == nil || entry.DecidedAt.Before(asOf)
+}
+
+// add counts a transaction, held or not; transactions must be added oldest first
+func (b *featureBucket) add(entry *models.TransactionHistoryEntry, held bool) {
+	b.Count++
+	b.Sum += entry.Amount
+	b.SumSquares += entry.Amount * entry.Amount
+	if held {
+		b.Held++
+		if entry.DecidedAt != nil && entry.DecidedAt.After(b.LastDecidedAt) {
+			b.LastDecidedAt = *entry.DecidedAt
+		}
+	}
+
+	if entry.Location != "" {
+		if b.LastLocation != "" && b.LastLocation != entry.Location {
+			b.LocationChanges++
+		}
+		if b.FirstLocation == "" {
+			b.FirstLocation = entry.Location
+		}
+		b.LastLocation = entry.Location
+		b.Locations[entry.Location] = entry.CreatedAt
+	}
+	if entry.Merchant != "" {
+		b.Merchants[entry.Merchant] = entry.CreatedAt
+	}
+	if entry.Channel != "" {
+		if b.LastChannel != "" && b.LastChannel != entry.Channel {
+			b.ChannelSwitches++
+		}
+		if b.FirstChannel == "" {
+			b.FirstChannel = entry.Channel
+		}
+		b.LastChannel = entry.Channel
+	}
+	if entry.Location != "" || entry.Country != "" {
+		b.seenAt(featurePlace{Location: entry.Location, Country: entry.Country, SeenAt: entry.CreatedAt, ID: entry.ID})
+	}
+	b.LastTxAt = entry.CreatedAt
+}
+
+func (b *featureBucket) seenAt(place featurePlace) {
+	for i := range b.Places {
+		if b.Places[i].Location == place.Location && b.Places[i].Country == place.Country {
+			b.Places[i] = place
+			return
+		}
+	}
+	b.Places = append(b.Places, place)
+}
+
+// summarizeEntries summarizes the transactions in [from, to) of entries (oldest first),
+// counting those held by decisions made before decidedBefore (all when it is zero), or
+// returns nil when there are none
+func summarizeEntries(entries []*models.TransactionHistoryEntry, from, to, decidedBefore time.Time) *featureBucket {
+	var bucket *featureBucket
+	for _, entry := range entries {
+		if entry.CreatedAt.Before(from) || !entry.CreatedAt.Before(to) {
+			continue
+		}
+		if bucket == nil {
+			bucket = newFeatureBucket(from)
+		}
+		bucket.add(entry, heldBefore(entry, decidedBefore))
+	}
+	return bucket
+}
+
+// bucketHistory summarizes an account's history (oldest first) in whole buckets, oldest
+// first, counting every decision in it
+func bucketHistory(history []*models.TransactionHistoryEntry) []*featureBucket {
+	var buckets []*featureBucket
+	for _, entry := range history {
+		start := bucketStart(entry.CreatedAt)
+		if n := len(buckets); n == 0 || !buckets[n-1].Start.Equal(start) {
+			buckets = append(buckets, newFeatureBucket(start))
+		}
+		buckets[len(buckets)-1].add(entry, heldBefore(entry, time.Time{}))
+	}
+	return buckets
+}
+
+// unsettledRanges are the spans of the buckets that count a decision made at or after
+// asOf. Their transactions must be read individually to aggregate them as of asOf.
+func unsettledRanges(buckets []*featureBucket, asOf time.Time) [][2]time.Time {
+	var ranges [][2]time.Time
+	for _, b := range buckets {
+		if !b.settled(asOf) {
+			ranges = append(ranges, [2]time.Time{b.Start, b.Start.Add(featureBucketSpan)})
+		}
+	}
+	return ranges
+}
+
+// windowSegments splits [from, to) into summaries as of to, oldest first: the
+// transactions before the first whole bucket, the whole buckets (of buckets, oldest
+// first) and the transactions after the last whole bucket. A bucket counting a decision
+// made at or after to is summarized from its transactions instead, without it.
+func windowSegments(from, to time.Time, buckets []*featureBucket, entries []*models.TransactionHistoryEntry) []*featureBucket {
+	head := nextBucketStart(from)
+	if head.After(to) {
+		head = to
+	}
+	tail := bucketStart(to)
+	if tail.Before(head) {
+		tail = head
+	}
+
+	var segments []*featureBucket
+	if b := summarizeEntries(entries, from, head, to); b != nil {
+		segments = append(segments, b)
+	}
+	for _, b := range buckets {
+		if b.Start.Before(head) || !b.Start.Before(tail) {
+			continue
+		}
+		segment := b
+		if !b.settled(to) {
+			segment = summarizeEntries(entries, b.Start, b.Start.Add(featureBucketSpan), to)
+		}
+		if segment != nil {
+			segments = append(segments, segment)
+		}
+	}
+	if b := summarizeEntries(entries, tail, to, to); b != nil {
+		segments = append(segments, b)
+	}
+	return segments
+}
+
+// WindowStats are an account's aggregates over one rolling window
+type WindowStats struct {
+	Count           int     `json:"count"`
+	Sum             float64 `json:"sum"`
+	SumSquares      float64 `json:"sum_squares"`
+	Held            int     `json:"held"` // scored step-up, review or decline
+	LocationChanges int     `json:"location_changes"`
+	ChannelSwitches int     `json:"channel_switches"`
+	UniqueLocations int     `json:"unique_locations"`
+	UniqueMerchants int     `json:"unique_merchants"`
+
+	lastLocation string
+	lastChannel  string
+	locations    map[string]bool
+	merchants    map[string]bool
+}
+
+func newWindowStats() *WindowStats {
+	return &WindowStats{
+		locations: make(map[string]bool),
+		merchants: make(map[string]bool),
+	}
+}
+
+// add counts a transaction; transactions must be added oldest first
+func (w *WindowStats) add(amount float64, location, merchant, channel string, held bool) {
+	w.Count++
+	w.Sum += amount
+	w.SumSquares += amount * amount
+	if held {
+		w.Held++
+	}
+
+	if location != "" {
+		if w.lastLocation != "" && w.lastLocation != location {
+			w.LocationChanges++
+		}
+		w.lastLocation = location
+		if !w.locations[location] {
+			w.locations[location] = true
+			w.UniqueLocations++
+		}
+	}
+	if merchant != "" && !w.merchants[merchant] {
+		w.merchants[merchant] = true
+		w.UniqueMerchants++
+	}
+	if channel != "" {
+		if w.lastChannel != "" && w.lastChannel != channel {
+			w.ChannelSwitches++
+		}
+		w.lastChannel = channel
+	}
+}
+
+// merge counts a bucket's transactions; buckets must be merged oldest first
+func (w *WindowStats) merge(b *featureBucket) {
+	w.Count += b.Count
+	w.Sum += b.Sum
+	w.SumSquares += b.SumSquares
+	w.Held += b.Held
+
+	if b.FirstLocation != "" {
+		if w.lastLocation != "" && w.lastLocation != b.FirstLocation {
+			w.LocationChanges++
+		}
+		w.lastLocation = b.LastLocation
+	}
+	w.LocationChanges += b.LocationChanges
+	if b.FirstChannel != "" {
+		if w.lastChannel != "" && w.lastChannel != b.FirstChannel {
+			w.ChannelSwitches++
+		}
+		w.lastChannel = b.LastChannel
+	}
+	w.ChannelSwitches += b.ChannelSwitches
+
+	for location := range b.Locations {
+		if !w.locations[location] {
+			w.locations[location] = true
+			w.UniqueLocations++
+		}
+	}
+	for merchant := range b.Merchants {
+		if !w.merchants[merchant] {
+			w.merchants[merchant] = true
+			w.UniqueMerchants++
+		}
+	}
+}
+
+// Mean returns the average transaction amount
+func (w *WindowStats) Mean() float64 {
+	if w.Count == 0 {
+		return 0
+	}
+	return w.Sum / float64(w.Count)
+}
+
+// StdDev returns the sample standard deviation of transaction amounts, as Postgres STDDEV does
+func (w *WindowStats) StdDev() float64 {
+	if w.Count < 2 {
+		return 0
+	}
+	n := float64(w.Count)
+	variance := (w.SumSquares - w.Sum*w.Sum/n) / (n - 1)
+	if variance <= 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// AccountAggregates are an account's rolling aggregates as of a point in time, over its
+// transactions strictly before it
+type AccountAggregates struct {
+	AccountID    uuid.UUID               `json:"account_id"`
+	AsOf         time.Time               `json:"as_of"`
+	Windows      map[string]*WindowStats `json:"windows"` // keyed by window name (1h, 24h, 7d, 30d)
+	LastLocation string                  `json:"last_location,omitempty"`
+	LastChannel  string                  `json:"last_channel,omitempty"`
+	LastTxAt     *time.Time              `json:"last_transaction_at,omitempty"`
+
+	// When each location, merchant and place was last seen
+	locations map[string]time.Time
+	merchants map[string]time.Time
+	places    map[featurePlace]featurePlace // keyed by location and country only
+}
+
+// aggregateBuckets aggregates an account's activity as of asOf from its whole buckets
+// (oldest first) and its transactions (oldest first) in the partial buckets at the
+// window edges and in unsettled buckets. Only transactions in
+// [asOf - featureHistorySpan, asOf) count, and only decisions made before asOf hold
+// them. The online feature store and Postgres both aggregate this way, in the same
+// order, so their aggregates agree exactly.
+func aggregateBuckets(accountID uuid.UUID, asOf time.Time, buckets []*featureBucket, entries []*models.TransactionHistoryEntry) *AccountAggregates {
+	aggs := &AccountAggregates{
+		AccountID: accountID,
+		AsOf:      asOf,
+		Windows:   make(map[string]*WindowStats, len(featureWindows)),
+		locations: make(map[string]time.Time),
+		merchants: make(map[string]time.Time),
+		places:    make(map[featurePlace]featurePlace),
+	}
+
+	for _, fw := range featureWindows {
+		w := newWindowStats()
+		for _, segment := range windowSegments(asOf.Add(-fw.span), asOf, buckets, entries) {
+			w.merge(segment)
+		}
+		aggs.Windows[fw.name] = w
+	}
+
+	// Later segments are newer, so each overwrites what earlier ones saw
+	for _, segment := range windowSegments(asOf.Add(-featureHistorySpan), asOf, buckets, entries) {
+		if segment.LastLocation != "" {
+			aggs.LastLocation = segment.LastLocation
+		}
+		if segment.LastChannel != "" {
+			aggs.LastChannel = segment.LastChannel
+		}
+		lastTxAt := segment.LastTxAt
+		aggs.LastTxAt = &lastTxAt
+
+		for location, at := range segment.Locations {
+			aggs.locations[location] = at
+		}
+		for merchant, at := range segment.Merchants {
+			aggs.merchants[merchant] = at
+		}
+		for _, place := range segment.Places {
+			aggs.places[featurePlace{Location: place.Location, Country: place.Country}] = place
+		}
+	}
+
+	return aggs
+}
+
+// aggregateHistory aggregates an account's history (oldest first) as of asOf. Only
+// transactions in [asOf - featureHistorySpan, asOf) count.
+func aggregateHistory(accountID uuid.UUID, history []*models.TransactionHistoryEntry, asOf time.Time) *AccountAggregates {
+	return aggregateBuckets(accountID, asOf, bucketHistory(history), history)
+}
+
+// Window returns the aggregates of the named window
+func (a *AccountAggregates) Window(name string) *WindowStats {
+	if w, ok := a.Windows[name]; ok {
+		return w
+	}
+	return newWindowStats()
+}
+
+// SeenLocation reports whether the account transacted from location since the given time
+func (a *AccountAggregates) SeenLocation(location string, since time.Time) bool {
+	at, ok := a.locations[location]
+	return ok && !at.Before(since)
+}
+
+// SeenMerchant reports whether the account transacted with merchant since the given time
+func (a *AccountAggregates) SeenMerchant(merchant string, since time.Time) bool {
+	at, ok := a.merchants[merchant]
+	return ok && !at.Before(since)
+}
+
+// recentPlaces returns the places the account transacted from, most recently seen first
+func (a *AccountAggregates) recentPlaces() []featurePlace {
+	places := make([]featurePlace, 0, len(a.places))
+	for _, place := range a.places {
+		places = append(places, place)
+	}
+	sort.Slice(places, func(i, j int) bool {
+		if !places[i].SeenAt.Equal(places[j].SeenAt) {
+			return places[i].SeenAt.After(places[j].SeenAt)
+		}
+		return places[i].ID.String() > places[j].ID.String()
+	})
+	return places
+}
+
+// rollingFeatures computes a transaction's rolling account features from the account's
+// aggregates as of its own CreatedAt, over its strictly earlier transactions, so a
+// replay, backtest or delayed message sees exactly what live scoring saw. Window counts
+// and amounts include the transaction itself.
+func rollingFeatures(tx *models.Transaction, aggs *AccountAggregates) *models.RiskFeatures {
+	features := &models.RiskFeatures{}
+
+	// Novelty and recency against the earlier transactions only
+	since7d := tx.CreatedAt.Add(-7 * 24 * time.Hour)
+	if tx.Location != "" {
+		features.IsNewLocation = !aggs.SeenLocation(tx.Location, since7d)
+	}
+	if tx.Merchant != "" {
+		features.IsNewMerchant = !aggs.SeenMerchant(tx.Merchant, since7d)
+	}
+	if aggs.LastTxAt != nil && aggs.LastTxAt.After(tx.CreatedAt.Add(-24*time.Hour)) {
+		features.TimeSinceLastTx = tx.CreatedAt.Sub(*aggs.LastTxAt).Hours()
+	}
+
+	for _, w := range aggs.Windows {
+		w.add(tx.Amount, tx.Location, tx.Merchant, tx.Channel, false)
+	}
+	w1h, w24h, w7d, w30d := aggs.Window("1h"), aggs.Window("24h"), aggs.Window("7d"), aggs.Window("30d")
+
+	// Spending patterns
+	features.RollingAvgSpend7d = w7d.Mean()
+	features.RollingAvgSpend30d = w30d.Mean()
+	features.RollingStdDev30d = w30d.StdDev()
+	if features.RollingStdDev30d > 0 {
+		features.AmountDeviation = (tx.Amount - features.RollingAvgSpend30d) / features.RollingStdDev30d
+	}
+
+	// Velocity
+	features.TransactionVelocity1h = w1h.Count
+	features.TransactionVelocity24h = w24h.Count
+
+	// Location and channel changes
+	features.UniqueLocations7d = w7d.UniqueLocations
+	features.LocationChangeCount = w7d.LocationChanges
+	features.ChannelSwitchCount = w7d.ChannelSwitches
+
+	// Anomaly ratio (held or declined transactions / total)
+	if w7d.Count > 0 {
+		features.AnomalyRatio = float64(w7d.Held) / float64(w7d.Count)
+	}
+
+	return features
+}
@@ -3,7 +3,10 @@ package scoring
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,66 +19,438 @@ import (
 type RuleEngine struct {
 	mu           sync.RWMutex
 	rules        []DBRule
+	version      int // published rule-set version of rules (0 = built-in defaults)
+	aggregation  models.ScoreAggregation
 	lastReload   time.Time
 	reloadPeriod time.Duration
 }
 
+// RuleResult is the outcome of evaluating the installed rule set
+type RuleResult struct {
+	Score           float64
+	Triggered       []string
+	ShadowTriggered []string // shadow rules that fired; they never add to Score
+	RuleSetVersion  int
+	Aggregation     string // strategy that combined the triggered rules into Score
+
+	// Impacts is the impact each triggered rule counted with; rules superseded
+	// by a stronger rule of their group count 0
+	Impacts map[string]float64
+}
+
 // DBRule represents a rule loaded from the database
 type DBRule struct {
-	ID          string          `json:"id"`
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Condition   RuleCondition   `json:"condition"`
-	ScoreImpact float64         `json:"score_impact"`
-	RiskLevel   string          `json:"risk_level"`
-	Priority    int             `json:"priority"`
-	Enabled     bool            `json:"enabled"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Condition   RuleCondition `json:"condition"`
+	ScoreImpact float64       `json:"score_impact"`
+	RiskLevel   string        `json:"risk_level"`
+	Priority    int           `json:"priority"`
+	Enabled     bool          `json:"enabled"`
+	Mode        string        `json:"mode"`            // live (default) or shadow
+	Group       string        `json:"group,omitempty"` // triggered rules in a group contribute at most once
+
+	EffectiveFrom  *time.Time           `json:"effective_from,omitempty"`
+	EffectiveUntil *time.Time           `json:"effective_until,omitempty"`
+	Schedule       *models.RuleSchedule `json:"schedule,omitempty"`
+
+	window *ruleWindow // set when the rule is loaded or validated
 }
 
 // RuleCondition represents a rule condition
 type RuleCondition struct {
-	Type       string          `json:"type"`       // threshold, compound, time_range
-	Field      string          `json:"field"`      // field to check
-	Operator   string          `json:"operator"`   // >, <, =, >=, <=, !=, AND, OR
-	Value      interface{}     `json:"value"`      // value to compare
-	Conditions []RuleCondition `json:"conditions"` // for compound rules
-	Start      int             `json:"start"`      // for time_range
-	End        int             `json:"end"`        // for time_range
+	Type       string          `json:"type"`                 // threshold, compound, time_range, expression, window_aggregate
+	Field      string          `json:"field,omitempty"`      // field to check
+	Operator   string          `json:"operator,omitempty"`   // >, <, =, >=, <=, !=, AND, OR
+	Value      interface{}     `json:"value,omitempty"`      // value to compare
+	Conditions []RuleCondition `json:"conditions,omitempty"` // for compound rules
+	Start      int             `json:"start,omitempty"`      // for time_range
+	End        int             `json:"end,omitempty"`        // for time_range
+	Expression string          `json:"expression,omitempty"` // for expression (see expression.go)
+	Aggregate  string          `json:"aggregate,omitempty"`  // for window_aggregate: count, sum, distinct
+	Window     string          `json:"window,omitempty"`     // for window_aggregate, e.g. "30m", "24h", "7d"
+	Filter     string          `json:"filter,omitempty"`     // for window_aggregate: expression over transaction fields
+
+	compiled  *compiledExpression // set when the condition is loaded or validated
+	aggregate *windowAggregate    // set when the condition is loaded or validated
 }
 
-// RuleRepository interface for fetching rules
+// ErrInvalidRule is returned when a rule fails validation
+var ErrInvalidRule = errors.New("invalid rule")
+
+// RuleRepository interface for fetching the published rule set
 type RuleRepository interface {
-	GetEnabledRules(ctx context.Context) ([]models.Rule, error)
+	GetActiveRuleSet(ctx context.Context) (*models.RuleSetVersion, error)
 }
 
-// NewRuleEngine creates a new rule engine
+// NewRuleEngine creates a new rule engine.
+// It starts with the built-in default rules until LoadRulesFromDB succeeds.
 func NewRuleEngine(reloadPeriod time.Duration) *RuleEngine {
 	return &RuleEngine{
-		rules:        make([]DBRule, 0),
+		rules:        getDefaultDBRules(),
+		aggregation:  DefaultAggregation,
 		reloadPeriod: reloadPeriod,
 	}
 }
 
-// LoadRulesFromDB loads rules from the database
-func (re *RuleEngine) LoadRulesFromDB(ctx context.Context, db interface{ Query(context.Context, string, ...interface{}) (interface{ Close(); Next() bool; Scan(...interface{}) error }, error) }) error {
-	// Query for loading rules from database
-	// In production, this would execute:
-	// SELECT id, name, description, condition, score_impact, risk_level, priority, enabled
-	// FROM rules WHERE enabled = true ORDER BY priority ASC
+// LoadRulesFromDB installs the enabled rules of the latest published rule-set version.
+// Rows with a malformed condition are rejected and logged; the remaining rules
+// are still installed so one bad edit cannot disable the whole rule set.
+func (re *RuleEngine) LoadRulesFromDB(ctx context.Context, repo RuleRepository) error {
+	ruleSet, err := repo.GetActiveRuleSet(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch rules: %w", err)
+	}
 
-	// This is a simplified version - in production, use proper repository
-	re.mu.Lock()
-	defer re.mu.Unlock()
+	re.mu.RLock()
+	unchanged := re.version == ruleSet.Version
+	re.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
 
-	// For now, we'll use the hardcoded rules as default
-	// In production, this would query the database
-	re.rules = getDefaultDBRules()
+	rules := make([]DBRule, 0, len(ruleSet.Rules))
+	rejected := 0
+	for _, r := range ruleSet.Rules {
+		if !r.Enabled {
+			continue
+		}
+		rule, err := parseDBRule(r)
+		if err != nil {
+			rejected++
+			log.Error().Err(err).Str("rule_id", r.ID).Msg("Rejected malformed rule")
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	aggregation := ruleSet.Aggregation
+	if err := ValidateAggregation(&aggregation); err != nil {
+		log.Error().Err(err).Int("rule_set_version", ruleSet.Version).Msg("Rejected score aggregation, using sum_capped")
+		aggregation = DefaultAggregation
+	}
+
+	re.mu.Lock()
+	re.rules = rules
+	re.version = ruleSet.Version
+	re.aggregation = aggregation
 	re.lastReload = time.Now()
+	re.mu.Unlock()
+
+	log.Info().
+		Int("rule_set_version", ruleSet.Version).
+		Int("rule_count", len(rules)).
+		Int("rejected_count", rejected).
+		Str("aggregation", aggregation.Strategy).
+		Msg("Rules loaded from database")
+	return nil
+}
+
+// StartReloader periodically reloads rules from the repository until ctx is cancelled.
+// A failed reload keeps the previously loaded rules in place.
+func (re *RuleEngine) StartReloader(ctx context.Context, repo RuleRepository) {
+	if re.reloadPeriod <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(re.reloadPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := re.LoadRulesFromDB(ctx, repo); err != nil {
+					log.Error().Err(err).Msg("Failed to reload rules")
+				}
+			}
+		}
+	}()
+}
+
+// Version returns the rule-set version currently installed (0 = built-in defaults)
+func (re *RuleEngine) Version() int {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+	return re.version
+}
+
+// Aggregation returns the score aggregation of the installed rule set
+func (re *RuleEngine) Aggregation() models.ScoreAggregation {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+	return re.aggregation
+}
+
+// LastReload returns the time rules were last loaded from the database
+func (re *RuleEngine) LastReload() time.Time {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+	return re.lastReload
+}
+
+// parseDBRule converts a rules table row into a DBRule, parsing and validating its condition
+func parseDBRule(r models.Rule) (DBRule, error) {
+	var cond RuleCondition
+	if err := json.Unmarshal(r.Condition, &cond); err != nil {
+		return DBRule{}, fmt.Errorf("rule %s: invalid condition JSON: %w", r.ID, err)
+	}
+
+	normalizeCondition(&cond)
+	if err := validateCondition(cond, "condition"); err != nil {
+		return DBRule{}, fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+	attachCompiledExpressions(&cond)
+
+	window, err := newRuleWindow(r.EffectiveFrom, r.EffectiveUntil, r.Schedule)
+	if err != nil {
+		return DBRule{}, fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+
+	return DBRule{
+		ID:             r.ID,
+		Name:           r.Name,
+		Description:    r.Description,
+		Condition:      cond,
+		ScoreImpact:    r.ScoreImpact,
+		RiskLevel:      r.RiskLevel,
+		Priority:       r.Priority,
+		Enabled:        r.Enabled,
+		Mode:           r.Mode,
+		Group:          r.Group,
+		EffectiveFrom:  r.EffectiveFrom,
+		EffectiveUntil: r.EffectiveUntil,
+		Schedule:       r.Schedule,
+		window:         window,
+	}, nil
+}
+
+// ValidateRule checks a rule before it is saved: its metadata, effective window and schedule
+// must be well formed and its condition tree may only use known fields, known operators and
+// values of the field's type. The condition, mode and schedule days are normalized in place.
+// Failures wrap ErrInvalidRule.
+func ValidateRule(rule *DBRule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidRule)
+	}
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	switch rule.RiskLevel {
+	case models.RiskLevelLow, models.RiskLevelMedium, models.RiskLevelHigh, models.RiskLevelCritical:
+	default:
+		return fmt.Errorf("%w: unknown risk_level %q", ErrInvalidRule, rule.RiskLevel)
+	}
+	if rule.ScoreImpact < 0 || rule.ScoreImpact > 100 {
+		return fmt.Errorf("%w: score_impact must be between 0 and 100", ErrInvalidRule)
+	}
+	if len(rule.Group) > 100 {
+		return fmt.Errorf("%w: group must be at most 100 characters", ErrInvalidRule)
+	}
+	switch rule.Mode {
+	case "":
+		rule.Mode = models.RuleModeLive
+	case models.RuleModeLive, models.RuleModeShadow:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidRule, rule.Mode)
+	}
 
-	log.Info().Int("rule_count", len(re.rules)).Msg("Rules loaded from database")
+	window, err := newRuleWindow(rule.EffectiveFrom, rule.EffectiveUntil, rule.Schedule)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	rule.window = window
+
+	normalizeCondition(&rule.Condition)
+	if err := validateCondition(rule.Condition, "condition"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	attachCompiledExpressions(&rule.Condition)
 	return nil
 }
 
+// normalizeCondition fills in the implied "threshold" type for leaf conditions
+// that only specify a field, as the seeded compound rules do.
+func normalizeCondition(cond *RuleCondition) {
+	if cond.Type == "" && cond.Field != "" {
+		cond.Type = "threshold"
+	}
+	for i := range cond.Conditions {
+		normalizeCondition(&cond.Conditions[i])
+	}
+}
+
+// validateCondition checks that a condition tree only uses supported types, fields and operators
+func validateCondition(cond RuleCondition, path string) error {
+	switch cond.Type {
+	case "threshold":
+		if _, ok := ruleFields[cond.Field]; !ok {
+			return fmt.Errorf("%s: unknown field %q", path, cond.Field)
+		}
+		if !thresholdOperators[cond.Operator] {
+			return fmt.Errorf("%s: unknown operator %q", path, cond.Operator)
+		}
+		if cond.Value == nil {
+			return fmt.Errorf("%s: missing value", path)
+		}
+		if err := validateThresholdValue(cond); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case "compound":
+		if cond.Operator != "AND" && cond.Operator != "OR" {
+			return fmt.Errorf("%s: compound operator must be AND or OR, got %q", path, cond.Operator)
+		}
+		if len(cond.Conditions) == 0 {
+			return fmt.Errorf("%s: compound condition has no sub-conditions", path)
+		}
+		for i, sub := range cond.Conditions {
+			if err := validateCondition(sub, fmt.Sprintf("%s.conditions[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "time_range":
+		if cond.Start < 0 || cond.Start > 24 || cond.End < 0 || cond.End > 24 {
+			return fmt.Errorf("%s: time_range hours must be between 0 and 24", path)
+		}
+	case "expression":
+		if strings.TrimSpace(cond.Expression) == "" {
+			return fmt.Errorf("%s: missing expression", path)
+		}
+		if _, err := compileExpression(cond.Expression); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case "window_aggregate":
+		if _, err := newWindowAggregate(cond); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("%s: unknown condition type %q", path, cond.Type)
+	}
+	return nil
+}
+
+// attachCompiledExpressions stores the compiled form of every validated expression
+// and window aggregate in the tree
+func attachCompiledExpressions(cond *RuleCondition) {
+	switch cond.Type {
+	case "expression":
+		cond.compiled, _ = compileExpression(cond.Expression)
+	case "window_aggregate":
+		cond.aggregate, _ = newWindowAggregate(*cond)
+	}
+	for i := range cond.Conditions {
+		attachCompiledExpressions(&cond.Conditions[i])
+	}
+}
+
+// thresholdOperators lists the comparison operators supported by threshold conditions
+var thresholdOperators = map[string]bool{
+	">": true, "<": true, ">=": true, "<=": true, "=": true, "==": true, "!=": true,
+}
+
+// equalityOperators are the only threshold operators allowed on bool and string fields
+var equalityOperators = map[string]bool{"=": true, "==": true, "!=": true}
+
+// validateThresholdValue checks that a threshold's operator and value fit the type of its field
+func validateThresholdValue(cond RuleCondition) error {
+	switch ruleFields[cond.Field] {
+	case fieldNumber:
+		if _, ok := toFloat64(cond.Value); !ok {
+			return fmt.Errorf("field %q expects a number, got %T", cond.Field, cond.Value)
+		}
+	case fieldBool:
+		if !equalityOperators[cond.Operator] {
+			return fmt.Errorf("operator %q is not supported on bool field %q", cond.Operator, cond.Field)
+		}
+		if _, ok := cond.Value.(bool); !ok {
+			return fmt.Errorf("field %q expects a bool, got %T", cond.Field, cond.Value)
+		}
+	case fieldString:
+		if !equalityOperators[cond.Operator] {
+			return fmt.Errorf("operator %q is not supported on string field %q", cond.Operator, cond.Field)
+		}
+		if _, ok := cond.Value.(string); !ok {
+			return fmt.Errorf("field %q expects a string, got %T", cond.Field, cond.Value)
+		}
+	}
+	return nil
+}
+
+// fieldType describes the kind of value a rule field resolves to
+type fieldType string
+
+const (
+	fieldNumber fieldType = "number"
+	fieldBool   fieldType = "bool"
+	fieldString fieldType = "string"
+)
+
+// ruleFields lists the fields threshold conditions and expressions may reference (see getFieldValue).
+// Names match the JSON tags of models.RiskFeatures plus a few transaction attributes.
+var ruleFields = map[string]fieldType{
+	"amount":            fieldNumber,
+	"hour":              fieldNumber,
+	"channel":           fieldString,
+	"country":           fieldString,
+	"merchant_category": fieldString,
+	"merchant":          fieldString,
+	"location":          fieldString,
+	"implied_speed_kmh": fieldNumber,
+
+	"rolling_avg_spend_7d":      fieldNumber,
+	"rolling_avg_spend_30d":     fieldNumber,
+	"rolling_std_dev_30d":       fieldNumber,
+	"spending_z_score":          fieldNumber,
+	"transaction_velocity_1h":   fieldNumber,
+	"transaction_velocity_24h":  fieldNumber,
+	"velocity_z_score":          fieldNumber,
+	"unique_locations_7d":       fieldNumber,
+	"location_change_count":     fieldNumber,
+	"is_new_location":           fieldBool,
+	"is_high_risk_country":      fieldBool,
+	"location_risk_level":       fieldString,
+	"location_risk_rank":        fieldNumber,
+	"is_sanctioned":             fieldBool,
+	"distance_from_last_tx_km":  fieldNumber,
+	"is_new_merchant":           fieldBool,
+	"merchant_risk_score":       fieldNumber,
+	"time_since_last_tx_hours":  fieldNumber,
+	"is_unusual_hour":           fieldBool,
+	"day_of_week_anomaly":       fieldBool,
+	"amount_deviation":          fieldNumber,
+	"anomaly_ratio":             fieldNumber,
+	"behavioral_anomaly_score":  fieldNumber,
+	"recent_small_tx_count":     fieldNumber,
+	"probe_merchant_count":      fieldNumber,
+	"probe_same_merchant":       fieldBool,
+	"minutes_since_last_probe":  fieldNumber,
+	"follows_probe_pattern":     fieldBool,
+	"shared_beneficiary_count":  fieldNumber,
+	"counterparty_count_24h":    fieldNumber,
+	"flagged_beneficiary_count": fieldNumber,
+	"peer_group_avg_spend":      fieldNumber,
+	"peer_group_deviation":      fieldNumber,
+	"is_new_device":             fieldBool,
+	"device_age_days":           fieldNumber,
+	"account_device_count":      fieldNumber,
+	"channel_switch_count":      fieldNumber,
+}
+
+// transactionFields lists the fields window_aggregate filters and fields may reference.
+// They are resolved from each historical transaction alone, without its features.
+var transactionFields = map[string]fieldType{
+	"amount":            fieldNumber,
+	"hour":              fieldNumber,
+	"channel":           fieldString,
+	"country":           fieldString,
+	"merchant_category": fieldString,
+	"merchant":          fieldString,
+	"location":          fieldString,
+}
+
 // getDefaultDBRules returns the default rules in DB format
 func getDefaultDBRules() []DBRule {
 	return []DBRule{
@@ -220,64 +595,223 @@ func getDefaultDBRules() []DBRule {
 			Priority:    60,
 			Enabled:     true,
 		},
+		// Modern fraud pattern rules
+		{
+			ID:          "RULE_SEQUENCE_EXFIL_PATTERN",
+			Name:        "Sequence Exfiltration Pattern",
+			Description: "Small probe transactions followed by a large transaction",
+			Condition: RuleCondition{
+				Type:     "compound",
+				Operator: "AND",
+				Conditions: []RuleCondition{
+					{Type: "threshold", Field: "follows_probe_pattern", Operator: "=", Value: true},
+					{Type: "threshold", Field: "amount", Operator: ">", Value: float64(1000)},
+				},
+			},
+			ScoreImpact: 35.0,
+			RiskLevel:   models.RiskLevelHigh,
+			Priority:    8,
+			Enabled:     true,
+		},
+		{
+			ID:          "RULE_PEER_GROUP_ANOMALY",
+			Name:        "Peer Group Anomaly",
+			Description: "Spend deviates more than 3 standard deviations from similar accounts",
+			Condition: RuleCondition{
+				Type:     "threshold",
+				Field:    "peer_group_deviation",
+				Operator: ">",
+				Value:    float64(3.0),
+			},
+			ScoreImpact: 25.0,
+			RiskLevel:   models.RiskLevelMedium,
+			Priority:    22,
+			Enabled:     true,
+		},
+		{
+			ID:          "RULE_SHARED_BENEFICIARY_NETWORK",
+			Name:        "Shared Beneficiary Network",
+			Description: "Multiple accounts sending to the same beneficiary (mule account pattern)",
+			Condition: RuleCondition{
+				Type:     "threshold",
+				Field:    "shared_beneficiary_count",
+				Operator: ">",
+				Value:    float64(3),
+			},
+			ScoreImpact: 30.0,
+			RiskLevel:   models.RiskLevelHigh,
+			Priority:    12,
+			Enabled:     true,
+		},
+		{
+			ID:          "RULE_RAPID_DEVICE_SWITCH",
+			Name:        "Rapid Device Switch",
+			Description: "High-value transaction from a new device",
+			Condition: RuleCondition{
+				Type:     "compound",
+				Operator: "AND",
+				Conditions: []RuleCondition{
+					{Type: "threshold", Field: "is_new_device", Operator: "=", Value: true},
+					{Type: "threshold", Field: "amount", Operator: ">", Value: float64(500)},
+				},
+			},
+			ScoreImpact: 25.0,
+			RiskLevel:   models.RiskLevelMedium,
+			Priority:    18,
+			Enabled:     true,
+		},
+		{
+			ID:          "RULE_GEO_IMPOSSIBLE_TRAVEL",
+			Name:        "Impossible Travel",
+			Description: "Location unreachable since the previous transaction (faster than 900 km/h)",
+			Condition: RuleCondition{
+				Type:     "threshold",
+				Field:    "implied_speed_kmh",
+				Operator: ">",
+				Value:    float64(900),
+			},
+			ScoreImpact: 40.0,
+			RiskLevel:   models.RiskLevelCritical,
+			Priority:    3,
+			Enabled:     true,
+		},
+		{
+			ID:          "RULE_RAPID_CHANNEL_SWITCH",
+			Name:        "Rapid Channel Switching",
+			Description: "Switching between online, POS and ATM rapidly",
+			Condition: RuleCondition{
+				Type:     "threshold",
+				Field:    "channel_switch_count",
+				Operator: ">",
+				Value:    float64(3),
+			},
+			ScoreImpact: 15.0,
+			RiskLevel:   models.RiskLevelMedium,
+			Priority:    45,
+			Enabled:     true,
+		},
+		{
+			ID:          "RULE_BEHAVIORAL_ANOMALY",
+			Name:        "Behavioral Pattern Anomaly",
+			Description: "Composite behavioral anomaly score exceeds threshold",
+			Condition: RuleCondition{
+				Type:     "threshold",
+				Field:    "behavioral_anomaly_score",
+				Operator: ">",
+				Value:    float64(50),
+			},
+			ScoreImpact: 20.0,
+			RiskLevel:   models.RiskLevelMedium,
+			Priority:    35,
+			Enabled:     true,
+		},
 	}
 }
 
 // Evaluate evaluates all rules against features and transaction
-func (re *RuleEngine) Evaluate(features *models.RiskFeatures, tx *models.Transaction) (float64, []string) {
+func (re *RuleEngine) Evaluate(features *models.RiskFeatures, tx *models.Transaction) RuleResult {
+	return re.evaluate(features, tx, nil, nil)
+}
+
+// EvaluateSubset evaluates only the given live rule IDs (used by A/B experiments).
+// An empty list evaluates every rule. Shadow rules are always evaluated.
+// A nil aggregation uses the installed rule set's strategy.
+func (re *RuleEngine) EvaluateSubset(features *models.RiskFeatures, tx *models.Transaction, ruleIDs []string, aggregation *models.ScoreAggregation) RuleResult {
+	if len(ruleIDs) == 0 {
+		return re.evaluate(features, tx, nil, aggregation)
+	}
+
+	allowed := make(map[string]bool, len(ruleIDs))
+	for _, id := range ruleIDs {
+		allowed[id] = true
+	}
+	return re.evaluate(features, tx, allowed, aggregation)
+}
+
+func (re *RuleEngine) evaluate(features *models.RiskFeatures, tx *models.Transaction, allowed map[string]bool, aggregation *models.ScoreAggregation) RuleResult {
 	re.mu.RLock()
 	defer re.mu.RUnlock()
 
-	var totalScore float64
+	agg := re.aggregation
+	if aggregation != nil {
+		agg = *aggregation
+	}
+
+	var impacts []float64
+	groupImpacts := make(map[string]float64) // strongest triggered impact per rule group
+	groupWinners := make(map[string]string)  // rule that set the group's impact
+	counted := make(map[string]float64)
 	var triggeredRules []string
+	var shadowTriggered []string
 
 	// Build evaluation context
 	ctx := buildEvaluationContext(features, tx)
 
 	for _, rule := range re.rules {
-		if !rule.Enabled {
+		if !rule.Enabled || !rule.window.activeAt(tx.CreatedAt) {
+			continue
+		}
+
+		// Shadow rules are recorded on all traffic but never score
+		if rule.Mode == models.RuleModeShadow {
+			if re.evaluateCondition(rule.Condition, ctx) {
+				shadowTriggered = append(shadowTriggered, rule.ID)
+			}
+			continue
+		}
+
+		if allowed != nil && !allowed[rule.ID] {
 			continue
 		}
 
 		if re.evaluateCondition(rule.Condition, ctx) {
-			totalScore += rule.ScoreImpact
 			triggeredRules = append(triggeredRules, rule.ID)
+			counted[rule.ID] = 0
+			if rule.Group == "" {
+				impacts = append(impacts, rule.ScoreImpact)
+				counted[rule.ID] = rule.ScoreImpact
+			} else if rule.ScoreImpact > groupImpacts[rule.Group] {
+				groupImpacts[rule.Group] = rule.ScoreImpact
+				groupWinners[rule.Group] = rule.ID
+			}
 		}
 	}
 
-	// Cap at 100
-	if totalScore > 100 {
-		totalScore = 100
+	for group, impact := range groupImpacts {
+		impacts = append(impacts, impact)
+		counted[groupWinners[group]] = impact
 	}
+	totalScore := combineScores(impacts, agg)
 
-	return totalScore, triggeredRules
+	return RuleResult{
+		Score:           math.Round(totalScore*100) / 100,
+		Triggered:       triggeredRules,
+		ShadowTriggered: shadowTriggered,
+		RuleSetVersion:  re.version,
+		Aggregation:     agg.Strategy,
+		Impacts:         counted,
+	}
 }
 
 // evaluationContext holds all values for rule evaluation
 type evaluationContext struct {
-	Amount               float64
-	AmountDeviation      float64
-	TransactionVelocity1h int
-	TransactionVelocity24h int
-	LocationChangeCount  int
-	IsNewLocation        bool
-	IsNewMerchant        bool
-	IsHighRiskCountry    bool
-	Hour                 int
+	Features *models.RiskFeatures
+	Tx       *models.Transaction
+	Hour     int
+
+	// ImpliedSpeedKmh is the travel speed needed to reach this transaction's
+	// location from the previous located one (0 when either location is unknown)
+	ImpliedSpeedKmh float64
 }
 
 func buildEvaluationContext(features *models.RiskFeatures, tx *models.Transaction) evaluationContext {
-	return evaluationContext{
-		Amount:               tx.Amount,
-		AmountDeviation:      features.AmountDeviation,
-		TransactionVelocity1h: features.TransactionVelocity1h,
-		TransactionVelocity24h: features.TransactionVelocity24h,
-		LocationChangeCount:  features.LocationChangeCount,
-		IsNewLocation:        features.IsNewLocation,
-		IsNewMerchant:        features.IsNewMerchant,
-		IsHighRiskCountry:    features.IsHighRiskCountry,
-		Hour:                 tx.CreatedAt.Hour(),
+	ctx := evaluationContext{
+		Features: features,
+		Tx:       tx,
+		Hour:     tx.CreatedAt.Hour(),
 	}
+	ctx.ImpliedSpeedKmh = features.ImpliedSpeedKmh
+	return ctx
 }
 
 func (re *RuleEngine) evaluateCondition(cond RuleCondition, ctx evaluationContext) bool {
@@ -288,16 +822,29 @@ func (re *RuleEngine) evaluateCondition(cond RuleCondition, ctx evaluationContex
 		return re.evaluateCompound(cond, ctx)
 	case "time_range":
 		return re.evaluateTimeRange(cond, ctx)
+	case "expression":
+		compiled := cond.compiled
+		if compiled == nil {
+			var err error
+			if compiled, err = compileExpression(cond.Expression); err != nil {
+				return false
+			}
+		}
+		return compiled.Evaluate(ctx)
+	case "window_aggregate":
+		return re.evaluateWindowAggregate(cond, ctx)
 	default:
 		return false
 	}
 }
 
 func (re *RuleEngine) evaluateThreshold(cond RuleCondition, ctx evaluationContext) bool {
-	fieldValue := re.getFieldValue(cond.Field, ctx)
-	condValue := cond.Value
+	return compareValues(cond.Operator, getFieldValue(cond.Field, ctx), cond.Value)
+}
 
-	switch cond.Operator {
+// compareValues applies a threshold operator to a field value and a condition value
+func compareValues(operator string, fieldValue, condValue interface{}) bool {
+	switch operator {
 	case ">":
 		return compareFloat(fieldValue, condValue, func(a, b float64) bool { return a > b })
 	case "<":
@@ -345,26 +892,118 @@ func (re *RuleEngine) evaluateTimeRange(cond RuleCondition, ctx evaluationContex
 	return hour >= cond.Start && hour < cond.End
 }
 
-func (re *RuleEngine) getFieldValue(field string, ctx evaluationContext) interface{} {
+func getFieldValue(field string, ctx evaluationContext) interface{} {
+	f := ctx.Features
 	switch field {
+	// Transaction attributes
 	case "amount":
-		return ctx.Amount
-	case "amount_deviation":
-		return ctx.AmountDeviation
+		return ctx.Tx.Amount
+	case "hour":
+		return float64(ctx.Hour)
+	case "channel":
+		return ctx.Tx.Channel
+	case "country":
+		return ctx.Tx.Country
+	case "merchant_category":
+		return ctx.Tx.MerchantCategory
+	case "merchant":
+		return ctx.Tx.Merchant
+	case "location":
+		return ctx.Tx.Location
+	case "implied_speed_kmh":
+		return ctx.ImpliedSpeedKmh
+
+	// Spending patterns
+	case "rolling_avg_spend_7d":
+		return f.RollingAvgSpend7d
+	case "rolling_avg_spend_30d":
+		return f.RollingAvgSpend30d
+	case "rolling_std_dev_30d":
+		return f.RollingStdDev30d
+	case "spending_z_score":
+		return f.SpendingZScore
+
+	// Velocity metrics
 	case "transaction_velocity_1h":
-		return float64(ctx.TransactionVelocity1h)
+		return float64(f.TransactionVelocity1h)
 	case "transaction_velocity_24h":
-		return float64(ctx.TransactionVelocity24h)
+		return float64(f.TransactionVelocity24h)
+	case "velocity_z_score":
+		return f.VelocityZScore
+
+	// Location patterns
+	case "unique_locations_7d":
+		return float64(f.UniqueLocations7d)
 	case "location_change_count":
-		return float64(ctx.LocationChangeCount)
+		return float64(f.LocationChangeCount)
 	case "is_new_location":
-		return ctx.IsNewLocation
-	case "is_new_merchant":
-		return ctx.IsNewMerchant
+		return f.IsNewLocation
 	case "is_high_risk_country":
-		return ctx.IsHighRiskCountry
-	case "hour":
-		return float64(ctx.Hour)
+		return f.IsHighRiskCountry
+	case "location_risk_level":
+		return f.LocationRiskLevel
+	case "location_risk_rank":
+		return float64(f.LocationRiskRank)
+	case "is_sanctioned":
+		return f.IsSanctioned
+	case "distance_from_last_tx_km":
+		return f.DistanceFromLastTx
+
+	// Merchant patterns
+	case "is_new_merchant":
+		return f.IsNewMerchant
+	case "merchant_risk_score":
+		return f.MerchantRiskScore
+
+	// Temporal patterns
+	case "time_since_last_tx_hours":
+		return f.TimeSinceLastTx
+	case "is_unusual_hour":
+		return f.IsUnusualHour
+	case "day_of_week_anomaly":
+		return f.DayOfWeekAnomaly
+
+	// Behavioral anomalies
+	case "amount_deviation":
+		return f.AmountDeviation
+	case "anomaly_ratio":
+		return f.AnomalyRatio
+	case "behavioral_anomaly_score":
+		return f.BehavioralAnomalyScore
+
+	// Sequence patterns
+	case "recent_small_tx_count":
+		return float64(f.RecentSmallTxCount)
+	case "probe_merchant_count":
+		return float64(f.ProbeMerchantCount)
+	case "probe_same_merchant":
+		return f.ProbeSameMerchant
+	case "minutes_since_last_probe":
+		return f.MinutesSinceLastProbe
+	case "follows_probe_pattern":
+		return f.FollowsProbePattern
+	case "shared_beneficiary_count":
+		return float64(f.SharedBeneficiaryCount)
+	case "counterparty_count_24h":
+		return float64(f.CounterpartyCount24h)
+	case "flagged_beneficiary_count":
+		return float64(f.FlaggedBeneficiaryCount)
+
+	// Peer group comparison
+	case "peer_group_avg_spend":
+		return f.PeerGroupAvgSpend
+	case "peer_group_deviation":
+		return f.PeerGroupDeviation
+
+	// Device/channel patterns
+	case "is_new_device":
+		return f.IsNewDevice
+	case "device_age_days":
+		return f.DeviceAgeDays
+	case "account_device_count":
+		return float64(f.AccountDeviceCount)
+	case "channel_switch_count":
+		return float64(f.ChannelSwitchCount)
 	default:
 		return nil
 	}
@@ -420,7 +1059,7 @@ func toFloat64(v interface{}) (float64, bool) {
 func (re *RuleEngine) GetRules() []DBRule {
 	re.mu.RLock()
 	defer re.mu.RUnlock()
-	
+
 	rules := make([]DBRule, len(re.rules))
 	copy(rules, re.rules)
 	return rules
@@ -0,0 +1,106 @@
+package scoring
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // schedules name IANA time zones; containers may lack zoneinfo
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// scheduleDays maps the day names accepted in a RuleSchedule
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ruleWindow is the compiled effective window and schedule of a rule
+type ruleWindow struct {
+	from     *time.Time
+	until    *time.Time
+	schedule *models.RuleSchedule
+	days     map[time.Weekday]bool // nil = every day
+	location *time.Location
+}
+
+// newRuleWindow validates a rule's effective window and schedule.
+// Day names are normalized to lower case in place.
+func newRuleWindow(from, until *time.Time, schedule *models.RuleSchedule) (*ruleWindow, error) {
+	if from != nil && until != nil && !until.After(*from) {
+		return nil, fmt.Errorf("effective_until must be after effective_from")
+	}
+
+	w := &ruleWindow{from: from, until: until, schedule: schedule, location: time.UTC}
+	if schedule == nil {
+		return w, nil
+	}
+
+	if schedule.StartHour < 0 || schedule.StartHour > 23 {
+		return nil, fmt.Errorf("schedule start_hour must be between 0 and 23")
+	}
+	if schedule.EndHour < 0 || schedule.EndHour > 24 || schedule.EndHour == schedule.StartHour {
+		return nil, fmt.Errorf("schedule end_hour must be between 0 and 24 and differ from start_hour")
+	}
+
+	if len(schedule.Days) > 0 {
+		w.days = make(map[time.Weekday]bool, len(schedule.Days))
+		for i, day := range schedule.Days {
+			day = strings.ToLower(day)
+			weekday, ok := scheduleDays[day]
+			if !ok {
+				return nil, fmt.Errorf("unknown schedule day %q (want mon, tue, wed, thu, fri, sat or sun)", schedule.Days[i])
+			}
+			schedule.Days[i] = day
+			w.days[weekday] = true
+		}
+	}
+
+	if schedule.Timezone != "" {
+		location, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown schedule timezone %q", schedule.Timezone)
+		}
+		w.location = location
+	}
+
+	return w, nil
+}
+
+// activeAt reports whether the rule applies to a transaction made at t
+func (w *ruleWindow) activeAt(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+	if w.from != nil && t.Before(*w.from) {
+		return false
+	}
+	if w.until != nil && !t.Before(*w.until) {
+		return false
+	}
+	if w.schedule == nil {
+		return true
+	}
+
+	local := t.In(w.location)
+	hour := local.Hour()
+	start, end := w.schedule.StartHour, w.schedule.EndHour
+
+	if start < end {
+		return (w.days == nil || w.days[local.Weekday()]) && hour >= start && hour < end
+	}
+
+	// The window wraps past midnight; early hours belong to the previous day's window
+	if hour >= start {
+		return w.days == nil || w.days[local.Weekday()]
+	}
+	if hour < end {
+		return w.days == nil || w.days[local.AddDate(0, 0, -1).Weekday()]
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// ErrRuleTestsFailed is returned when a rule change would break a rule's test cases
+var ErrRuleTestsFailed = errors.New("rule test cases failed")
+
+// RuleTestReport summarizes a run of every rule's test cases
+type RuleTestReport struct {
+	Rules    int               `json:"rules"`
+	Passed   int               `json:"passed"`
+	Failed   int               `json:"failed"`
+	Failures []RuleTestFailure `json:"failures,omitempty"`
+}
+
+// RuleTestFailure describes a test case whose outcome did not match, or a rule that could not be tested
+type RuleTestFailure struct {
+	RuleID     string `json:"rule_id"`
+	Case       string `json:"case,omitempty"`
+	ExpectFire bool   `json:"expect_fire"`
+	Fired      bool   `json:"fired"`
+	Error      string `json:"error,omitempty"` // set when the rule itself is invalid
+}
+
+// RuleTestError carries the report of a failed run; it wraps ErrRuleTestsFailed
+type RuleTestError struct {
+	Report *RuleTestReport
+}
+
+func (e *RuleTestError) Error() string {
+	ids := make([]string, 0, len(e.Report.Failures))
+	for _, f := range e.Report.Failures {
+		if f.Case != "" {
+			ids = append(ids, f.RuleID+"/"+f.Case)
+		} else {
+			ids = append(ids, f.RuleID)
+		}
+	}
+	return fmt.Sprintf("%v: %d of %d failed (%s)", ErrRuleTestsFailed,
+		e.Report.Failed, e.Report.Passed+e.Report.Failed, strings.Join(ids, ", "))
+}
+
+func (e *RuleTestError) Unwrap() error {
+	return ErrRuleTestsFailed
+}
+
+// RunRuleTests evaluates every rule's test cases against that rule's condition.
+// Disabled rules are tested too, so they still work when re-enabled. A rule whose
+// condition cannot be parsed is reported as a failure of the whole rule.
+func RunRuleTests(rules []models.Rule) *RuleTestReport {
+	re := NewRuleEngine(0)
+	report := &RuleTestReport{Rules: len(rules)}
+
+	for _, r := range rules {
+		if len(r.TestCases) == 0 {
+			continue
+		}
+
+		rule, err := parseDBRule(r)
+		if err != nil {
+			report.Failed++
+			report.Failures = append(report.Failures, RuleTestFailure{RuleID: r.ID, Error: err.Error()})
+			continue
+		}
+
+		for i, tc := range r.TestCases {
+			features, tx := tc.Features, tc.Transaction
+			fired := re.evaluateCondition(rule.Condition, buildEvaluationContext(&features, &tx))
+			if fired == tc.ExpectFire {
+				report.Passed++
+				continue
+			}
+
+			name := tc.Name
+			if name == "" {
+				name = fmt.Sprintf("case %d", i+1)
+			}
+			report.Failed++
+			report.Failures = append(report.Failures, RuleTestFailure{
+				RuleID:     r.ID,
+				Case:       name,
+				ExpectFire: tc.ExpectFire,
+				Fired:      fired,
+			})
+		}
+	}
+
+	return report
+}
+
+// CheckRuleTests runs every rule's test cases and returns a *RuleTestError if any fail
+func CheckRuleTests(rules []models.Rule) (*RuleTestReport, error) {
+	report := RunRuleTests(rules)
+	if report.Failed > 0 {
+		return report, &RuleTestError{Report: report}
+	}
+	return report, nil
+}
@@ -0,0 +1,64 @@
+package scoring
+
+import (
+	"math"
+	"time"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// ProbeThresholds define card-testing probes: small transactions that check a card works
+// before a large one
+type ProbeThresholds struct {
+	MaxAmount   float64       // largest amount counted as a probe
+	LargeAmount float64       // smallest amount a probe pattern leads up to
+	Window      time.Duration // how far back probes are counted; capped at the feature history span
+	MinProbes   int           // probes in the window needed for a probe pattern
+}
+
+// DefaultProbeThresholds are the probe thresholds of a scoring engine until SetProbeThresholds
+var DefaultProbeThresholds = ProbeThresholds{
+	MaxAmount:   5,
+	LargeAmount: 1000,
+	Window:      10 * time.Minute,
+	MinProbes:   1,
+}
+
+// sequenceFeatures sets the probe features of a transaction from the account's strictly
+// earlier transactions, oldest first: how many probes fell in the window before it, at
+// how many merchants and whether at its own, how long ago the last one was, and whether
+// the transaction is a large one following enough probes
+func sequenceFeatures(thresholds ProbeThresholds, tx *models.Transaction, history []*models.TransactionHistoryEntry, features *models.RiskFeatures) {
+	window := min(thresholds.Window, featureHistorySpan)
+	since := tx.CreatedAt.Add(-window)
+	merchant := normalizeMerchant(tx.Merchant)
+
+	merchants := make(map[string]bool)
+	var lastProbeAt time.Time
+	for _, entry := range history {
+		if entry.CreatedAt.Before(since) || !entry.CreatedAt.Before(tx.CreatedAt) {
+			continue
+		}
+		if entry.Amount <= 0 || entry.Amount > thresholds.MaxAmount {
+			continue
+		}
+
+		features.RecentSmallTxCount++
+		lastProbeAt = entry.CreatedAt
+		if probeMerchant := normalizeMerchant(entry.Merchant); probeMerchant != "" {
+			merchants[probeMerchant] = true
+			if probeMerchant == merchant {
+				features.ProbeSameMerchant = true
+			}
+		}
+	}
+
+	if features.RecentSmallTxCount == 0 {
+		return
+	}
+
+	features.ProbeMerchantCount = len(merchants)
+	features.MinutesSinceLastProbe = math.Round(tx.CreatedAt.Sub(lastProbeAt).Minutes()*10) / 10
+	features.FollowsProbePattern = features.RecentSmallTxCount >= thresholds.MinProbes &&
+		tx.Amount >= thresholds.LargeAmount
+}
@@ -0,0 +1,214 @@
+package scoring
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+)
+
+// maxAggregateWindow bounds how far back a window_aggregate condition may look
+const maxAggregateWindow = 30 * 24 * time.Hour
+
+// windowAggregate is a compiled window_aggregate condition, e.g.
+//
+//	{"type": "window_aggregate", "aggregate": "count", "window": "30m",
+//	 "filter": "channel == 'atm' and amount > 200", "operator": ">", "value": 3}
+//
+// It aggregates the account's transactions in (tx time - window, tx time) and the
+// transaction being scored, that match the filter.
+type windowAggregate struct {
+	key       string // identifies the value in RiskFeatures.WindowAggregates
+	aggregate string // count, sum, distinct
+	field     string // summed or distinct transaction field (empty for count)
+	window    time.Duration
+	filter    *compiledExpression // nil matches every transaction
+}
+
+// newWindowAggregate validates and compiles a window_aggregate condition
+func newWindowAggregate(cond RuleCondition) (*windowAggregate, error) {
+	agg := &windowAggregate{aggregate: cond.Aggregate, field: cond.Field}
+
+	switch cond.Aggregate {
+	case "count":
+		if cond.Field != "" {
+			return nil, fmt.Errorf("count aggregate does not take a field")
+		}
+	case "sum":
+		if transactionFields[cond.Field] != fieldNumber {
+			return nil, fmt.Errorf("sum aggregate needs a numeric transaction field, got %q", cond.Field)
+		}
+	case "distinct":
+		if _, ok := transactionFields[cond.Field]; !ok {
+			return nil, fmt.Errorf("distinct aggregate needs a transaction field, got %q", cond.Field)
+		}
+	default:
+		return nil, fmt.Errorf("unknown aggregate %q (want count, sum or distinct)", cond.Aggregate)
+	}
+
+	window, err := parseWindow(cond.Window)
+	if err != nil {
+		return nil, err
+	}
+	agg.window = window
+
+	if cond.Filter != "" {
+		filter, err := compileScopedExpression(transactionScope, cond.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter: %w", err)
+		}
+		agg.filter = filter
+	}
+
+	if !thresholdOperators[cond.Operator] {
+		return nil, fmt.Errorf("unknown operator %q", cond.Operator)
+	}
+	if _, ok := toFloat64(cond.Value); !ok {
+		return nil, fmt.Errorf("window_aggregate expects a numeric value, got %T", cond.Value)
+	}
+
+	agg.key = fmt.Sprintf("%s(%s)[%s]", cond.Aggregate, cond.Field, cond.Window)
+	if cond.Filter != "" {
+		agg.key += " where " + cond.Filter
+	}
+	return agg, nil
+}
+
+// parseWindow parses a Go duration, also accepting whole days such as "7d"
+func parseWindow(s string) (time.Duration, error) {
+	var window time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		window = d
+	}
+
+	if window <= 0 || window > maxAggregateWindow {
+		return 0, fmt.Errorf("window %q must be positive and at most 30d", s)
+	}
+	return window, nil
+}
+
+// evaluateWindowAggregate compares the value computed by computeWindowAggregates
+func (re *RuleEngine) evaluateWindowAggregate(cond RuleCondition, ctx evaluationContext) bool {
+	agg := cond.aggregate
+	if agg == nil {
+		var err error
+		if agg, err = newWindowAggregate(cond); err != nil {
+			return false
+		}
+	}
+
+	value, ok := ctx.Features.WindowAggregates[agg.key]
+	if !ok {
+		return false
+	}
+
+	return compareValues(cond.Operator, value, cond.Value)
+}
+
+// windowAggregates returns the distinct window aggregates used by the enabled rules
+func (re *RuleEngine) windowAggregates() []*windowAggregate {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var aggs []*windowAggregate
+
+	var walk func(cond RuleCondition)
+	walk = func(cond RuleCondition) {
+		if cond.Type == "window_aggregate" && cond.aggregate != nil && !seen[cond.aggregate.key] {
+			seen[cond.aggregate.key] = true
+			aggs = append(aggs, cond.aggregate)
+		}
+		for _, sub := range cond.Conditions {
+			walk(sub)
+		}
+	}
+
+	for _, rule := range re.rules {
+		if rule.Enabled {
+			walk(rule.Condition)
+		}
+	}
+	return aggs
+}
+
+// computeWindowAggregates computes every window aggregate the installed rules use,
+// from a single fetch of the account's transactions over the longest window.
+func (e *ScoringEngine) computeWindowAggregates(ctx context.Context, accountID uuid.UUID, tx *models.Transaction, features *models.RiskFeatures) {
+	aggs := e.ruleEngine.windowAggregates()
+	if len(aggs) == 0 {
+		return
+	}
+
+	var maxWindow time.Duration
+	for _, agg := range aggs {
+		if agg.window > maxWindow {
+			maxWindow = agg.window
+		}
+	}
+
+	history, err := e.txRepo.GetByAccountInRange(ctx, accountID, tx.CreatedAt.Add(-maxWindow), tx.CreatedAt)
+	if err != nil {
+		log.Warn().Err(err).Str("account_id", accountID.String()).Msg("Failed to fetch transactions for window aggregates")
+		return
+	}
+
+	// Filters only see transaction fields, so the contexts can be built once
+	noFeatures := &models.RiskFeatures{}
+	contexts := make([]evaluationContext, len(history))
+	for i, t := range history {
+		contexts[i] = buildEvaluationContext(noFeatures, t)
+	}
+
+	features.WindowAggregates = make(map[string]float64, len(aggs))
+	for _, agg := range aggs {
+		from := tx.CreatedAt.Add(-agg.window)
+		var total float64
+		distinct := make(map[string]bool)
+
+		for i, t := range history {
+			if !t.CreatedAt.After(from) {
+				continue
+			}
+			// Only strictly earlier transactions, as for the other rolling features
+			if t.ID != tx.ID && !t.CreatedAt.Before(tx.CreatedAt) {
+				continue
+			}
+			if agg.filter != nil && !agg.filter.Evaluate(contexts[i]) {
+				continue
+			}
+
+			switch agg.aggregate {
+			case "count":
+				total++
+			case "sum":
+				if v, ok := toFloat64(getFieldValue(agg.field, contexts[i])); ok {
+					total += v
+				}
+			case "distinct":
+				distinct[fmt.Sprintf("%v", getFieldValue(agg.field, contexts[i]))] = true
+			}
+		}
+
+		if agg.aggregate == "distinct" {
+			total = float64(len(distinct))
+		}
+		features.WindowAggregates[agg.key] = total
+	}
+}
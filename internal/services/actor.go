@@ -0,0 +1,18 @@
+package services
+
+import "github.com/google/uuid"
+
+// Actor identifies who made a change, for the audit trail
+type Actor struct {
+	UserID    uuid.UUID
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+func (a Actor) userID() *uuid.UUID {
+	if a.UserID == uuid.Nil {
+		return nil
+	}
+	return &a.UserID
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+// policyEntityID is the audit entity of the decision policy
+var policyEntityID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("decision_policy"))
+
+// DecisionPolicyService handles decision policy administration. Every change publishes
+// a new immutable version; the local policy engine installs it immediately and other
+// processes pick it up on their next reload.
+type DecisionPolicyService struct {
+	repo         *repositories.DecisionPolicyRepository
+	auditRepo    *repositories.AuditRepository
+	policyEngine *scoring.PolicyEngine
+}
+
+// NewDecisionPolicyService creates a new decision policy service
+func NewDecisionPolicyService(repo *repositories.DecisionPolicyRepository, auditRepo *repositories.AuditRepository, policyEngine *scoring.PolicyEngine) *DecisionPolicyService {
+	return &DecisionPolicyService{
+		repo:         repo,
+		auditRepo:    auditRepo,
+		policyEngine: policyEngine,
+	}
+}
+
+// DecisionPolicyRequest represents a request to publish a new decision policy
+type DecisionPolicyRequest struct {
+	Thresholds  *models.RiskThresholds `json:"thresholds"` // defaults to 25/50/70
+	Actions     map[string]string      `json:"actions"`    // missing risk levels take the built-in actions
+	Fallback    string                 `json:"fallback"`   // defaults to approve_with_monitoring
+	Segments    []models.PolicySegment `json:"segments"`
+	Description string                 `json:"description"`
+}
+
+// GetActivePolicy returns the latest published decision policy
+func (s *DecisionPolicyService) GetActivePolicy(ctx context.Context) (*models.DecisionPolicy, error) {
+	return s.repo.GetActiveDecisionPolicy(ctx)
+}
+
+// GetPolicyVersion returns a specific decision policy version
+func (s *DecisionPolicyService) GetPolicyVersion(ctx context.Context, version int) (*models.DecisionPolicy, error) {
+	return s.repo.GetVersion(ctx, version)
+}
+
+// ListPolicyVersions returns published decision policy versions, newest first
+func (s *DecisionPolicyService) ListPolicyVersions(ctx context.Context, page, pageSize int) ([]*models.DecisionPolicy, int, error) {
+	return s.repo.ListVersions(ctx, page, pageSize)
+}
+
+// ActivePolicyVersion returns the decision policy version installed in this process
+func (s *DecisionPolicyService) ActivePolicyVersion() int {
+	return s.policyEngine.Version()
+}
+
+// PublishPolicy validates and publishes a new decision policy version
+func (s *DecisionPolicyService) PublishPolicy(ctx context.Context, req *DecisionPolicyRequest, actor Actor) (*models.DecisionPolicy, error) {
+	policy := &models.DecisionPolicy{
+		Thresholds:  scoring.DefaultRiskThresholds,
+		Actions:     req.Actions,
+		Fallback:    req.Fallback,
+		Segments:    req.Segments,
+		Description: req.Description,
+		CreatedBy:   actor.userID(),
+	}
+	if req.Thresholds != nil {
+		policy.Thresholds = *req.Thresholds
+	}
+	if policy.Description == "" {
+		policy.Description = "update decision policy"
+	}
+
+	return s.publish(ctx, policy, "publish", actor)
+}
+
+// RollbackPolicy republishes an earlier decision policy version as the newest version
+func (s *DecisionPolicyService) RollbackPolicy(ctx context.Context, version int, actor Actor) (*models.DecisionPolicy, error) {
+	target, err := s.repo.GetVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &models.DecisionPolicy{
+		Thresholds:     target.Thresholds,
+		Actions:        target.Actions,
+		Fallback:       target.Fallback,
+		Segments:       target.Segments,
+		Description:    fmt.Sprintf("rollback to version %d", version),
+		RolledBackFrom: &version,
+		CreatedBy:      actor.userID(),
+	}
+
+	return s.publish(ctx, policy, "rollback", actor)
+}
+
+func (s *DecisionPolicyService) publish(ctx context.Context, policy *models.DecisionPolicy, action string, actor Actor) (*models.DecisionPolicy, error) {
+	if err := scoring.ValidateDecisionPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	previousVersion := 0
+	if previous, err := s.repo.GetActiveDecisionPolicy(ctx); err == nil {
+		previousVersion = previous.Version
+	}
+
+	version, err := s.repo.Publish(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := s.repo.GetVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policyEngine.LoadFromDB(ctx, s.repo); err != nil {
+		log.Error().Err(err).Msg("Failed to reload decision policy after change")
+	}
+
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventPolicyUpdate,
+		EntityID:   policyEntityID,
+		EntityType: "decision_policy",
+		UserID:     actor.userID(),
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"previous_version":        previousVersion,
+			"decision_policy_version": version,
+			"rolled_back_from":        policy.RolledBackFrom,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Int("decision_policy_version", version).
+			Msg("Failed to create audit log")
+	}
+
+	return published, nil
+}
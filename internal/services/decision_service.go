@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+)
+
+var (
+	// ErrInvalidStatusTransition is returned when a transaction cannot move to the requested status
+	ErrInvalidStatusTransition = errors.New("invalid transaction status transition")
+	// ErrInvalidReviewDecision is returned for a review outcome other than approve or decline
+	ErrInvalidReviewDecision = errors.New("review decision must be approve or decline")
+)
+
+// statusTransitions is the lifecycle after scoring: step-up and review holds resolve to
+// approved or declined, and a monitored approval can be escalated to manual review.
+// Approved and declined are final.
+var statusTransitions = map[string]map[string]bool{
+	models.TransactionStatusStepUpPending: {models.TransactionStatusApproved: true, models.TransactionStatusDeclined: true},
+	models.TransactionStatusReviewPending: {models.TransactionStatusApproved: true, models.TransactionStatusDeclined: true},
+	models.TransactionStatusMonitored:     {models.TransactionStatusReviewPending: true},
+}
+
+// DecisionService resolves the scoring decisions that hold a transaction: step-up
+// authentication results, manual review outcomes and escalations of monitored approvals
+type DecisionService struct {
+	txRepo        *repositories.TransactionRepository
+	riskScoreRepo *repositories.RiskScoreRepository
+	merchantRepo  *repositories.MerchantProfileRepository
+	auditRepo     *repositories.AuditRepository
+}
+
+// NewDecisionService creates a new decision service
+func NewDecisionService(txRepo *repositories.TransactionRepository, riskScoreRepo *repositories.RiskScoreRepository, merchantRepo *repositories.MerchantProfileRepository, auditRepo *repositories.AuditRepository) *DecisionService {
+	return &DecisionService{
+		txRepo:        txRepo,
+		riskScoreRepo: riskScoreRepo,
+		merchantRepo:  merchantRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// TransactionDecision is the scoring decision of a transaction and its current status
+type TransactionDecision struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Decision      string    `json:"decision"`
+	RiskLevel     string    `json:"risk_level"`
+	Score         float64   `json:"score"`
+	Status        string    `json:"status"`
+}
+
+// ReviewRequest is a manual review outcome
+type ReviewRequest struct {
+	Decision string `json:"decision" binding:"required"` // approve or decline
+	Note     string `json:"note"`
+}
+
+// GetDecision returns the scoring decision and current status of a transaction
+func (s *DecisionService) GetDecision(ctx context.Context, txID uuid.UUID) (*TransactionDecision, error) {
+	tx, err := s.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transactionDecision(ctx, tx)
+}
+
+// CompleteStepUp records the result of step-up authentication
+func (s *DecisionService) CompleteStepUp(ctx context.Context, txID uuid.UUID, passed bool, actor Actor) (*TransactionDecision, error) {
+	status := models.TransactionStatusDeclined
+	if passed {
+		status = models.TransactionStatusApproved
+	}
+
+	return s.transition(ctx, txID, models.TransactionStatusStepUpPending, status, "step_up", "", actor)
+}
+
+// Review records the outcome of a manual review
+func (s *DecisionService) Review(ctx context.Context, txID uuid.UUID, req *ReviewRequest, actor Actor) (*TransactionDecision, error) {
+	var status string
+	switch req.Decision {
+	case models.DecisionApprove:
+		status = models.TransactionStatusApproved
+	case models.DecisionDecline:
+		status = models.TransactionStatusDeclined
+	default:
+		return nil, fmt.Errorf("%w, got %q", ErrInvalidReviewDecision, req.Decision)
+	}
+
+	return s.transition(ctx, txID, models.TransactionStatusReviewPending, status, "review", req.Note, actor)
+}
+
+// Escalate holds a monitored approval for manual review
+func (s *DecisionService) Escalate(ctx context.Context, txID uuid.UUID, note string, actor Actor) (*TransactionDecision, error) {
+	return s.transition(ctx, txID, models.TransactionStatusMonitored, models.TransactionStatusReviewPending, "escalate", note, actor)
+}
+
+// transition moves a transaction from the expected status to the next one and records it
+func (s *DecisionService) transition(ctx context.Context, txID uuid.UUID, from, to, action, note string, actor Actor) (*TransactionDecision, error) {
+	tx, err := s.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.Status != from || !statusTransitions[from][to] {
+		return nil, fmt.Errorf("%w: transaction is %s, %s requires %s", ErrInvalidStatusTransition, tx.Status, action, from)
+	}
+
+	if err := s.txRepo.TransitionStatus(ctx, tx.ID, tx.CreatedAt, from, to); err != nil {
+		return nil, err
+	}
+	tx.Status = to
+
+	// Count the resolved decision in the merchant's profile
+	if decision, ok := models.ResolvedDecisions[to]; ok {
+		if err := s.merchantRepo.ResolveDecision(ctx, tx.ID, decision); err != nil {
+			log.Error().Err(err).
+				Str("transaction_id", tx.ID.String()).
+				Msg("Failed to update merchant profile with resolved decision")
+		}
+	}
+
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventDecision,
+		EntityID:   tx.ID,
+		EntityType: "transaction",
+		UserID:     actor.userID(),
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"from_status": from,
+			"to_status":   to,
+			"note":        note,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Str("transaction_id", tx.ID.String()).
+			Msg("Failed to create audit log")
+	}
+
+	log.Info().
+		Str("transaction_id", tx.ID.String()).
+		Str("action", action).
+		Str("status", from+"->"+to).
+		Msg("Transaction decision resolved")
+
+	return s.transactionDecision(ctx, tx)
+}
+
+func (s *DecisionService) transactionDecision(ctx context.Context, tx *models.Transaction) (*TransactionDecision, error) {
+	decision := &TransactionDecision{
+		TransactionID: tx.ID,
+		Status:        tx.Status,
+	}
+
+	score, err := s.riskScoreRepo.GetByTransactionID(ctx, tx.ID)
+	if err != nil && !errors.Is(err, repositories.ErrRiskScoreNotFound) {
+		return nil, err
+	}
+	if score != nil {
+		decision.Decision = score.Decision
+		decision.RiskLevel = score.RiskLevel
+		decision.Score = score.Score
+	}
+
+	return decision, nil
+}
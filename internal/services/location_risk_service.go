@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+// LocationRiskService administers country risk levels in the geo_locations reference
+// data. Updates are installed in the local gazetteer immediately; scoring workers pick
+// them up on their next reload.
+type LocationRiskService struct {
+	repo      *repositories.GeoLocationRepository
+	auditRepo *repositories.AuditRepository
+	gazetteer *scoring.Gazetteer
+}
+
+// NewLocationRiskService creates a new location risk service
+func NewLocationRiskService(repo *repositories.GeoLocationRepository, auditRepo *repositories.AuditRepository, gazetteer *scoring.Gazetteer) *LocationRiskService {
+	return &LocationRiskService{
+		repo:      repo,
+		auditRepo: auditRepo,
+		gazetteer: gazetteer,
+	}
+}
+
+// CountryRiskRequest represents a request to update a country's risk level
+type CountryRiskRequest struct {
+	RiskLevel    string `json:"risk_level" binding:"required"` // low, medium, high, sanctioned
+	IsSanctioned *bool  `json:"is_sanctioned"`                 // defaults to true only for the sanctioned level
+	CountryName  string `json:"country_name"`                  // required for a country not yet in the reference data
+}
+
+// ListCountries returns the country-level entries with their risk levels
+func (s *LocationRiskService) ListCountries(ctx context.Context) ([]models.GeoLocation, error) {
+	return s.repo.GetCountries(ctx)
+}
+
+// UpdateCountryRisk sets a country's risk level, creating its country-level entry when
+// only its cities are in the reference data. City-level risk levels are unchanged.
+func (s *LocationRiskService) UpdateCountryRisk(ctx context.Context, countryCode string, req *CountryRiskRequest, actor Actor) (*models.GeoLocation, error) {
+	countryCode = strings.ToUpper(strings.TrimSpace(countryCode))
+
+	before, err := s.repo.GetCountry(ctx, countryCode)
+	if err != nil && !errors.Is(err, repositories.ErrGeoLocationNotFound) {
+		return nil, err
+	}
+
+	loc := &models.GeoLocation{
+		CountryCode: countryCode,
+		CountryName: req.CountryName,
+		RiskLevel:   strings.ToLower(req.RiskLevel),
+	}
+	loc.IsSanctioned = loc.RiskLevel == scoring.LocationRiskSanctioned
+	if req.IsSanctioned != nil {
+		loc.IsSanctioned = *req.IsSanctioned
+	}
+	if loc.CountryName == "" && before != nil {
+		loc.CountryName = before.CountryName
+	}
+	if loc.CountryName == "" {
+		loc.CountryName = countryCode
+	}
+	if err := scoring.ValidateLocationRisk(loc); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpsertCountryRisk(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.gazetteer.Load(ctx, s.repo); err != nil {
+		log.Error().Err(err).Msg("Failed to reload geo locations after change")
+	}
+
+	action := "create"
+	if before != nil {
+		action = "update"
+	}
+	s.createAuditLog(ctx, countryCode, action, before, updated, actor)
+
+	return updated, nil
+}
+
+// createAuditLog records a country risk change with the acting user
+func (s *LocationRiskService) createAuditLog(ctx context.Context, countryCode, action string, before, after *models.GeoLocation, actor Actor) {
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventLocationRisk,
+		EntityID:   uuid.NewSHA1(uuid.NameSpaceURL, []byte("country:"+countryCode)),
+		EntityType: "geo_location",
+		UserID:     actor.userID(),
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"country_code": countryCode,
+			"before":       before,
+			"after":        after,
+		},
+	}
+
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Str("country_code", countryCode).
+			Msg("Failed to create audit log")
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+var (
+	// ErrInvalidMerchantOverride is returned for a merchant risk override outside 0-100
+	ErrInvalidMerchantOverride = errors.New("merchant risk override must be between 0 and 100")
+	// ErrTransactionWithoutMerchant is returned when charging back a transaction that has no merchant
+	ErrTransactionWithoutMerchant = errors.New("transaction has no merchant")
+	// ErrTransactionNotScored is returned when charging back a transaction that has not been scored
+	ErrTransactionNotScored = errors.New("transaction has not been scored")
+)
+
+// MerchantService administers merchant reputation profiles: viewing them, overriding
+// their risk scores and labeling chargebacks
+type MerchantService struct {
+	repo          *repositories.MerchantProfileRepository
+	txRepo        *repositories.TransactionRepository
+	riskScoreRepo *repositories.RiskScoreRepository
+	auditRepo     *repositories.AuditRepository
+}
+
+// NewMerchantService creates a new merchant service
+func NewMerchantService(repo *repositories.MerchantProfileRepository, txRepo *repositories.TransactionRepository, riskScoreRepo *repositories.RiskScoreRepository, auditRepo *repositories.AuditRepository) *MerchantService {
+	return &MerchantService{
+		repo:          repo,
+		txRepo:        txRepo,
+		riskScoreRepo: riskScoreRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// MerchantOverrideRequest represents a request to override a merchant's risk score
+type MerchantOverrideRequest struct {
+	RiskScore *float64 `json:"risk_score" binding:"required"` // 0-100
+	Reason    string   `json:"reason" binding:"required"`
+}
+
+// ChargebackRequest labels a transaction as charged back
+type ChargebackRequest struct {
+	ChargedBackAt *time.Time `json:"charged_back_at"` // defaults to now
+	Reason        string     `json:"reason"`
+}
+
+// ListProfiles returns merchant profiles with their current risk scores, busiest first
+func (s *MerchantService) ListProfiles(ctx context.Context, page, pageSize int) ([]*models.MerchantProfile, int, error) {
+	profiles, total, err := s.repo.List(ctx, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	for _, profile := range profiles {
+		profile.RiskScore = scoring.MerchantRiskScore(profile, now)
+	}
+	return profiles, total, nil
+}
+
+// GetProfile returns a merchant profile with its current risk score
+func (s *MerchantService) GetProfile(ctx context.Context, id int64) (*models.MerchantProfile, error) {
+	profile, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.RiskScore = scoring.MerchantRiskScore(profile, time.Now())
+	return profile, nil
+}
+
+// SetOverride replaces a merchant's computed risk score. Scoring uses the override from
+// the next transaction at the merchant.
+func (s *MerchantService) SetOverride(ctx context.Context, id int64, req *MerchantOverrideRequest, actor Actor) (*models.MerchantProfile, error) {
+	if *req.RiskScore < 0 || *req.RiskScore > 100 {
+		return nil, fmt.Errorf("%w, got %v", ErrInvalidMerchantOverride, *req.RiskScore)
+	}
+
+	return s.updateOverride(ctx, id, req.RiskScore, req.Reason, "override", actor)
+}
+
+// ClearOverride restores a merchant's computed risk score
+func (s *MerchantService) ClearOverride(ctx context.Context, id int64, actor Actor) (*models.MerchantProfile, error) {
+	return s.updateOverride(ctx, id, nil, "", "clear_override", actor)
+}
+
+func (s *MerchantService) updateOverride(ctx context.Context, id int64, riskScore *float64, reason, action string, actor Actor) (*models.MerchantProfile, error) {
+	before, err := s.GetProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var userID *uuid.UUID
+	if riskScore != nil {
+		userID = actor.userID()
+	}
+	updated, err := s.repo.SetOverride(ctx, id, riskScore, reason, userID)
+	if err != nil {
+		return nil, err
+	}
+	updated.RiskScore = scoring.MerchantRiskScore(updated, time.Now())
+
+	s.createAuditLog(ctx, merchantEntityID(id), "merchant_profile", action, models.JSONB{
+		"merchant_key": updated.MerchantKey,
+		"before":       before,
+		"after":        updated,
+	}, actor)
+
+	return updated, nil
+}
+
+// RecordChargeback labels a transaction as charged back, counting it against its
+// merchant. A transaction scored before merchant profiles were enabled is first added to
+// its merchant's profile with the decision it was scored with, or resolved to.
+func (s *MerchantService) RecordChargeback(ctx context.Context, txID uuid.UUID, req *ChargebackRequest, actor Actor) (*models.MerchantProfile, error) {
+	tx, err := s.txRepo.GetByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	merchantKey := scoring.MerchantKey(tx.Merchant, tx.MerchantCategory)
+	if merchantKey == "" {
+		return nil, ErrTransactionWithoutMerchant
+	}
+
+	chargedBackAt := time.Now()
+	if req.ChargedBackAt != nil {
+		chargedBackAt = *req.ChargedBackAt
+	}
+
+	profile, err := s.repo.RecordChargeback(ctx, tx.ID, chargedBackAt)
+	if errors.Is(err, repositories.ErrMerchantTransactionNotFound) {
+		score, scoreErr := s.riskScoreRepo.GetByTransactionID(ctx, tx.ID)
+		if errors.Is(scoreErr, repositories.ErrRiskScoreNotFound) {
+			return nil, ErrTransactionNotScored
+		}
+		if scoreErr != nil {
+			return nil, scoreErr
+		}
+		decision := score.Decision
+		if resolved, ok := models.ResolvedDecisions[tx.Status]; ok {
+			decision = resolved
+		}
+		if err := s.repo.RecordTransaction(ctx, merchantKey, tx, decision); err != nil {
+			return nil, err
+		}
+		profile, err = s.repo.RecordChargeback(ctx, tx.ID, chargedBackAt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.RiskScore = scoring.MerchantRiskScore(profile, time.Now())
+
+	s.createAuditLog(ctx, tx.ID, "transaction", "chargeback", models.JSONB{
+		"merchant_key":    merchantKey,
+		"charged_back_at": chargedBackAt,
+		"reason":          req.Reason,
+	}, actor)
+
+	return profile, nil
+}
+
+// merchantEntityID is the audit entity ID of a merchant profile
+func merchantEntityID(id int64) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("merchant:"+strconv.FormatInt(id, 10)))
+}
+
+// createAuditLog records a merchant profile change with the acting user
+func (s *MerchantService) createAuditLog(ctx context.Context, entityID uuid.UUID, entityType, action string, payload models.JSONB, actor Actor) {
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventMerchantUpdate,
+		EntityID:   entityID,
+		EntityType: entityType,
+		UserID:     actor.userID(),
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload:    payload,
+	}
+
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Str("entity_id", entityID.String()).
+			Str("action", action).
+			Msg("Failed to create audit log")
+	}
+}
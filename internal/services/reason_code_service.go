@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+// ReasonCodeService administers the reason-code catalog and renders the reason
+// codes of stored decisions. Saves install the catalog in the local process
+// immediately; scoring workers pick it up on their next reload.
+type ReasonCodeService struct {
+	repo          *repositories.ReasonCodeRepository
+	riskScoreRepo *repositories.RiskScoreRepository
+	txRepo        *repositories.TransactionRepository
+	auditRepo     *repositories.AuditRepository
+	catalog       *scoring.ReasonCodeCatalog
+}
+
+// NewReasonCodeService creates a new reason code service
+func NewReasonCodeService(
+	repo *repositories.ReasonCodeRepository,
+	riskScoreRepo *repositories.RiskScoreRepository,
+	txRepo *repositories.TransactionRepository,
+	auditRepo *repositories.AuditRepository,
+	catalog *scoring.ReasonCodeCatalog,
+) *ReasonCodeService {
+	return &ReasonCodeService{
+		repo:          repo,
+		riskScoreRepo: riskScoreRepo,
+		txRepo:        txRepo,
+		auditRepo:     auditRepo,
+		catalog:       catalog,
+	}
+}
+
+// DecisionReasons are the customer-facing reason codes of a scoring decision
+type DecisionReasons struct {
+	TransactionID uuid.UUID               `json:"transaction_id"`
+	RiskLevel     string                  `json:"risk_level"`
+	ReasonCodes   []models.DecisionReason `json:"reason_codes"`
+}
+
+// ListReasonCodes returns the whole catalog
+func (s *ReasonCodeService) ListReasonCodes(ctx context.Context) ([]models.ReasonCode, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// GetReasonCode returns a catalog entry by code
+func (s *ReasonCodeService) GetReasonCode(ctx context.Context, code string) (*models.ReasonCode, error) {
+	return s.repo.GetByCode(ctx, code)
+}
+
+// SaveReasonCode creates or replaces a catalog entry. The catalog as a whole is
+// validated, so a rule or anomaly type cannot be mapped to two codes.
+func (s *ReasonCodeService) SaveReasonCode(ctx context.Context, rc models.ReasonCode, actor Actor) (*models.ReasonCode, error) {
+	if err := scoring.ValidateReasonCode(&rc); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *models.ReasonCode
+	candidate := make([]models.ReasonCode, 0, len(codes)+1)
+	for i := range codes {
+		if codes[i].Code == rc.Code {
+			before = &codes[i]
+			continue
+		}
+		candidate = append(candidate, codes[i])
+	}
+	if err := scoring.ValidateReasonCodes(append(candidate, rc)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Upsert(ctx, &rc); err != nil {
+		return nil, err
+	}
+
+	s.reloadCatalog(ctx)
+
+	action := "create"
+	if before != nil {
+		action = "update"
+	}
+	s.createAuditLog(ctx, rc.Code, action, before, &rc, actor)
+
+	return &rc, nil
+}
+
+// DeleteReasonCode removes a catalog entry. The fallback code cannot be removed;
+// contributions from unmapped rules and anomalies are attributed to it.
+func (s *ReasonCodeService) DeleteReasonCode(ctx context.Context, code string, actor Actor) error {
+	if code == scoring.FallbackReasonCode {
+		return fmt.Errorf("%w: the fallback code %s cannot be deleted", scoring.ErrInvalidReasonCode, code)
+	}
+
+	before, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, code); err != nil {
+		return err
+	}
+
+	s.reloadCatalog(ctx)
+	s.createAuditLog(ctx, code, "delete", before, nil, actor)
+	return nil
+}
+
+// GetDecisionReasons returns the reason codes stored with a transaction's risk score,
+// with messages in the first of the preferred languages the catalog has
+func (s *ReasonCodeService) GetDecisionReasons(ctx context.Context, txID uuid.UUID, languages []string) (*DecisionReasons, error) {
+	score, err := s.riskScoreRepo.GetByTransactionID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *models.Transaction
+	if len(languages) > 0 {
+		tx, err = s.txRepo.GetByID(ctx, txID)
+		if err != nil && !errors.Is(err, repositories.ErrTransactionNotFound) {
+			return nil, err
+		}
+	}
+
+	reasons := score.ReasonCodes
+	if tx != nil {
+		reasons = s.catalog.Render(reasons, tx, languages)
+	}
+
+	return &DecisionReasons{
+		TransactionID: txID,
+		RiskLevel:     score.RiskLevel,
+		ReasonCodes:   reasons,
+	}, nil
+}
+
+// reloadCatalog installs the saved catalog in the local process
+func (s *ReasonCodeService) reloadCatalog(ctx context.Context) {
+	if err := s.catalog.Load(ctx, s.repo); err != nil {
+		log.Error().Err(err).Msg("Failed to reload reason codes after change")
+	}
+}
+
+// createAuditLog records a catalog change with the acting user
+func (s *ReasonCodeService) createAuditLog(ctx context.Context, code, action string, before, after *models.ReasonCode, actor Actor) {
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventReasonCodeUpdate,
+		EntityID:   uuid.NewSHA1(uuid.NameSpaceURL, []byte("reason_code:"+code)),
+		EntityType: "reason_code",
+		UserID:     actor.userID(),
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"code":   code,
+			"before": before,
+			"after":  after,
+		},
+	}
+
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Str("code", code).
+			Msg("Failed to create audit log")
+	}
+}
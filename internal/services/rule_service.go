@@ -0,0 +1,538 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/enterprise/risk-engine/internal/models"
+	"github.com/enterprise/risk-engine/internal/repositories"
+	"github.com/enterprise/risk-engine/internal/scoring"
+)
+
+// RuleService handles rule administration. Every save publishes a new immutable
+// rule-set version; the local rule engine installs it immediately and other
+// processes pick it up on their next reload.
+type RuleService struct {
+	ruleRepo   *repositories.RuleRepository
+	auditRepo  *repositories.AuditRepository
+	ruleEngine *scoring.RuleEngine
+}
+
+// NewRuleService creates a new rule service
+func NewRuleService(ruleRepo *repositories.RuleRepository, auditRepo *repositories.AuditRepository, ruleEngine *scoring.RuleEngine) *RuleService {
+	return &RuleService{
+		ruleRepo:   ruleRepo,
+		auditRepo:  auditRepo,
+		ruleEngine: ruleEngine,
+	}
+}
+
+// RuleRequest represents a rule create or update request
+type RuleRequest struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	Condition   scoring.RuleCondition `json:"condition"`
+	ScoreImpact float64               `json:"score_impact"`
+	RiskLevel   string                `json:"risk_level" binding:"required"`
+	Priority    int                   `json:"priority"`
+	Enabled     *bool                 `json:"enabled"`
+	Mode        string                `json:"mode"`  // live (default) or shadow
+	Group       string                `json:"group"` // triggered rules in a group contribute at most once
+
+	EffectiveFrom  *time.Time           `json:"effective_from"`
+	EffectiveUntil *time.Time           `json:"effective_until"` // the rule is auto-disabled once it passes
+	Schedule       *models.RuleSchedule `json:"schedule"`
+
+	TestCases []models.RuleTestCase `json:"test_cases"`
+}
+
+// change describes the rule-set version published by this actor
+func (a Actor) change(description string) repositories.RuleSetChange {
+	return repositories.RuleSetChange{
+		CreatedBy:   a.userID(),
+		Description: description,
+	}
+}
+
+// ListRules returns all rules, including disabled ones
+func (s *RuleService) ListRules(ctx context.Context) ([]models.Rule, error) {
+	return s.ruleRepo.GetAll(ctx)
+}
+
+// GetRule returns a single rule
+func (s *RuleService) GetRule(ctx context.Context, id string) (*models.Rule, error) {
+	return s.ruleRepo.GetByID(ctx, id)
+}
+
+// CreateRule validates and stores a new rule
+func (s *RuleService) CreateRule(ctx context.Context, req *RuleRequest, actor Actor) (*models.Rule, error) {
+	rule, err := buildRule(req.ID, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRuleTests(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	version, err := s.ruleRepo.Create(ctx, rule, actor.change("create rule "+rule.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	s.reloadEngine(ctx)
+	s.createAuditLog(ctx, rule.ID, "create", nil, rule, version, actor)
+	return rule, nil
+}
+
+// UpdateRule validates and replaces an existing rule's definition.
+// Enabled is left unchanged when the request omits it.
+func (s *RuleService) UpdateRule(ctx context.Context, id string, req *RuleRequest, actor Actor) (*models.Rule, error) {
+	before, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := buildRule(id, req, before.Enabled)
+	if err != nil {
+		return nil, err
+	}
+	rule.CreatedAt = before.CreatedAt
+
+	if err := s.checkRuleTests(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	version, err := s.ruleRepo.Update(ctx, rule, actor.change("update rule "+rule.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	s.reloadEngine(ctx)
+	s.createAuditLog(ctx, rule.ID, "update", before, rule, version, actor)
+	return rule, nil
+}
+
+// SetRuleEnabled enables or disables a rule
+func (s *RuleService) SetRuleEnabled(ctx context.Context, id string, enabled bool, actor Actor) (*models.Rule, error) {
+	before, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+
+	version, err := s.ruleRepo.SetEnabled(ctx, id, enabled, actor.change(action+" rule "+id))
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.reloadEngine(ctx)
+	s.createAuditLog(ctx, id, action, before, after, version, actor)
+	return after, nil
+}
+
+// TestRules runs every stored rule's test cases
+func (s *RuleService) TestRules(ctx context.Context) (*scoring.RuleTestReport, error) {
+	rules, err := s.ruleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scoring.RunRuleTests(rules), nil
+}
+
+// checkRuleTests runs the whole rule set's test cases with candidate in place of the
+// stored rule of the same ID, returning a *scoring.RuleTestError if any fail
+func (s *RuleService) checkRuleTests(ctx context.Context, candidate *models.Rule) error {
+	rules, err := s.ruleRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range rules {
+		if rules[i].ID == candidate.ID {
+			rules[i] = *candidate
+			replaced = true
+		}
+	}
+	if !replaced {
+		rules = append(rules, *candidate)
+	}
+
+	_, err = scoring.CheckRuleTests(rules)
+	return err
+}
+
+// RuleSetDiff lists the rule differences between two rule-set versions
+type RuleSetDiff struct {
+	FromVersion int                               `json:"from_version"`
+	ToVersion   int                               `json:"to_version"`
+	Added       []models.Rule                     `json:"added"`
+	Removed     []models.Rule                     `json:"removed"`
+	Changed     map[string]map[string]interface{} `json:"changed"`               // rule ID -> field -> {before, after}
+	Aggregation map[string]interface{}            `json:"aggregation,omitempty"` // {before, after} when the strategy changed
+}
+
+// ListRuleSetVersions returns published rule-set versions, newest first
+func (s *RuleService) ListRuleSetVersions(ctx context.Context, page, pageSize int) ([]*models.RuleSetVersion, int, error) {
+	return s.ruleRepo.ListRuleSetVersions(ctx, page, pageSize)
+}
+
+// ActiveRuleSetVersion returns the rule-set version installed in this process's rule engine
+func (s *RuleService) ActiveRuleSetVersion() int {
+	return s.ruleEngine.Version()
+}
+
+// GetRuleSetVersion returns a rule-set version with its rules
+func (s *RuleService) GetRuleSetVersion(ctx context.Context, version int) (*models.RuleSetVersion, error) {
+	return s.ruleRepo.GetRuleSetVersion(ctx, version)
+}
+
+// DiffRuleSetVersions compares two rule-set versions
+func (s *RuleService) DiffRuleSetVersions(ctx context.Context, fromVersion, toVersion int) (*RuleSetDiff, error) {
+	from, err := s.ruleRepo.GetRuleSetVersion(ctx, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.ruleRepo.GetRuleSetVersion(ctx, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &RuleSetDiff{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Added:       []models.Rule{},
+		Removed:     []models.Rule{},
+		Changed:     make(map[string]map[string]interface{}),
+	}
+
+	fromRules := make(map[string]*models.Rule, len(from.Rules))
+	for i := range from.Rules {
+		fromRules[from.Rules[i].ID] = &from.Rules[i]
+	}
+
+	seen := make(map[string]bool, len(to.Rules))
+	for i := range to.Rules {
+		rule := &to.Rules[i]
+		seen[rule.ID] = true
+
+		old, ok := fromRules[rule.ID]
+		if !ok {
+			diff.Added = append(diff.Added, *rule)
+			continue
+		}
+		if changes := diffRuleFields(ruleAuditFields(old), ruleAuditFields(rule)); len(changes) > 0 {
+			diff.Changed[rule.ID] = changes
+		}
+	}
+
+	for _, rule := range from.Rules {
+		if !seen[rule.ID] {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+
+	if from.Aggregation != to.Aggregation {
+		diff.Aggregation = map[string]interface{}{
+			"before": from.Aggregation,
+			"after":  to.Aggregation,
+		}
+	}
+
+	return diff, nil
+}
+
+// RollbackRuleSet republishes an earlier rule-set version as the new active version
+func (s *RuleService) RollbackRuleSet(ctx context.Context, version int, actor Actor) (*models.RuleSetVersion, error) {
+	previous, err := s.ruleRepo.GetActiveRuleSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newVersion, err := s.ruleRepo.Rollback(ctx, version, actor.change(fmt.Sprintf("rollback to version %d", version)))
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := s.ruleRepo.GetRuleSetVersion(ctx, newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.reloadEngine(ctx)
+
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventRuleUpdate,
+		EntityID:   RuleEntityID("rule-set"),
+		EntityType: "rule_set",
+		UserID:     actor.userID(),
+		Action:     "rollback",
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"rollback_to":      version,
+			"previous_version": previous.Version,
+			"rule_set_version": newVersion,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Int("rule_set_version", newVersion).
+			Msg("Failed to create audit log")
+	}
+
+	return published, nil
+}
+
+// SetAggregation validates and publishes a new score aggregation strategy for the rule set
+func (s *RuleService) SetAggregation(ctx context.Context, aggregation models.ScoreAggregation, actor Actor) (*models.RuleSetVersion, error) {
+	if err := scoring.ValidateAggregation(&aggregation); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.ruleRepo.GetActiveRuleSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newVersion, err := s.ruleRepo.SetAggregation(ctx, aggregation, actor.change("set score aggregation to "+aggregation.Strategy))
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := s.ruleRepo.GetRuleSetVersion(ctx, newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.reloadEngine(ctx)
+
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventRuleUpdate,
+		EntityID:   RuleEntityID("rule-set"),
+		EntityType: "rule_set",
+		UserID:     actor.userID(),
+		Action:     "set_aggregation",
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"previous_version": previous.Version,
+			"rule_set_version": newVersion,
+			"before":           previous.Aggregation,
+			"after":            aggregation,
+		},
+	}
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Int("rule_set_version", newVersion).
+			Msg("Failed to create audit log")
+	}
+
+	return published, nil
+}
+
+// ExpireRules disables every rule past its effective_until, publishing one new rule-set
+// version and writing an "expire" audit log entry per rule
+func (s *RuleService) ExpireRules(ctx context.Context) ([]models.Rule, error) {
+	expired, version, err := s.ruleRepo.DisableExpired(ctx, time.Now(), repositories.RuleSetChange{
+		Description: "expire rules past effective_until",
+	})
+	if err != nil || len(expired) == 0 {
+		return nil, err
+	}
+
+	s.reloadEngine(ctx)
+
+	for i := range expired {
+		after := &expired[i]
+		before := *after
+		before.Enabled = true
+		s.createAuditLog(ctx, after.ID, "expire", &before, after, version, Actor{UserAgent: "rule-expiry-job"})
+	}
+
+	log.Info().
+		Int("expired_count", len(expired)).
+		Int("rule_set_version", version).
+		Msg("Expired rules disabled")
+	return expired, nil
+}
+
+// StartExpiryJob periodically disables expired rules until ctx is cancelled
+func (s *RuleService) StartExpiryJob(ctx context.Context, period time.Duration) {
+	if period <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ExpireRules(ctx); err != nil {
+					log.Error().Err(err).Msg("Failed to expire rules")
+				}
+			}
+		}
+	}()
+}
+
+// buildRule validates a request and converts it into a rules table row
+func buildRule(id string, req *RuleRequest, defaultEnabled bool) (*models.Rule, error) {
+	enabled := defaultEnabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	dbRule := scoring.DBRule{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		Condition:   req.Condition,
+		ScoreImpact: req.ScoreImpact,
+		RiskLevel:   req.RiskLevel,
+		Priority:    req.Priority,
+		Enabled:     enabled,
+		Mode:        req.Mode,
+		Group:       req.Group,
+
+		EffectiveFrom:  req.EffectiveFrom,
+		EffectiveUntil: req.EffectiveUntil,
+		Schedule:       req.Schedule,
+	}
+	if err := scoring.ValidateRule(&dbRule); err != nil {
+		return nil, err
+	}
+
+	condition, err := json.Marshal(dbRule.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode condition: %w", err)
+	}
+
+	return &models.Rule{
+		ID:             dbRule.ID,
+		Name:           dbRule.Name,
+		Description:    dbRule.Description,
+		Condition:      condition,
+		ScoreImpact:    dbRule.ScoreImpact,
+		RiskLevel:      dbRule.RiskLevel,
+		Priority:       dbRule.Priority,
+		Enabled:        dbRule.Enabled,
+		Mode:           dbRule.Mode,
+		Group:          dbRule.Group,
+		EffectiveFrom:  dbRule.EffectiveFrom,
+		EffectiveUntil: dbRule.EffectiveUntil,
+		Schedule:       dbRule.Schedule,
+		TestCases:      req.TestCases,
+	}, nil
+}
+
+// reloadEngine installs the newly published rule-set version in the local rule engine
+func (s *RuleService) reloadEngine(ctx context.Context) {
+	if err := s.ruleEngine.LoadRulesFromDB(ctx, s.ruleRepo); err != nil {
+		log.Error().Err(err).Msg("Failed to reload rules after change")
+	}
+}
+
+// createAuditLog records a rule change with the acting user and a before/after diff
+func (s *RuleService) createAuditLog(ctx context.Context, ruleID, action string, before, after *models.Rule, version int, actor Actor) {
+	beforeFields := ruleAuditFields(before)
+	afterFields := ruleAuditFields(after)
+
+	auditLog := &models.AuditLog{
+		EventType:  models.AuditEventRuleUpdate,
+		EntityID:   RuleEntityID(ruleID),
+		EntityType: "rule",
+		UserID:     actor.userID(),
+		Action:     action,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Payload: models.JSONB{
+			"rule_id":          ruleID,
+			"rule_set_version": version,
+			"before":           beforeFields,
+			"after":            afterFields,
+			"changes":          diffRuleFields(beforeFields, afterFields),
+		},
+	}
+
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		log.Error().Err(err).
+			Str("rule_id", ruleID).
+			Msg("Failed to create audit log")
+	}
+}
+
+// RuleEntityID derives a stable audit entity ID from a rule's string ID,
+// so a rule's history can be fetched with AuditRepository.GetByEntityID.
+func RuleEntityID(ruleID string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("rule:"+ruleID))
+}
+
+// ruleAuditFields flattens a rule into the fields tracked by the audit trail
+func ruleAuditFields(rule *models.Rule) map[string]interface{} {
+	if rule == nil {
+		return nil
+	}
+
+	var condition interface{}
+	if err := json.Unmarshal(rule.Condition, &condition); err != nil {
+		condition = string(rule.Condition)
+	}
+
+	return map[string]interface{}{
+		"name":         rule.Name,
+		"description":  rule.Description,
+		"condition":    condition,
+		"score_impact": rule.ScoreImpact,
+		"risk_level":   rule.RiskLevel,
+		"priority":     rule.Priority,
+		"enabled":      rule.Enabled,
+		"mode":         rule.Mode,
+		"group":        rule.Group,
+
+		"effective_from":  rule.EffectiveFrom,
+		"effective_until": rule.EffectiveUntil,
+		"schedule":        rule.Schedule,
+		"test_cases":      len(rule.TestCases),
+	}
+}
+
+// diffRuleFields returns the fields whose values differ, as {"before": x, "after": y}
+func diffRuleFields(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for field, newValue := range after {
+		oldValue, existed := before[field]
+		if existed && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes[field] = map[string]interface{}{
+			"before": oldValue,
+			"after":  newValue,
+		}
+	}
+	return changes
+}